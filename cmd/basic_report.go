@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/mprimi/go-bench-away/v1/client"
+	"github.com/mprimi/go-bench-away/v1/core"
 	"github.com/mprimi/go-bench-away/v1/reports"
 
 	"github.com/google/subcommands"
@@ -15,12 +16,16 @@ import (
 
 type basicReportCmd struct {
 	baseCommand
+	clientOptions       reportClientOptions
 	skipTimeOp          bool
 	skipSpeed           bool
 	benchmarkFilterExpr string
 	hiddenResultsTable  bool
 	outputPath          string
 	reportCfg           reports.ReportConfig
+	outputOptions       reportOutputOptions
+	benchmarksSet       string
+	resources           bool
 	customLabels        string
 }
 
@@ -35,6 +40,7 @@ func basicReportCommand() subcommands.Command {
 }
 
 func (cmd *basicReportCmd) SetFlags(f *flag.FlagSet) {
+	cmd.clientOptions.setFlags(f)
 	f.StringVar(&cmd.outputPath, "output", "report.html", "Output report (HTML)")
 	f.StringVar(&cmd.reportCfg.Title, "title", "", "Title of the report (auto-generated if empty)")
 	f.BoolVar(&cmd.skipTimeOp, "no_timeop", false, "Do not include time/op graph and table")
@@ -42,9 +48,12 @@ func (cmd *basicReportCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&cmd.benchmarkFilterExpr, "benchmark_filter", "", "Regular expression to filter experiments based on benchmark name")
 	f.BoolVar(&cmd.hiddenResultsTable, "hide_table", false, "Hide the results table by default")
 	f.StringVar(&cmd.customLabels, "labels", "", "Use custom labels (comma separated, no spaces, e.g.: \"a,b,c\")")
+	cmd.outputOptions.setFlags(f)
+	f.StringVar(&cmd.benchmarksSet, "benchmarks", "", "Benchmarks to include when jobs ran different sets: union (default, missing values shown as gaps) or intersection")
+	f.BoolVar(&cmd.resources, "resources", false, "Include charts of the resource usage (CPU, memory, context switches) sampled while jobs ran")
 }
 
-func (cmd *basicReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (cmd *basicReportCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
 	if rootOptions.verbose {
 		fmt.Printf("%s args: %v\n", cmd.name, f.Args())
 	}
@@ -55,10 +64,7 @@ func (cmd *basicReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...inte
 		return subcommands.ExitUsageError
 	}
 
-	c, err := client.NewClient(
-		rootOptions.natsServerUrl,
-		rootOptions.credentials,
-		rootOptions.namespace,
+	c, done, err := cmd.clientOptions.newReportClient(
 		client.InitJobsRepository(),
 		client.InitArtifactsStore(),
 		client.Verbose(rootOptions.verbose),
@@ -68,9 +74,9 @@ func (cmd *basicReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...inte
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return subcommands.ExitFailure
 	}
-	defer c.Close()
+	defer done()
 
-	dataTable, err := reports.CreateDataTable(c, jobIds...)
+	dataTable, err := reports.CreateDataTableWithContext(ctx, c, jobIds...)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return subcommands.ExitFailure
@@ -80,6 +86,23 @@ func (cmd *basicReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...inte
 		cmd.reportCfg.Verbose()
 	}
 
+	if err := cmd.outputOptions.configure(&cmd.reportCfg); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitUsageError
+	}
+
+	if cmd.benchmarksSet != "" {
+		benchmarksSet, err := reports.ParseBenchmarksSet(cmd.benchmarksSet)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitUsageError
+		}
+		if err := cmd.reportCfg.SetBenchmarksSet(benchmarksSet); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitUsageError
+		}
+	}
+
 	if cmd.customLabels != "" {
 		cmd.reportCfg.SetCustomLabels(strings.Split(cmd.customLabels, ","))
 	}
@@ -102,6 +125,15 @@ func (cmd *basicReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...inte
 		)
 	}
 
+	if cmd.resources {
+		section, err := resourceUsageSection(c, jobIds)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitFailure
+		}
+		cmd.reportCfg.AddSections(section)
+	}
+
 	file, err := os.Create(cmd.outputPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
@@ -118,3 +150,20 @@ func (cmd *basicReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...inte
 	fmt.Printf("Created report: %s\n", cmd.outputPath)
 	return subcommands.ExitSuccess
 }
+
+// Section charting the resource usage sampled while the given jobs ran
+func resourceUsageSection(c reportClient, jobIds []string) (reports.SectionConfig, error) {
+	jobs := make([]*core.JobRecord, len(jobIds))
+	for i, jobId := range jobIds {
+		job, _, err := c.LoadJob(jobId)
+		if err != nil {
+			return nil, err
+		}
+		jobs[i] = job
+	}
+	usage, err := reports.LoadResourceUsage(c, jobs)
+	if err != nil {
+		return nil, err
+	}
+	return reports.ResourceUsageChart("", usage), nil
+}
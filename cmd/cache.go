@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/google/subcommands"
+)
+
+type cacheCmd struct {
+	baseCommand
+	all bool
+}
+
+func cacheCommand() subcommands.Command {
+	return &cacheCmd{
+		baseCommand: baseCommand{
+			name:     "cache",
+			synopsis: "Manage the local cache of completed jobs and results",
+			usage: "cache info\n" +
+				"cache prune [-all]\n" +
+				"\n" +
+				"Report commands cache completed jobs and their results, see top-level options -cache_dir and -cache_size.\n",
+		},
+	}
+}
+
+func (cmd *cacheCmd) SetFlags(f *flag.FlagSet) {
+	f.BoolVar(&cmd.all, "all", false, "Remove all cached files (action: prune)")
+}
+
+func (cmd *cacheCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if rootOptions.verbose {
+		fmt.Printf("%s args: %v\n", cmd.name, f.Args())
+	}
+
+	if f.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "Missing action\n%s", cmd.usage)
+		return subcommands.ExitUsageError
+	}
+
+	// Options follow the action
+	action := f.Arg(0)
+	if err := f.Parse(f.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitUsageError
+	}
+
+	jobsCache, err := openCache()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitFailure
+	}
+
+	switch action {
+	case "info":
+		files, size, err := jobsCache.Usage()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitFailure
+		}
+		fmt.Printf("Cache directory: %s\n", jobsCache.Dir())
+		fmt.Printf("Files: %d, size: %.1f MB (limit: %d MB)\n", files, float64(size)/(1<<20), rootOptions.cacheSizeMB)
+
+	case "prune":
+		var removed int
+		var freed int64
+		if cmd.all {
+			removed, freed, err = jobsCache.Prune(0)
+		} else {
+			removed, freed, err = jobsCache.Trim()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitFailure
+		}
+		fmt.Printf("Removed %d files (%.1f MB)\n", removed, float64(freed)/(1<<20))
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown action: %s\n%s", action, cmd.usage)
+		return subcommands.ExitUsageError
+	}
+
+	return subcommands.ExitSuccess
+}
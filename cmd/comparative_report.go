@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"github.com/mprimi/go-bench-away/v1/client"
+	"github.com/mprimi/go-bench-away/v1/core"
 	"github.com/mprimi/go-bench-away/v1/reports"
 
 	"github.com/google/subcommands"
@@ -14,14 +15,21 @@ import (
 
 type comparativeReportCmd struct {
 	baseCommand
+	clientOptions       reportClientOptions
 	skipTimeOp          bool
 	skipSpeed           bool
 	benchmarkFilterExpr string
 	hiddenResultsTable  bool
 	outputPath          string
 	reportCfg           reports.ReportConfig
+	outputOptions       reportOutputOptions
+	benchmarksSet       string
+	resources           bool
 	beforeLabel         string
 	afterLabel          string
+	profiles            bool
+	profileRows         int
+	webURL              string
 }
 
 func comparativeReportCommand() subcommands.Command {
@@ -35,6 +43,7 @@ func comparativeReportCommand() subcommands.Command {
 }
 
 func (cmd *comparativeReportCmd) SetFlags(f *flag.FlagSet) {
+	cmd.clientOptions.setFlags(f)
 	f.StringVar(&cmd.outputPath, "output", "report.html", "Output report (HTML)")
 	f.StringVar(&cmd.reportCfg.Title, "title", "", "Title of the report (auto-generated if empty)")
 	f.BoolVar(&cmd.skipTimeOp, "no_timeop", false, "Do not include time/op graph and table")
@@ -43,9 +52,15 @@ func (cmd *comparativeReportCmd) SetFlags(f *flag.FlagSet) {
 	f.BoolVar(&cmd.hiddenResultsTable, "hide_table", true, "Hide the results table by default")
 	f.StringVar(&cmd.beforeLabel, "label_before", "Before", "Alternative label for the before/left side of the comparison")
 	f.StringVar(&cmd.afterLabel, "label_after", "After", "Alternative label for the after/right side of the comparison")
+	cmd.outputOptions.setFlags(f)
+	f.BoolVar(&cmd.profiles, "profiles", false, "Include the functions whose profile values changed most, for each profile type collected by both jobs")
+	f.IntVar(&cmd.profileRows, "profile_rows", reports.DefaultProfileDiffRows, "Number of functions in each profile changes table")
+	f.StringVar(&cmd.webURL, "web_url", "", "Base URL of the web UI, linked from profile changes tables for differential flame graphs (required with -profiles), e.g. http://localhost:8888")
+	f.StringVar(&cmd.benchmarksSet, "benchmarks", "", "Benchmarks to include when jobs ran different sets: union (default, missing values shown as gaps) or intersection")
+	f.BoolVar(&cmd.resources, "resources", false, "Include charts of the resource usage (CPU, memory, context switches) sampled while jobs ran")
 }
 
-func (cmd *comparativeReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (cmd *comparativeReportCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
 	if rootOptions.verbose {
 		fmt.Printf("%s args: %v\n", cmd.name, f.Args())
 	}
@@ -56,10 +71,16 @@ func (cmd *comparativeReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ .
 		return subcommands.ExitUsageError
 	}
 
-	c, err := client.NewClient(
-		rootOptions.natsServerUrl,
-		rootOptions.credentials,
-		rootOptions.namespace,
+	if cmd.profiles && cmd.webURL == "" {
+		fmt.Fprintf(os.Stderr, "-profiles requires -web_url, the web UI serving the differential flame graphs\n")
+		return subcommands.ExitUsageError
+	}
+	if cmd.profileRows < 1 {
+		fmt.Fprintf(os.Stderr, "Invalid -profile_rows: %d\n", cmd.profileRows)
+		return subcommands.ExitUsageError
+	}
+
+	c, done, err := cmd.clientOptions.newReportClient(
 		client.InitJobsRepository(),
 		client.InitArtifactsStore(),
 		client.Verbose(rootOptions.verbose),
@@ -69,9 +90,9 @@ func (cmd *comparativeReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ .
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return subcommands.ExitFailure
 	}
-	defer c.Close()
+	defer done()
 
-	dataTable, err := reports.CreateDataTable(c, jobIds...)
+	dataTable, err := reports.CreateDataTableWithContext(ctx, c, jobIds...)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return subcommands.ExitFailure
@@ -83,6 +104,23 @@ func (cmd *comparativeReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ .
 		cmd.reportCfg.Verbose()
 	}
 
+	if err := cmd.outputOptions.configure(&cmd.reportCfg); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitUsageError
+	}
+
+	if cmd.benchmarksSet != "" {
+		benchmarksSet, err := reports.ParseBenchmarksSet(cmd.benchmarksSet)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitUsageError
+		}
+		if err := cmd.reportCfg.SetBenchmarksSet(benchmarksSet); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitUsageError
+		}
+	}
+
 	cmd.reportCfg.AddSections(
 		reports.JobsTable(),
 	)
@@ -105,6 +143,24 @@ func (cmd *comparativeReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ .
 		)
 	}
 
+	if cmd.profiles {
+		profileSections, err := cmd.profileDiffSections(c, jobIds[0], jobIds[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitFailure
+		}
+		cmd.reportCfg.AddSections(profileSections...)
+	}
+
+	if cmd.resources {
+		section, err := resourceUsageSection(c, jobIds)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitFailure
+		}
+		cmd.reportCfg.AddSections(section)
+	}
+
 	file, err := os.Create(cmd.outputPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
@@ -121,3 +177,34 @@ func (cmd *comparativeReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ .
 	fmt.Printf("Created report: %s\n", cmd.outputPath)
 	return subcommands.ExitSuccess
 }
+
+// Sections comparing each type of profile collected by both jobs
+func (cmd *comparativeReportCmd) profileDiffSections(c reportClient, baseJobId, newJobId string) ([]reports.SectionConfig, error) {
+	baseJob, _, err := c.LoadJob(baseJobId)
+	if err != nil {
+		return nil, err
+	}
+	newJob, _, err := c.LoadJob(newJobId)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := []reports.SectionConfig{}
+	for _, profile := range core.ProfileTypes {
+		_, baseHasProfile := baseJob.Profiles[profile]
+		_, newHasProfile := newJob.Profiles[profile]
+		if !baseHasProfile || !newHasProfile {
+			continue
+		}
+		diff, err := reports.LoadProfileDiff(c, baseJob, newJob, profile)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, reports.ProfileDiffTable(diff, cmd.profileRows, cmd.webURL))
+	}
+
+	if len(sections) == 0 {
+		fmt.Printf("Jobs %s and %s have no profiles of the same type\n", baseJobId, newJobId)
+	}
+	return sections, nil
+}
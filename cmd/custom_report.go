@@ -15,10 +15,13 @@ import (
 
 type customReportCmd struct {
 	baseCommand
-	outputPath   string
-	reportCfg    reports.ReportConfig
-	specPath     string
-	customLabels string
+	clientOptions reportClientOptions
+	outputPath    string
+	reportCfg     reports.ReportConfig
+	outputOptions reportOutputOptions
+	benchmarksSet string
+	specPath      string
+	customLabels  string
 }
 
 func customReportCommand() subcommands.Command {
@@ -26,27 +29,25 @@ func customReportCommand() subcommands.Command {
 		baseCommand: baseCommand{
 			name:     "custom-report",
 			synopsis: "Creates a report based on a provided JSON specification",
-			usage:    "custom-report [options] -spec <spec_file> jobId1 jobId2 ... jobIdN\n",
+			usage:    "custom-report [options] -spec <spec_file> [jobId1 jobId2 ... jobIdN]\n",
 		},
 	}
 }
 
 func (cmd *customReportCmd) SetFlags(f *flag.FlagSet) {
+	cmd.clientOptions.setFlags(f)
 	f.StringVar(&cmd.outputPath, "output", "report.html", "Output report (HTML)")
-	f.StringVar(&cmd.specPath, "spec", "spec.json", "Report configuration (JSON)")
+	f.StringVar(&cmd.specPath, "spec", "spec.json", "Report configuration (JSON or YAML)")
 	f.StringVar(&cmd.customLabels, "labels", "", "Use custom labels (comma separated, no spaces, e.g.: \"a,b,c\")")
+	cmd.outputOptions.setFlags(f)
+	f.StringVar(&cmd.benchmarksSet, "benchmarks", "", "Benchmarks to include when jobs ran different sets: union (default, missing values shown as gaps) or intersection")
 }
 
-func (cmd *customReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (cmd *customReportCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
 	if rootOptions.verbose {
 		fmt.Printf("%s args: %v\n", cmd.name, f.Args())
 	}
 
-	if len(f.Args()) < 1 {
-		fmt.Fprintf(os.Stderr, "Must specify at least one job id\n")
-		return subcommands.ExitUsageError
-	}
-
 	spec := &reports.ReportSpec{}
 	err := spec.LoadFile(cmd.specPath)
 	if err != nil {
@@ -54,22 +55,44 @@ func (cmd *customReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...int
 		return subcommands.ExitFailure
 	}
 
-	c, err := client.NewClient(
-		rootOptions.natsServerUrl,
-		rootOptions.credentials,
-		rootOptions.namespace,
+	// Jobs given as arguments take precedence over jobs selected in the spec
+	useSpecJobs := len(f.Args()) == 0
+	if useSpecJobs && spec.Jobs == nil {
+		fmt.Fprintf(os.Stderr, "Must specify at least one job id, or select jobs in the report spec\n")
+		return subcommands.ExitUsageError
+	}
+
+	clientOpts := []client.Option{
 		client.InitJobsRepository(),
 		client.InitArtifactsStore(),
 		client.Verbose(rootOptions.verbose),
-	)
+	}
+
+	if useSpecJobs && spec.Jobs.Query != nil {
+		clientOpts = append(clientOpts, client.InitJobsQueue())
+	}
+
+	c, done, err := cmd.clientOptions.newReportClient(clientOpts...)
 
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return subcommands.ExitFailure
 	}
-	defer c.Close()
+	defer done()
+
+	jobIds := f.Args()
+	if useSpecJobs {
+		jobIds, err = spec.Jobs.JobIds(c)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to select jobs: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		if rootOptions.verbose {
+			fmt.Printf("Selected %d jobs: %v\n", len(jobIds), jobIds)
+		}
+	}
 
-	dataTable, err := reports.CreateDataTable(c, f.Args()...)
+	dataTable, err := reports.CreateDataTableWithContext(ctx, c, jobIds...)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return subcommands.ExitFailure
@@ -89,6 +112,23 @@ func (cmd *customReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...int
 		cmd.reportCfg.SetCustomLabels(strings.Split(cmd.customLabels, ","))
 	}
 
+	if err := cmd.outputOptions.configure(&cmd.reportCfg); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitUsageError
+	}
+
+	if cmd.benchmarksSet != "" {
+		benchmarksSet, err := reports.ParseBenchmarksSet(cmd.benchmarksSet)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitUsageError
+		}
+		if err := cmd.reportCfg.SetBenchmarksSet(benchmarksSet); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitUsageError
+		}
+	}
+
 	file, err := os.Create(cmd.outputPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
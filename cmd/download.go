@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 
 	"github.com/mprimi/go-bench-away/v1/client"
+	"github.com/mprimi/go-bench-away/v1/core"
 
 	"github.com/google/subcommands"
 )
@@ -120,6 +121,42 @@ func (cmd *downloadCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interfa
 			}
 			fmt.Printf("Downloaded %s\n", filePath)
 		}
+
+		for _, profile := range core.ProfileTypes {
+			if _, found := job.Profiles[profile]; !found {
+				continue
+			}
+			fileName := fmt.Sprintf("%s_%s", jobId, core.ProfileArtifact(profile))
+			filePath := filepath.Join(cmd.outputDirPath, fileName)
+			err := c.DownloadProfileArtifact(job, profile, filePath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Download failed: %v\n", err)
+				return subcommands.ExitFailure
+			}
+			fmt.Printf("Downloaded %s\n", filePath)
+		}
+
+		if job.TestBinary != "" {
+			fileName := fmt.Sprintf("%s_%s", jobId, core.TestBinaryArtifact)
+			filePath := filepath.Join(cmd.outputDirPath, fileName)
+			err := c.DownloadTestBinaryArtifact(job, filePath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Download failed: %v\n", err)
+				return subcommands.ExitFailure
+			}
+			fmt.Printf("Downloaded %s\n", filePath)
+		}
+
+		if job.Resources != "" {
+			fileName := fmt.Sprintf("%s_%s", jobId, core.ResourcesArtifact)
+			filePath := filepath.Join(cmd.outputDirPath, fileName)
+			err := c.DownloadResourcesArtifact(job, filePath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Download failed: %v\n", err)
+				return subcommands.ExitFailure
+			}
+			fmt.Printf("Downloaded %s\n", filePath)
+		}
 	}
 
 	return subcommands.ExitSuccess
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mprimi/go-bench-away/v1/archive"
+	"github.com/mprimi/go-bench-away/v1/client"
+	"github.com/mprimi/go-bench-away/v1/core"
+
+	"github.com/google/subcommands"
+)
+
+type exportCmd struct {
+	baseCommand
+	outputPath string
+	all        bool
+	filter     jobsFilterOptions
+}
+
+func exportCommand() subcommands.Command {
+	return &exportCmd{
+		baseCommand: baseCommand{
+			name:     "export",
+			synopsis: "Export jobs and their artifacts to an archive",
+			usage: "export -o archive.tar.gz jobId [jobId [...]]\n" +
+				"export -o archive.tar.gz [search options]\n" +
+				"export -o archive.tar.gz -all\n" +
+				"Writes job records and all their artifacts to a tar.gz archive, which can be loaded with import.\n" +
+				"Jobs currently running are skipped.\n",
+		},
+	}
+}
+
+func (cmd *exportCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&cmd.outputPath, "o", "", "Output archive file")
+	f.BoolVar(&cmd.all, "all", false, "Export all jobs")
+	cmd.filter.setFlags(f)
+}
+
+func (cmd *exportCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if rootOptions.verbose {
+		fmt.Printf("%s args: %v\n", cmd.name, f.Args())
+	}
+
+	if cmd.outputPath == "" {
+		fmt.Fprintf(os.Stderr, "Missing output file (-o)\n%s", cmd.usage)
+		return subcommands.ExitUsageError
+	}
+
+	query, err := cmd.filter.query()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitUsageError
+	}
+	selectors := 0
+	for _, selected := range []bool{f.NArg() > 0, query != nil, cmd.all} {
+		if selected {
+			selectors++
+		}
+	}
+	if selectors != 1 {
+		fmt.Fprintf(os.Stderr, "Select jobs with either job IDs, search options, or -all\n%s", cmd.usage)
+		return subcommands.ExitUsageError
+	}
+
+	c, err := client.NewClient(
+		rootOptions.natsServerUrl,
+		rootOptions.credentials,
+		rootOptions.namespace,
+		client.Verbose(rootOptions.verbose),
+		client.InitJobsRepository(),
+		client.InitArtifactsStore(),
+	)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitFailure
+	}
+	defer c.Close()
+
+	var jobs []*core.JobRecord
+	if f.NArg() > 0 {
+		var errs core.ParallelErrors
+		jobs, errs = c.LoadJobs(ctx, f.Args())
+		err = errs.Err()
+	} else {
+		if cmd.all {
+			query = &core.JobsQuery{}
+		}
+		jobs, _, err = c.SearchJobs(*query)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitFailure
+	}
+
+	selectedJobs := make([]*core.JobRecord, 0, len(jobs))
+	for _, job := range jobs {
+		if job.Status == core.Running {
+			fmt.Printf("Skipping running job %s\n", job.Id)
+			continue
+		}
+		selectedJobs = append(selectedJobs, job)
+	}
+
+	if len(selectedJobs) == 0 {
+		fmt.Fprintf(os.Stderr, "No jobs to export\n")
+		return subcommands.ExitFailure
+	}
+
+	file, err := os.Create(cmd.outputPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitFailure
+	}
+
+	_, err = archive.Export(file, c, rootOptions.namespace, selectedJobs)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(cmd.outputPath)
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitFailure
+	}
+
+	fmt.Printf("Exported %d jobs to %s\n", len(selectedJobs), cmd.outputPath)
+	return subcommands.ExitSuccess
+}
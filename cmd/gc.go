@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/client"
+
+	"github.com/google/subcommands"
+)
+
+type gcCmd struct {
+	baseCommand
+	days   int
+	dryRun bool
+}
+
+func gcCommand() subcommands.Command {
+	return &gcCmd{
+		baseCommand: baseCommand{
+			name:     "gc",
+			synopsis: "Delete logs and scripts of old jobs, keeping results",
+			usage: "gc -days N [-dry_run]\n" +
+				"Deletes log and script artifacts of completed jobs older than N days.\n" +
+				"Results and job records are kept. Pinned jobs and jobs in pinned groups are skipped (see: pin).\n",
+		},
+	}
+}
+
+func (cmd *gcCmd) SetFlags(f *flag.FlagSet) {
+	f.IntVar(&cmd.days, "days", 0, "Retention, in days: artifacts of jobs completed earlier are deleted")
+	f.BoolVar(&cmd.dryRun, "dry_run", false, "Report what would be deleted and the space reclaimed, without deleting anything")
+}
+
+func (cmd *gcCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if rootOptions.verbose {
+		fmt.Printf("%s args: %v\n", cmd.name, f.Args())
+	}
+
+	if cmd.days <= 0 {
+		fmt.Fprintf(os.Stderr, "Missing or invalid -days\n%s", cmd.usage)
+		return subcommands.ExitUsageError
+	}
+
+	c, err := client.NewClient(
+		rootOptions.natsServerUrl,
+		rootOptions.credentials,
+		rootOptions.namespace,
+		client.Verbose(rootOptions.verbose),
+		client.InitJobsRepository(),
+		client.InitArtifactsStore(),
+	)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitFailure
+	}
+	defer c.Close()
+
+	report, err := c.CollectGarbage(time.Duration(cmd.days)*24*time.Hour, cmd.dryRun)
+	if report != nil {
+		fmt.Printf("%v\n", report)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitFailure
+	}
+
+	return subcommands.ExitSuccess
+}
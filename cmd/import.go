@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mprimi/go-bench-away/v1/archive"
+	"github.com/mprimi/go-bench-away/v1/client"
+	"github.com/mprimi/go-bench-away/v1/core"
+
+	"github.com/google/subcommands"
+)
+
+type importCmd struct {
+	baseCommand
+	onConflict string
+	altQueue   string
+}
+
+func importCommand() subcommands.Command {
+	return &importCmd{
+		baseCommand: baseCommand{
+			name:     "import",
+			synopsis: "Import jobs and their artifacts from an archive",
+			usage: "import [options] archive.tar.gz\n" +
+				"Loads jobs from an archive created with export, preserving job IDs and timestamps.\n" +
+				"Jobs still submitted are queued again, other jobs are only searchable (see: list).\n",
+		},
+	}
+}
+
+func (cmd *importCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&cmd.onConflict, "on_conflict", "skip", "If a job with the same ID exists: skip, overwrite, fail")
+	f.StringVar(&cmd.altQueue, "queue", "", "Queue submitted jobs in a non-default queue with the specified name")
+}
+
+func (cmd *importCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if rootOptions.verbose {
+		fmt.Printf("%s args: %v\n", cmd.name, f.Args())
+	}
+
+	if f.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Expected one archive file\n%s", cmd.usage)
+		return subcommands.ExitUsageError
+	}
+
+	switch cmd.onConflict {
+	case "skip", "overwrite", "fail":
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid -on_conflict: %s\n", cmd.onConflict)
+		return subcommands.ExitUsageError
+	}
+
+	file, err := os.Open(f.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitFailure
+	}
+	defer file.Close()
+
+	clientOpts := []client.Option{
+		client.Verbose(rootOptions.verbose),
+		client.InitJobsQueue(),
+		client.InitJobsRepository(),
+		client.InitArtifactsStore(),
+	}
+
+	if cmd.altQueue != "" {
+		clientOpts = append(clientOpts, client.WithAltQueue(cmd.altQueue))
+	}
+
+	c, err := client.NewClient(
+		rootOptions.natsServerUrl,
+		rootOptions.credentials,
+		rootOptions.namespace,
+		clientOpts...,
+	)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitFailure
+	}
+	defer c.Close()
+
+	imported, skipped, queued := 0, 0, 0
+	manifest, err := archive.Import(file, func(job *archive.Job) error {
+		err := c.ImportJob(job.Record, job.Artifacts, cmd.onConflict == "overwrite")
+		if errors.Is(err, core.ErrJobExists) && cmd.onConflict == "skip" {
+			fmt.Printf("Skipping existing job %s\n", job.Record.Id)
+			skipped++
+			return nil
+		} else if err != nil {
+			return err
+		}
+		imported++
+		if job.Record.Status == core.Submitted {
+			queued++
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		if imported > 0 {
+			fmt.Fprintf(os.Stderr, "Imported %d jobs before the error\n", imported)
+		}
+		return subcommands.ExitFailure
+	}
+
+	fmt.Printf(
+		"Imported %d jobs (%d skipped, %d queued) exported from namespace %s on %v\n",
+		imported,
+		skipped,
+		queued,
+		manifest.Namespace,
+		manifest.Created,
+	)
+	return subcommands.ExitSuccess
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"github.com/mprimi/go-bench-away/v1/client"
+	"github.com/mprimi/go-bench-away/v1/core"
 
 	"github.com/google/subcommands"
 )
@@ -14,20 +15,24 @@ import (
 type initCmd struct {
 	baseCommand
 	altQueue string
+	migrate  bool
 }
 
 func initCommand() subcommands.Command {
 	return &initCmd{
 		baseCommand: baseCommand{
 			name:     "init",
-			synopsis: "Initializes server schemas (Stream, KV store, Object store)",
-			usage:    "init [options]\n",
+			synopsis: "Initializes server schemas (Stream, KV stores, Object store)",
+			usage: "init [options]\n" +
+				"init -migrate\n" +
+				"With -migrate, upgrades existing job records to the current schema version (run after upgrading).\n",
 		},
 	}
 }
 
 func (cmd *initCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&cmd.altQueue, "queue", "", "Initialize non-default jobs queue with the given name")
+	f.BoolVar(&cmd.migrate, "migrate", false, "Rewrite existing job records to the current schema version, rather than initializing")
 }
 
 func (cmd *initCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
@@ -43,6 +48,10 @@ func (cmd *initCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}
 		clientOpts = append(clientOpts, client.WithAltQueue(cmd.altQueue))
 	}
 
+	if cmd.migrate {
+		clientOpts = append(clientOpts, client.InitJobsRepository())
+	}
+
 	c, err := client.NewClient(
 		rootOptions.natsServerUrl,
 		rootOptions.credentials,
@@ -56,10 +65,22 @@ func (cmd *initCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}
 	}
 	defer c.Close()
 
+	if cmd.migrate {
+		migrated, err := c.MigrateJobs()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitFailure
+		}
+		fmt.Printf("Migrated %d job records to schema version %d\n", migrated, core.JobSchemaVersion)
+		return subcommands.ExitSuccess
+	}
+
 	initFuncs := []func() error{
 		c.CreateJobsQueue,
 		c.CreateJobsRepository,
+		c.CreateJobsIndex,
 		c.CreateArtifactsStore,
+		c.CreateSchedulesRepository,
 	}
 
 	for _, fun := range initFuncs {
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+// Register the flags of job parameters, shared by commands submitting jobs
+func jobParamsFlags(f *flag.FlagSet, p *core.JobParameters) {
+	f.StringVar(&p.GitRemote, "remote", "https://github.com/nats-io/nats-server.git", "Git remote URL")
+	f.StringVar(&p.GitRef, "ref", "main", "Git reference (branch, SHA, tag, ...)")
+	f.StringVar(&p.TestsSubDir, "tests_dir", ".", "Name of subdirectory in source where to run tests from")
+	f.StringVar(&p.TestsFilterExpr, "filter", "*", "Filter expression to select what tests are executed")
+	f.UintVar(&p.Reps, "reps", 3, "Number of repetitions for each tests")
+	f.DurationVar(&p.TestMinRuntime, "min_runtime", 1*time.Second, "Minimum duration of each benchmark")
+	f.DurationVar(&p.Timeout, "timeout", 3*time.Hour, "Max time allowed to run all tests")
+	f.BoolVar(&p.SkipCleanup, "skip_cleanup", false, "Do not remove worker temporary directory after execution")
+	f.StringVar(&p.GoPath, "go_path", "", "Run using a custom Go (default looks for `go` in $PATH)")
+	f.StringVar(&p.CleanupCmd, "cleanup_command", "", "Command to execute after tests have run")
+	f.StringVar(&p.Group, "group", "", "Label grouping related jobs, can be used to search jobs")
+	f.Func("profile", "Comma-separated profiles to collect (options: cpu, mem, block, mutex)", func(s string) error {
+		profiles, err := core.ParseProfiles(s)
+		p.Profiles = profiles
+		return err
+	})
+	f.Func("package", "Package pattern to benchmark in the tests directory, with an optional filter, as pattern[=filter], e.g. ./server/... (can be repeated)", func(s string) error {
+		ps, err := core.ParsePackageSpec(s)
+		p.Packages = append(p.Packages, ps)
+		return err
+	})
+	f.Func("tags", "Comma-separated build tags (passed to go test -tags)", func(s string) error {
+		tags, err := core.ParseBuildTags(s)
+		p.BuildTags = tags
+		return err
+	})
+	f.Func("cpu", "Comma-separated GOMAXPROCS values each benchmark is run with (passed to go test -cpu)", func(s string) error {
+		cpus, err := core.ParseCPUList(s)
+		p.CPUs = cpus
+		return err
+	})
+	f.BoolVar(&p.BenchMem, "benchmem", false, "Report memory allocation statistics of benchmarks")
+	f.Func("test_flag", "Extra go test flag, e.g. -race or -gcflags=-N (can be repeated, must be allowed by the worker)", func(s string) error {
+		if core.TestFlagName(s) == "" {
+			return fmt.Errorf("expected -name or -name=value")
+		}
+		p.TestFlags = append(p.TestFlags, s)
+		return nil
+	})
+	f.Func("env", "Environment variable set when running the job, as NAME=value, e.g. GOGC=off (can be repeated, must be allowed by the worker)", func(s string) error {
+		name, value, err := core.ParseEnvVar(s)
+		if err != nil {
+			return err
+		}
+		if p.Env == nil {
+			p.Env = map[string]string{}
+		}
+		p.Env[name] = value
+		return nil
+	})
+	f.StringVar(&p.ScriptTemplate, "script_template", "", "Name of the worker script template used to run the job (default script if empty)")
+	f.Func("script_param", "Custom parameter of the script template, as name=value (can be repeated)", func(s string) error {
+		name, value, err := core.ParseScriptParam(s)
+		if err != nil {
+			return err
+		}
+		if p.ScriptParams == nil {
+			p.ScriptParams = map[string]string{}
+		}
+		p.ScriptParams[name] = value
+		return nil
+	})
+}
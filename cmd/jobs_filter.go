@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"flag"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+// Options of commands searching jobs across the full history
+type jobsFilterOptions struct {
+	status     string
+	username   string
+	remote     string
+	ref        string
+	since      string
+	until      string
+	workerHost string
+	group      string
+}
+
+func (o *jobsFilterOptions) setFlags(f *flag.FlagSet) {
+	f.StringVar(&o.status, "status", "", "Search jobs with the given status (submitted, running, failed, succeeded, cancelled)")
+	f.StringVar(&o.username, "user", "", "Search jobs submitted by the given user")
+	f.StringVar(&o.remote, "remote", "", "Search jobs with the given Git remote URL")
+	f.StringVar(&o.ref, "ref", "", "Search jobs with the given Git reference, or SHA prefix")
+	f.StringVar(&o.since, "since", "", "Search jobs created at or after the given time (RFC3339 or YYYY-MM-DD)")
+	f.StringVar(&o.until, "until", "", "Search jobs created before the given time (RFC3339 or YYYY-MM-DD)")
+	f.StringVar(&o.workerHost, "host", "", "Search jobs executed by the given worker host")
+	f.StringVar(&o.group, "group", "", "Search jobs in the given group")
+}
+
+// Build a search query from the filter options, returns nil if no filter is set
+func (o *jobsFilterOptions) query() (*core.JobsQuery, error) {
+	q := &core.JobsQuery{
+		Username:   o.username,
+		GitRemote:  o.remote,
+		Ref:        o.ref,
+		WorkerHost: o.workerHost,
+		Group:      o.group,
+	}
+	if o.status != "" {
+		status, err := core.ParseJobStatus(o.status)
+		if err != nil {
+			return nil, err
+		}
+		q.Status = &status
+	}
+	for _, t := range []struct {
+		value  string
+		target *time.Time
+	}{
+		{o.since, &q.Since},
+		{o.until, &q.Until},
+	} {
+		if t.value != "" {
+			parsed, err := core.ParseQueryTime(t.value)
+			if err != nil {
+				return nil, err
+			}
+			*t.target = parsed
+		}
+	}
+
+	if *q == (core.JobsQuery{}) {
+		return nil, nil
+	}
+	return q, nil
+}
@@ -16,7 +16,9 @@ import (
 type listCmd struct {
 	baseCommand
 	limit    int
+	offset   int
 	altQueue string
+	filter   jobsFilterOptions
 }
 
 func listCommand() subcommands.Command {
@@ -24,17 +26,33 @@ func listCommand() subcommands.Command {
 		baseCommand: baseCommand{
 			name:     "list",
 			synopsis: "lists recent jobs",
-			usage:    "list [options]\n",
+			usage: "list [options]\n" +
+				"Lists the most recent jobs in the queue.\n" +
+				"With search filters, searches jobs in all queues (or the one selected with -queue) across the full history.\n",
 		},
 	}
 }
 
 func (cmd *listCmd) SetFlags(f *flag.FlagSet) {
 	f.IntVar(&cmd.limit, "n", 10, "Maximum number of recent jobs to show (0 for unlimited)")
+	f.IntVar(&cmd.offset, "offset", 0, "Number of matching jobs to skip (search only)")
 	f.StringVar(&cmd.altQueue, "queue", "", "Read jobs from a non-default queue with the specified name")
+	cmd.filter.setFlags(f)
 }
 
-func (cmd *listCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+// Build a search query from the filter options, returns nil if no filter is set
+func (cmd *listCmd) searchQuery() (*core.JobsQuery, error) {
+	q, err := cmd.filter.query()
+	if q == nil || err != nil {
+		return nil, err
+	}
+	q.Queue = cmd.altQueue
+	q.Offset = cmd.offset
+	q.Limit = cmd.limit
+	return q, nil
+}
+
+func (cmd *listCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
 	if rootOptions.verbose {
 		fmt.Printf("%s args: %v\n", cmd.name, f.Args())
 	}
@@ -62,10 +80,39 @@ func (cmd *listCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}
 	}
 	defer c.Close()
 
-	jobs, err := c.LoadRecentJobs(cmd.limit)
+	query, err := cmd.searchQuery()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
-		return subcommands.ExitFailure
+		return subcommands.ExitUsageError
+	}
+
+	var jobs []*core.JobRecord
+	if query != nil {
+		var total int
+		jobs, total, err = c.SearchJobs(*query)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitFailure
+		}
+		if len(jobs) > 0 {
+			fmt.Printf("Matching jobs (%d-%d of %d):\n", cmd.offset+1, cmd.offset+len(jobs), total)
+		}
+	} else {
+		var errs core.ParallelErrors
+		jobs, errs, err = c.LoadRecentJobsWithContext(ctx, cmd.limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitFailure
+		}
+		// Show the jobs that loaded
+		for _, err := range errs {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+			}
+		}
+		if len(jobs) > 0 {
+			fmt.Printf("Recent jobs:\n")
+		}
 	}
 
 	if len(jobs) == 0 {
@@ -73,7 +120,6 @@ func (cmd *listCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}
 		return subcommands.ExitSuccess
 	}
 
-	fmt.Printf("Recent jobs:\n")
 	for _, job := range jobs {
 
 		fmt.Printf(
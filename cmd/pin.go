@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mprimi/go-bench-away/v1/client"
+
+	"github.com/google/subcommands"
+)
+
+type pinCmd struct {
+	baseCommand
+	group string
+	unpin bool
+	list  bool
+}
+
+func pinCommand() subcommands.Command {
+	return &pinCmd{
+		baseCommand: baseCommand{
+			name:     "pin",
+			synopsis: "Exempt jobs or groups of jobs from garbage collection",
+			usage: "pin [-unpin] jobId [jobId [...]]\n" +
+				"pin [-unpin] -group name\n" +
+				"pin -list\n",
+		},
+	}
+}
+
+func (cmd *pinCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&cmd.group, "group", "", "Pin all jobs (past and future) of the given group")
+	f.BoolVar(&cmd.unpin, "unpin", false, "Unpin, rather than pin")
+	f.BoolVar(&cmd.list, "list", false, "List pinned groups")
+}
+
+func (cmd *pinCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if rootOptions.verbose {
+		fmt.Printf("%s args: %v\n", cmd.name, f.Args())
+	}
+
+	if !cmd.list && cmd.group == "" && f.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "Specify job IDs, a group, or -list\n%s", cmd.usage)
+		return subcommands.ExitUsageError
+	}
+
+	c, err := client.NewClient(
+		rootOptions.natsServerUrl,
+		rootOptions.credentials,
+		rootOptions.namespace,
+		client.Verbose(rootOptions.verbose),
+		client.InitJobsRepository(),
+	)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitFailure
+	}
+	defer c.Close()
+
+	action := "Pinned"
+	if cmd.unpin {
+		action = "Unpinned"
+	}
+
+	if cmd.group != "" {
+		if err := c.PinGroup(cmd.group, !cmd.unpin); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitFailure
+		}
+		fmt.Printf("%s group: %s\n", action, cmd.group)
+	}
+
+	for _, jobId := range f.Args() {
+		if err := c.PinJob(jobId, !cmd.unpin); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitFailure
+		}
+		fmt.Printf("%s job: %s\n", action, jobId)
+	}
+
+	if cmd.list {
+		groups, err := c.PinnedGroups()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitFailure
+		}
+		fmt.Printf("Pinned groups: %d\n", len(groups))
+		for _, group := range groups {
+			fmt.Printf(" - %s\n", group)
+		}
+	}
+
+	return subcommands.ExitSuccess
+}
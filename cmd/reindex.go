@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mprimi/go-bench-away/v1/client"
+
+	"github.com/google/subcommands"
+)
+
+type reindexCmd struct {
+	baseCommand
+}
+
+func reindexCommand() subcommands.Command {
+	return &reindexCmd{
+		baseCommand: baseCommand{
+			name:     "reindex",
+			synopsis: "Rebuilds the jobs search index from job records",
+			usage: "reindex\n" +
+				"Indexes all job records, e.g. after upgrading from a version without search index.\n",
+		},
+	}
+}
+
+func (cmd *reindexCmd) SetFlags(_ *flag.FlagSet) {
+}
+
+func (cmd *reindexCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if rootOptions.verbose {
+		fmt.Printf("%s args: %v\n", cmd.name, f.Args())
+	}
+
+	c, err := client.NewClient(
+		rootOptions.natsServerUrl,
+		rootOptions.credentials,
+		rootOptions.namespace,
+		client.Verbose(rootOptions.verbose),
+		client.InitJobsRepository(),
+	)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitFailure
+	}
+	defer c.Close()
+
+	count, err := c.RebuildJobsIndex()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitFailure
+	}
+
+	fmt.Printf("Indexed %d jobs\n", count)
+	return subcommands.ExitSuccess
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/mprimi/go-bench-away/v1/cache"
+	"github.com/mprimi/go-bench-away/v1/client"
+	"github.com/mprimi/go-bench-away/v1/reports"
+)
+
+// Client used by report commands to load jobs and results
+type reportClient interface {
+	reports.JobRecordClient
+	reports.JobsQueryClient
+	reports.ProfileClient
+	reports.ResourcesClient
+}
+
+// Options of report commands controlling how jobs and results are loaded
+type reportClientOptions struct {
+	offline bool
+	noCache bool
+}
+
+func (o *reportClientOptions) setFlags(f *flag.FlagSet) {
+	f.BoolVar(&o.offline, "offline", false, "Create the report from the local cache only, without connecting to the server")
+	f.BoolVar(&o.noCache, "no_cache", false, "Do not use the local cache of completed jobs and results")
+}
+
+// Open the local cache, with location and size from the top-level options
+func openCache() (*cache.Cache, error) {
+	dir := rootOptions.cacheDir
+	if dir == "" {
+		var err error
+		dir, err = cache.DefaultDir()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to locate cache directory: %v", err)
+		}
+	}
+	return cache.Open(dir, rootOptions.cacheSizeMB<<20)
+}
+
+// Create a client for a report command, which loads completed jobs and results through the local cache (unless
+// disabled). Offline, it does not connect to the server. The returned function must be called when done.
+func (o *reportClientOptions) newReportClient(clientOpts ...client.Option) (reportClient, func(), error) {
+	if o.offline && o.noCache {
+		return nil, nil, fmt.Errorf("Options -offline and -no_cache are mutually exclusive")
+	}
+
+	var c *client.Client
+	if !o.offline {
+		var err error
+		c, err = client.NewClient(
+			rootOptions.natsServerUrl,
+			rootOptions.credentials,
+			rootOptions.namespace,
+			clientOpts...,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		if o.noCache {
+			return c, c.Close, nil
+		}
+	}
+
+	jobsCache, err := openCache()
+	if err != nil {
+		if c != nil {
+			c.Close()
+		}
+		return nil, nil, err
+	}
+
+	done := func() {
+		if c != nil {
+			c.Close()
+		}
+		if _, _, err := jobsCache.Trim(); err != nil {
+			fmt.Printf("Failed to trim cache: %v\n", err)
+		}
+	}
+
+	if o.offline {
+		return cache.NewCachedClient(nil, jobsCache), done, nil
+	}
+	return cache.NewCachedClient(c, jobsCache), done, nil
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"flag"
+
+	"github.com/mprimi/go-bench-away/v1/reports"
+)
+
+// Options of report commands controlling how results are presented and how the report is written
+type reportOutputOptions struct {
+	uncertaintyMethod string
+	confidence        float64
+	selfContained     bool
+}
+
+func (o *reportOutputOptions) setFlags(f *flag.FlagSet) {
+	f.StringVar(&o.uncertaintyMethod, "uncertainty", "", "Model for error bars and ± values: centile_deviation (default), percentile_range, standard_error, bootstrap_mean, bootstrap_median")
+	f.Float64Var(&o.confidence, "confidence", 0, "Confidence level (percent) used by the uncertainty model (default: 90)")
+	f.BoolVar(&o.selfContained, "self_contained", false, "Embed the charting library in the report, so it can be viewed offline (requires a build bundling it)")
+}
+
+// Apply the options to a report configuration, fails if they are invalid
+func (o *reportOutputOptions) configure(cfg *reports.ReportConfig) error {
+	if o.selfContained {
+		cfg.SelfContained()
+	}
+
+	if o.uncertaintyMethod != "" || o.confidence != 0 {
+		uncertainty, err := reports.ParseUncertainty(o.uncertaintyMethod, o.confidence)
+		if err != nil {
+			return err
+		}
+		if err := cfg.SetUncertainty(uncertainty); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/mprimi/go-bench-away/v1/cache"
 	"github.com/mprimi/go-bench-away/v1/core"
 
 	"github.com/google/subcommands"
@@ -17,6 +18,8 @@ var rootOptions struct {
 	natsServerUrl string
 	credentials   string
 	namespace     string
+	cacheDir      string
+	cacheSizeMB   int64
 }
 
 type baseCommand struct {
@@ -66,6 +69,8 @@ func Run(args []string) int {
 	rootFlagSet.StringVar(&rootOptions.natsServerUrl, "server", "nats://localhost:4222", "NATS server URL")
 	rootFlagSet.StringVar(&rootOptions.credentials, "creds", "", "Path to credentials file")
 	rootFlagSet.StringVar(&rootOptions.namespace, "namespace", "default", "Namespace (allows isolated sets of jobs to share a NATS server)")
+	rootFlagSet.StringVar(&rootOptions.cacheDir, "cache_dir", "", "Local cache of completed jobs and results (default: user cache directory)")
+	rootFlagSet.Int64Var(&rootOptions.cacheSizeMB, "cache_size", cache.DefaultMaxSize>>20, "Size limit of the local cache (MB)")
 
 	cmdr := subcommands.NewCommander(rootFlagSet, core.Name)
 	cmdr.ImportantFlag("server")
@@ -76,11 +81,18 @@ func Run(args []string) int {
 		"maintenance": {
 			initCommand(),
 			wipeCommand(),
+			reindexCommand(),
+			cacheCommand(),
+			gcCommand(),
+			pinCommand(),
+			exportCommand(),
+			importCommand(),
 		},
 		"submit, monitor, cancel": {
 			submitCommand(),
 			waitCommand(),
 			cancelCommand(),
+			scheduleCommand(),
 		},
 		"job debugging": {
 			downloadCommand(),
@@ -92,6 +104,7 @@ func Run(args []string) int {
 			comparativeReportCommand(),
 			customReportCommand(),
 			singleReportCommand(),
+			validateSpecCommand(),
 		},
 		"worker": {
 			workerCommand(),
@@ -14,6 +14,8 @@ func TestRun(t *testing.T) {
 		{[]string{}, 2},
 		{[]string{"blergh"}, 2},
 		{[]string{"help", "foo"}, 2},
+		{[]string{"compare", "-profiles", "job1", "job2"}, 2},
+		{[]string{"compare", "-profile_rows", "0", "job1", "job2"}, 2},
 		// Valid
 		{[]string{"commands"}, 0},
 		{[]string{"flags"}, 0},
@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/client"
+	"github.com/mprimi/go-bench-away/v1/core"
+	"github.com/mprimi/go-bench-away/v1/reports"
+
+	"github.com/google/subcommands"
+)
+
+type scheduleCmd struct {
+	baseCommand
+	cronExpr        string
+	params          core.JobParameters
+	reportSpecPath  string
+	reportJobsLimit int
+	outputPath      string
+}
+
+func scheduleCommand() subcommands.Command {
+	return &scheduleCmd{
+		baseCommand: baseCommand{
+			name:     "schedule",
+			synopsis: "Manage schedules of recurring jobs (e.g. nightly benchmarks)",
+			usage: "schedule add [options] -cron <expression> <name>\n" +
+				"schedule list\n" +
+				"schedule show <name>\n" +
+				"schedule report [-output <file>] <name>\n" +
+				"schedule pause <name>\n" +
+				"schedule resume <name>\n" +
+				"schedule delete <name>\n" +
+				"\n" +
+				"Jobs are submitted by a scheduler, running as part of a worker or web process (with option -scheduler).\n",
+		},
+	}
+}
+
+func (cmd *scheduleCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&cmd.cronExpr, "cron", "", "When to submit jobs, cron expression in UTC (e.g. \"0 2 * * *\" or @daily)")
+	jobParamsFlags(f, &cmd.params)
+	f.StringVar(&cmd.reportSpecPath, "report_spec", "", "Report configuration (JSON or YAML) for the series report (default: trend report)")
+	f.IntVar(&cmd.reportJobsLimit, "report_jobs", 0, "Number of most recent jobs included in the series report (0 for all)")
+	f.StringVar(&cmd.outputPath, "output", "report.html", "Output file for the series report (action: report)")
+}
+
+func (cmd *scheduleCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if rootOptions.verbose {
+		fmt.Printf("%s args: %v\n", cmd.name, f.Args())
+	}
+
+	if f.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "Missing action\n%s", cmd.usage)
+		return subcommands.ExitUsageError
+	}
+
+	// Options follow the action
+	action := f.Arg(0)
+	if err := f.Parse(f.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitUsageError
+	}
+
+	var name string
+	if action != "list" {
+		if f.NArg() != 1 {
+			fmt.Fprintf(os.Stderr, "Action %s requires a schedule name argument\n", action)
+			return subcommands.ExitUsageError
+		}
+		name = f.Arg(0)
+	}
+
+	clientOpts := []client.Option{
+		client.Verbose(rootOptions.verbose),
+		client.InitSchedulesRepository(),
+	}
+	if action == "report" {
+		clientOpts = append(clientOpts, client.InitArtifactsStore())
+	}
+
+	c, err := client.NewClient(
+		rootOptions.natsServerUrl,
+		rootOptions.credentials,
+		rootOptions.namespace,
+		clientOpts...,
+	)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitFailure
+	}
+	defer c.Close()
+
+	switch action {
+	case "add":
+		err = cmd.add(c, name)
+	case "list":
+		err = cmd.list(c)
+	case "show":
+		err = cmd.show(c, name)
+	case "report":
+		err = cmd.report(c, name)
+	case "pause", "resume":
+		err = cmd.setPaused(c, name, action == "pause")
+	case "delete":
+		err = c.DeleteSchedule(name)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown action: %s\n%s", action, cmd.usage)
+		return subcommands.ExitUsageError
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitFailure
+	}
+	return subcommands.ExitSuccess
+}
+
+func (cmd *scheduleCmd) add(c *client.Client, name string) error {
+	if cmd.cronExpr == "" {
+		return fmt.Errorf("missing cron expression (option -cron)")
+	}
+
+	schedule, err := core.NewSchedule(name, cmd.cronExpr, cmd.params)
+	if err != nil {
+		return err
+	}
+
+	if cmd.reportJobsLimit < 0 {
+		return fmt.Errorf("invalid number of report jobs: %d", cmd.reportJobsLimit)
+	}
+	schedule.ReportJobsLimit = cmd.reportJobsLimit
+
+	if cmd.reportSpecPath != "" {
+		spec := &reports.ReportSpec{}
+		if err := spec.LoadFile(cmd.reportSpecPath); err != nil {
+			return fmt.Errorf("failed to load report spec: %w", err)
+		}
+		if problems := spec.Validate(0); len(problems) > 0 {
+			return fmt.Errorf("invalid report spec: %w", problems[0])
+		}
+		// Stored as JSON, regardless of the original format
+		schedule.ReportSpec, err = json.Marshal(spec)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := c.CreateSchedule(schedule); err != nil {
+		return err
+	}
+
+	fmt.Printf("Created schedule %s, next run: %v\n", schedule.Name, schedule.NextRun)
+	return nil
+}
+
+func (cmd *scheduleCmd) list(c *client.Client) error {
+	schedules, err := c.ListSchedules()
+	if err != nil {
+		return err
+	}
+
+	if len(schedules) == 0 {
+		fmt.Printf("No schedules found\n")
+		return nil
+	}
+
+	fmt.Printf("Schedules:\n")
+	for _, schedule := range schedules {
+		status := fmt.Sprintf("next run: %v", schedule.NextRun)
+		if schedule.Paused {
+			status = "paused"
+		}
+		fmt.Printf(
+			" %s '%s' [%s] %d jobs\n",
+			schedule.Name,
+			schedule.CronExpr,
+			status,
+			len(schedule.JobIds),
+		)
+	}
+	return nil
+}
+
+func (cmd *scheduleCmd) show(c *client.Client, name string) error {
+	schedule, _, err := c.LoadSchedule(name)
+	if err != nil {
+		return err
+	}
+
+	reportSpec := "default trend report"
+	if len(schedule.ReportSpec) > 0 {
+		reportSpec = string(schedule.ReportSpec)
+	}
+
+	fmt.Printf(
+		"Schedule: %s\n"+
+			" - Cron: '%s' (UTC)\n"+
+			" - Paused: %v\n"+
+			" - Created: %v\n"+
+			" - Last run: %v\n"+
+			" - Next run: %v\n"+
+			" - Remote: %s Ref: %s\n"+
+			" - Filter: '%s'\n"+
+			" - Repetitions: %d x %v\n"+
+			" - Report spec: %s\n"+
+			" - Report: %s\n"+
+			" - Jobs (%d):\n"+
+			"",
+		schedule.Name,
+		schedule.CronExpr,
+		schedule.Paused,
+		schedule.Created,
+		schedule.LastRun,
+		schedule.NextRun,
+		schedule.Parameters.GitRemote,
+		schedule.Parameters.GitRef,
+		schedule.Parameters.TestsFilterExpr,
+		schedule.Parameters.Reps,
+		schedule.Parameters.TestMinRuntime,
+		reportSpec,
+		schedule.Report,
+		len(schedule.JobIds),
+	)
+	for _, jobId := range schedule.JobIds {
+		fmt.Printf("   - %s\n", jobId)
+	}
+	return nil
+}
+
+func (cmd *scheduleCmd) report(c *client.Client, name string) error {
+	schedule, _, err := c.LoadSchedule(name)
+	if err != nil {
+		return err
+	}
+
+	if err := c.DownloadSeriesReportArtifact(schedule, cmd.outputPath); err != nil {
+		return err
+	}
+
+	fmt.Printf("Downloaded series report: %s\n", cmd.outputPath)
+	return nil
+}
+
+func (cmd *scheduleCmd) setPaused(c *client.Client, name string, paused bool) error {
+	schedule, revision, err := c.LoadSchedule(name)
+	if err != nil {
+		return err
+	}
+
+	if paused {
+		schedule.Paused = true
+	} else if err := schedule.Resume(time.Now()); err != nil {
+		return err
+	}
+
+	_, err = c.UpdateSchedule(schedule, revision)
+	return err
+}
@@ -14,12 +14,14 @@ import (
 
 type singleReportCmd struct {
 	baseCommand
+	clientOptions       reportClientOptions
 	skipTimeOp          bool
 	skipSpeed           bool
 	benchmarkFilterExpr string
 	hiddenResultsTable  bool
 	outputPath          string
 	reportCfg           reports.ReportConfig
+	outputOptions       reportOutputOptions
 }
 
 func singleReportCommand() subcommands.Command {
@@ -33,15 +35,17 @@ func singleReportCommand() subcommands.Command {
 }
 
 func (cmd *singleReportCmd) SetFlags(f *flag.FlagSet) {
+	cmd.clientOptions.setFlags(f)
 	f.StringVar(&cmd.outputPath, "output", "report.html", "Output report (HTML)")
 	f.StringVar(&cmd.reportCfg.Title, "title", "", "Title of the report (auto-generated if empty)")
 	f.BoolVar(&cmd.skipTimeOp, "no_timeop", false, "Do not include time/op graph and table")
 	f.BoolVar(&cmd.skipSpeed, "no_speed", false, "Do not include speed graph and table")
 	f.StringVar(&cmd.benchmarkFilterExpr, "benchmark_filter", "", "Regular expression to filter experiments based on benchmark name")
 	f.BoolVar(&cmd.hiddenResultsTable, "hide_table", true, "Hide the results table by default")
+	cmd.outputOptions.setFlags(f)
 }
 
-func (cmd *singleReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (cmd *singleReportCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
 	if rootOptions.verbose {
 		fmt.Printf("%s args: %v\n", cmd.name, f.Args())
 	}
@@ -53,10 +57,7 @@ func (cmd *singleReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...int
 
 	jobId := f.Args()[0]
 
-	c, err := client.NewClient(
-		rootOptions.natsServerUrl,
-		rootOptions.credentials,
-		rootOptions.namespace,
+	c, done, err := cmd.clientOptions.newReportClient(
 		client.InitJobsRepository(),
 		client.InitArtifactsStore(),
 		client.Verbose(rootOptions.verbose),
@@ -66,9 +67,9 @@ func (cmd *singleReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...int
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return subcommands.ExitFailure
 	}
-	defer c.Close()
+	defer done()
 
-	dataTable, err := reports.CreateDataTable(c, jobId)
+	dataTable, err := reports.CreateDataTableWithContext(ctx, c, jobId)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return subcommands.ExitFailure
@@ -78,6 +79,11 @@ func (cmd *singleReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...int
 		cmd.reportCfg.Verbose()
 	}
 
+	if err := cmd.outputOptions.configure(&cmd.reportCfg); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitUsageError
+	}
+
 	cmd.reportCfg.AddSections(
 		reports.JobsTable(),
 	)
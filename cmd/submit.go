@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"os"
 	"os/user"
-	"time"
 
 	"github.com/mprimi/go-bench-away/v1/client"
 	"github.com/mprimi/go-bench-away/v1/core"
@@ -31,16 +30,7 @@ func submitCommand() subcommands.Command {
 }
 
 func (cmd *submitCmd) SetFlags(f *flag.FlagSet) {
-	f.StringVar(&cmd.params.GitRemote, "remote", "https://github.com/nats-io/nats-server.git", "Git remote URL")
-	f.StringVar(&cmd.params.GitRef, "ref", "main", "Git reference (branch, SHA, tag, ...)")
-	f.StringVar(&cmd.params.TestsSubDir, "tests_dir", ".", "Name of subdirectory in source where to run tests from")
-	f.StringVar(&cmd.params.TestsFilterExpr, "filter", "*", "Filter expression to select what tests are executed")
-	f.UintVar(&cmd.params.Reps, "reps", 3, "Number of repetitions for each tests")
-	f.DurationVar(&cmd.params.TestMinRuntime, "min_runtime", 1*time.Second, "Minimum duration of each benchmark")
-	f.DurationVar(&cmd.params.Timeout, "timeout", 3*time.Hour, "Max time allowed to run all tests")
-	f.BoolVar(&cmd.params.SkipCleanup, "skip_cleanup", false, "Do not remove worker temporary directory after execution")
-	f.StringVar(&cmd.params.GoPath, "go_path", "", "Run using a custom Go (default looks for `go` in $PATH)")
-	f.StringVar(&cmd.params.CleanupCmd, "cleanup_command", "", "Command to execute after tests have run")
+	jobParamsFlags(f, &cmd.params)
 	f.StringVar(&cmd.altQueue, "queue", "", "Publish job to a non-default queue with the specified name")
 }
 
@@ -15,12 +15,15 @@ import (
 
 type trendReportCmd struct {
 	baseCommand
+	clientOptions       reportClientOptions
 	skipTimeOp          bool
 	skipSpeed           bool
 	benchmarkFilterExpr string
 	hiddenResultsTable  bool
 	outputPath          string
 	reportCfg           reports.ReportConfig
+	outputOptions       reportOutputOptions
+	benchmarksSet       string
 	customLabels        string
 }
 
@@ -35,6 +38,7 @@ func trendReportCommand() subcommands.Command {
 }
 
 func (cmd *trendReportCmd) SetFlags(f *flag.FlagSet) {
+	cmd.clientOptions.setFlags(f)
 	f.StringVar(&cmd.outputPath, "output", "report.html", "Output report (HTML)")
 	f.StringVar(&cmd.reportCfg.Title, "title", "", "Title of the report (auto-generated if empty)")
 	f.BoolVar(&cmd.skipTimeOp, "no_timeop", false, "Do not include time/op graph and table")
@@ -42,9 +46,11 @@ func (cmd *trendReportCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&cmd.benchmarkFilterExpr, "benchmark_filter", "", "Regular expression to filter experiments based on benchmark name")
 	f.BoolVar(&cmd.hiddenResultsTable, "hide_table", false, "Hide the results table by default")
 	f.StringVar(&cmd.customLabels, "labels", "", "Use custom labels (comma separated, no spaces, e.g.: \"a,b,c\")")
+	cmd.outputOptions.setFlags(f)
+	f.StringVar(&cmd.benchmarksSet, "benchmarks", "", "Benchmarks to include when jobs ran different sets: union (default, missing values shown as gaps) or intersection")
 }
 
-func (cmd *trendReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (cmd *trendReportCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
 	if rootOptions.verbose {
 		fmt.Printf("%s args: %v\n", cmd.name, f.Args())
 	}
@@ -55,10 +61,7 @@ func (cmd *trendReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...inte
 		return subcommands.ExitUsageError
 	}
 
-	c, err := client.NewClient(
-		rootOptions.natsServerUrl,
-		rootOptions.credentials,
-		rootOptions.namespace,
+	c, done, err := cmd.clientOptions.newReportClient(
 		client.InitJobsRepository(),
 		client.InitArtifactsStore(),
 		client.Verbose(rootOptions.verbose),
@@ -68,9 +71,9 @@ func (cmd *trendReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...inte
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return subcommands.ExitFailure
 	}
-	defer c.Close()
+	defer done()
 
-	dataTable, err := reports.CreateDataTable(c, jobIds...)
+	dataTable, err := reports.CreateDataTableWithContext(ctx, c, jobIds...)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return subcommands.ExitFailure
@@ -80,6 +83,23 @@ func (cmd *trendReportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...inte
 		cmd.reportCfg.Verbose()
 	}
 
+	if err := cmd.outputOptions.configure(&cmd.reportCfg); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return subcommands.ExitUsageError
+	}
+
+	if cmd.benchmarksSet != "" {
+		benchmarksSet, err := reports.ParseBenchmarksSet(cmd.benchmarksSet)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitUsageError
+		}
+		if err := cmd.reportCfg.SetBenchmarksSet(benchmarksSet); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitUsageError
+		}
+	}
+
 	if cmd.customLabels != "" {
 		cmd.reportCfg.SetCustomLabels(strings.Split(cmd.customLabels, ","))
 	}
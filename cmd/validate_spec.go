@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mprimi/go-bench-away/v1/reports"
+
+	"github.com/google/subcommands"
+)
+
+type validateSpecCmd struct {
+	baseCommand
+	specPath    string
+	printSchema bool
+}
+
+func validateSpecCommand() subcommands.Command {
+	return &validateSpecCmd{
+		baseCommand: baseCommand{
+			name:     "validate-spec",
+			synopsis: "Checks a report specification (JSON or YAML) and lists all problems found",
+			usage:    "validate-spec [options] -spec <spec_file> [jobId1 jobId2 ... jobIdN]\n",
+		},
+	}
+}
+
+func (cmd *validateSpecCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&cmd.specPath, "spec", "spec.json", "Report configuration (JSON or YAML)")
+	f.BoolVar(&cmd.printSchema, "print_schema", false, "Print the JSON Schema of report specifications and exit")
+}
+
+func (cmd *validateSpecCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if rootOptions.verbose {
+		fmt.Printf("%s args: %v\n", cmd.name, f.Args())
+	}
+
+	if cmd.printSchema {
+		schema, err := reports.ReportSpecJSONSchema()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitFailure
+		}
+		fmt.Printf("%s\n", schema)
+		return subcommands.ExitSuccess
+	}
+
+	spec := &reports.ReportSpec{}
+	err := spec.LoadFile(cmd.specPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load report spec: %s\n", err)
+		return subcommands.ExitFailure
+	}
+
+	// Jobs given as arguments take precedence over jobs listed in the spec.
+	// If the spec selects jobs via query, their number is not known without querying.
+	numJobs := len(f.Args())
+	if numJobs == 0 && spec.Jobs != nil && spec.Jobs.Query == nil {
+		numJobs = len(spec.Jobs.Ids)
+	}
+
+	problems := spec.Validate(numJobs)
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.specPath, problem)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "Found %d problems\n", len(problems))
+		return subcommands.ExitFailure
+	}
+
+	fmt.Printf("Report spec %s is valid\n", cmd.specPath)
+	return subcommands.ExitSuccess
+}
@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/mprimi/go-bench-away/internal/retention"
+	"github.com/mprimi/go-bench-away/internal/scheduler"
 	"github.com/mprimi/go-bench-away/internal/web"
 	"github.com/mprimi/go-bench-away/v1/client"
 
@@ -16,8 +19,20 @@ import (
 
 type webCmd struct {
 	baseCommand
-	port     int
-	altQueue string
+	port          int
+	altQueue      string
+	runScheduler  bool
+	retentionDays int
+	auth          webAuthOptions
+}
+
+type webAuthOptions struct {
+	method         string
+	file           string
+	rolesFile      string
+	defaultRole    string
+	proxyHeader    string
+	trustedProxies string
 }
 
 func webCommand() subcommands.Command {
@@ -33,10 +48,17 @@ func webCommand() subcommands.Command {
 func (cmd *webCmd) SetFlags(f *flag.FlagSet) {
 	f.IntVar(&cmd.port, "port", 8888, "Port number")
 	f.StringVar(&cmd.altQueue, "queue", "", "Load jobs from a non-default queue with the specified name")
-
+	f.BoolVar(&cmd.runScheduler, "scheduler", false, "Also run the scheduler, submitting jobs of recurring schedules")
+	f.IntVar(&cmd.retentionDays, "retention_days", 0, "Also run the garbage collector, deleting logs and scripts of unpinned jobs older than the given number of days (0: disabled)")
+	f.StringVar(&cmd.auth.method, "auth", "none", "Authentication: none, token (bearer tokens), htpasswd (HTTP basic auth), proxy (header set by a trusted proxy)")
+	f.StringVar(&cmd.auth.file, "auth_file", "", "Tokens file (lines of: <token> <username> <role>) or htpasswd file (bcrypt or SHA1 hashes)")
+	f.StringVar(&cmd.auth.rolesFile, "auth_roles", "", "Roles of htpasswd and proxy users (lines of: <username> <role>)")
+	f.StringVar(&cmd.auth.defaultRole, "auth_default_role", "viewer", "Role of htpasswd and proxy users not listed in the roles file: viewer, submitter, admin")
+	f.StringVar(&cmd.auth.proxyHeader, "auth_proxy_header", "X-Forwarded-User", "Header containing the username, set by the trusted proxy")
+	f.StringVar(&cmd.auth.trustedProxies, "auth_trusted_proxies", "127.0.0.1/32,::1/128", "Networks (CIDR, comma separated) of trusted proxies")
 }
 
-func (cmd *webCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+func (cmd *webCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
 	if rootOptions.verbose {
 		fmt.Printf("%s args: %v\n", cmd.name, f.Args())
 	}
@@ -48,6 +70,10 @@ func (cmd *webCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{})
 		client.InitArtifactsStore(),
 	}
 
+	if cmd.runScheduler {
+		clientOpts = append(clientOpts, client.InitSchedulesRepository())
+	}
+
 	if cmd.altQueue != "" {
 		clientOpts = append(clientOpts, client.WithAltQueue(cmd.altQueue))
 	}
@@ -65,13 +91,42 @@ func (cmd *webCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{})
 	}
 	defer c.Close()
 
-	handler := web.NewHandler(c)
+	if cmd.runScheduler {
+		go func() {
+			err := scheduler.NewScheduler(c, 0).Run(ctx)
+			fmt.Fprintf(os.Stderr, "Scheduler stopped: %v\n", err)
+		}()
+	}
+
+	if cmd.retentionDays > 0 {
+		collector, err := retention.NewCollector(c, time.Duration(cmd.retentionDays)*24*time.Hour, 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitUsageError
+		}
+		go func() {
+			err := collector.Run(ctx)
+			fmt.Fprintf(os.Stderr, "Garbage collector stopped: %v\n", err)
+		}()
+	}
+
+	handlerOpts := []web.Option{}
+	if cmd.auth.method != "none" {
+		authenticator, err := cmd.auth.authenticator()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitUsageError
+		}
+		handlerOpts = append(handlerOpts, web.WithAuthenticator(authenticator))
+	}
+
+	handler := web.NewHandler(c, handlerOpts...)
 
 	s := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cmd.port),
 		Handler:      handler,
 		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		WriteTimeout: 60 * time.Second, // Generating reports can take a while
 	}
 
 	fmt.Printf("Listening on: %s\n", s.Addr)
@@ -84,3 +139,35 @@ func (cmd *webCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{})
 
 	return subcommands.ExitSuccess
 }
+
+func (opts *webAuthOptions) authenticator() (web.Authenticator, error) {
+	defaultRole, err := web.ParseRole(opts.defaultRole)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := map[string]web.Role{}
+	if opts.rolesFile != "" {
+		roles, err = web.LoadRolesFile(opts.rolesFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch opts.method {
+	case "token":
+		if opts.file == "" {
+			return nil, fmt.Errorf("auth method %s requires -auth_file", opts.method)
+		}
+		return web.LoadTokensFile(opts.file)
+	case "htpasswd":
+		if opts.file == "" {
+			return nil, fmt.Errorf("auth method %s requires -auth_file", opts.method)
+		}
+		return web.LoadHtpasswdFile(opts.file, roles, defaultRole)
+	case "proxy":
+		return web.NewTrustedProxyAuthenticator(opts.proxyHeader, strings.Split(opts.trustedProxies, ","), roles, defaultRole)
+	default:
+		return nil, fmt.Errorf("unknown auth method: %s", opts.method)
+	}
+}
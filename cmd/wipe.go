@@ -20,7 +20,7 @@ func wipeCommand() subcommands.Command {
 	return &wipeCmd{
 		baseCommand: baseCommand{
 			name:     "wipe",
-			synopsis: "Deletes server schemas (Stream, KV store, Object store)",
+			synopsis: "Deletes server schemas (Stream, KV stores, Object store)",
 			usage:    "wipe\n",
 		},
 	}
@@ -59,7 +59,9 @@ func (cmd *wipeCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}
 	initFuncs := []func() error{
 		c.DeleteJobsQueue,
 		c.DeleteJobsRepository,
+		c.DeleteJobsIndex,
 		c.DeleteArtifactsStore,
+		c.DeleteSchedulesRepository,
 	}
 
 	for _, fun := range initFuncs {
@@ -5,7 +5,11 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/mprimi/go-bench-away/internal/retention"
+	"github.com/mprimi/go-bench-away/internal/scheduler"
 	"github.com/mprimi/go-bench-away/internal/worker"
 	"github.com/mprimi/go-bench-away/v1/client"
 
@@ -17,6 +21,16 @@ type workerCmd struct {
 	jobsDir             string
 	altQueue            string
 	gitRemoteFilterExpr string
+	runScheduler        bool
+	retentionDays       int
+	preflight           worker.PreflightConfig
+	preflightMinFreeMB  uint64
+	sampleInterval      time.Duration
+	scriptTemplatePath  string
+	namedScripts        map[string]string
+	allowedEnv          string
+	allowedTestFlags    string
+	cacheDir            string
 }
 
 func workerCommand() subcommands.Command {
@@ -33,6 +47,30 @@ func (cmd *workerCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&cmd.jobsDir, "jobs_dir", "", "Directory where jobs are staged (defaults to os.MkdirTemp)")
 	f.StringVar(&cmd.altQueue, "queue", "", "Consume job from a non-default queue with the specified name")
 	f.StringVar(&cmd.gitRemoteFilterExpr, "gitRemoteFilterExpr", "", "Regex to restrict which git remotes can be targeted")
+	f.BoolVar(&cmd.runScheduler, "scheduler", false, "Also run the scheduler, submitting jobs of recurring schedules")
+	f.Float64Var(&cmd.preflight.MaxLoadAvg, "preflight_max_load", 0, "Before each job, check the 1-minute load average is at most this value (0: disabled)")
+	f.StringVar(&cmd.preflight.Governor, "preflight_governor", "", "Before each job, check the CPU frequency governor is this one, e.g. performance (empty: disabled)")
+	f.Float64Var(&cmd.preflight.MaxProcessCPU, "preflight_max_process_cpu", 0, "Before each job, check no other process uses more than this CPU percentage (0: disabled)")
+	f.Uint64Var(&cmd.preflightMinFreeMB, "preflight_min_free_mb", 0, "Before each job, check at least this much memory is available, in MB (0: disabled)")
+	f.IntVar(&cmd.preflight.Retries, "preflight_retries", 3, "Number of times pre-flight checks are repeated if they fail, before running the job with a noisy environment warning")
+	f.DurationVar(&cmd.preflight.RetryDelay, "preflight_retry_delay", 1*time.Minute, "Delay between pre-flight checks attempts")
+	f.DurationVar(&cmd.sampleInterval, "sample_interval", 1*time.Second, "Interval between samples of the resource usage of running jobs (0: disabled)")
+	f.StringVar(&cmd.scriptTemplatePath, "script_template", "", "Benchmark script template file, replacing the default script")
+	f.Func("named_script_template", "Benchmark script template that jobs can select by name, as name=path (can be repeated)", func(s string) error {
+		name, path, found := strings.Cut(s, "=")
+		if !found || name == "" || path == "" {
+			return fmt.Errorf("expected name=path")
+		}
+		if cmd.namedScripts == nil {
+			cmd.namedScripts = map[string]string{}
+		}
+		cmd.namedScripts[name] = path
+		return nil
+	})
+	f.StringVar(&cmd.allowedEnv, "allowed_env", strings.Join(worker.DefaultAllowedEnv, ","), "Comma-separated environment variables jobs can set")
+	f.StringVar(&cmd.allowedTestFlags, "allowed_test_flags", strings.Join(worker.DefaultAllowedTestFlags, ","), "Comma-separated extra go test flags jobs can set (by name, e.g. race,gcflags)")
+	f.StringVar(&cmd.cacheDir, "cache_dir", "", "Directory where git mirrors of remotes and the Go module cache are kept across jobs (empty: disabled)")
+	f.IntVar(&cmd.retentionDays, "retention_days", 0, "Also run the garbage collector, deleting logs and scripts of unpinned jobs older than the given number of days (0: disabled)")
 }
 
 func (cmd *workerCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
@@ -48,6 +86,10 @@ func (cmd *workerCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interfa
 		client.WithClientName("go-bench-away Worker"),
 	}
 
+	if cmd.runScheduler {
+		clientOpts = append(clientOpts, client.InitSchedulesRepository())
+	}
+
 	if cmd.altQueue != "" {
 		clientOpts = append(
 			clientOpts,
@@ -83,12 +125,49 @@ func (cmd *workerCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interfa
 		}
 	}
 
-	w, err := worker.NewWorker(c, cmd.jobsDir, allowedGitRemoteExpr)
+	cmd.preflight.MinFreeMemory = cmd.preflightMinFreeMB << 20
+
+	workerOpts := []worker.Option{
+		worker.WithPreflight(cmd.preflight),
+		worker.WithResourceSampling(cmd.sampleInterval),
+		worker.WithAllowedEnv(strings.Split(cmd.allowedEnv, ",")),
+		worker.WithAllowedTestFlags(strings.Split(cmd.allowedTestFlags, ",")),
+	}
+	if cmd.scriptTemplatePath != "" {
+		workerOpts = append(workerOpts, worker.WithScriptTemplate(cmd.scriptTemplatePath))
+	}
+	if cmd.cacheDir != "" {
+		workerOpts = append(workerOpts, worker.WithCacheDir(cmd.cacheDir))
+	}
+	for name, path := range cmd.namedScripts {
+		workerOpts = append(workerOpts, worker.WithNamedScriptTemplate(name, path))
+	}
+
+	w, err := worker.NewWorker(c, cmd.jobsDir, allowedGitRemoteExpr, workerOpts...)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return subcommands.ExitFailure
 	}
 
+	if cmd.runScheduler {
+		go func() {
+			err := scheduler.NewScheduler(c, 0).Run(ctx)
+			fmt.Fprintf(os.Stderr, "Scheduler stopped: %v\n", err)
+		}()
+	}
+
+	if cmd.retentionDays > 0 {
+		collector, err := retention.NewCollector(c, time.Duration(cmd.retentionDays)*24*time.Hour, 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return subcommands.ExitUsageError
+		}
+		go func() {
+			err := collector.Run(ctx)
+			fmt.Fprintf(os.Stderr, "Garbage collector stopped: %v\n", err)
+		}()
+	}
+
 	err = w.Run(ctx)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
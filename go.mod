@@ -3,25 +3,30 @@ module github.com/mprimi/go-bench-away
 go 1.18
 
 require (
+	github.com/google/pprof v0.0.0-20221118152302-e6195bd50e26
 	github.com/google/subcommands v1.2.0
 	github.com/google/uuid v1.3.0
 	github.com/montanaflynn/stats v0.7.0
 	github.com/nats-io/nats-server/v2 v2.9.3
 	github.com/nats-io/nats.go v1.25.0
+	golang.org/x/crypto v0.9.0
 	golang.org/x/perf v0.0.0-20230427221525-d343f6398b76
 	golang.org/x/sys v0.8.0
+	sigs.k8s.io/yaml v1.3.0
 )
 
 require (
+	github.com/chzyer/readline v1.5.0 // indirect
 	github.com/golang/protobuf v1.5.0 // indirect
 	github.com/google/safehtml v0.1.0 // indirect
+	github.com/ianlancetaylor/demangle v0.0.0-20220319035150-800ac71e25c2 // indirect
 	github.com/klauspost/compress v1.15.11 // indirect
 	github.com/minio/highwayhash v1.0.2 // indirect
 	github.com/nats-io/jwt/v2 v2.3.0 // indirect
 	github.com/nats-io/nkeys v0.4.4 // indirect
 	github.com/nats-io/nuid v1.0.1 // indirect
-	golang.org/x/crypto v0.9.0 // indirect
 	golang.org/x/text v0.9.0 // indirect
 	golang.org/x/time v0.0.0-20220922220347-f3bd1da661af // indirect
 	google.golang.org/protobuf v1.28.1 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
@@ -0,0 +1,11 @@
+package retention
+
+import (
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+type GarbageCollectorClient interface {
+	CollectGarbage(maxAge time.Duration, dryRun bool) (*core.GCReport, error)
+}
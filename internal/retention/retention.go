@@ -0,0 +1,60 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	kDefaultInterval = 24 * time.Hour
+)
+
+type Collector interface {
+	Run(context.Context) error
+}
+
+type collectorImpl struct {
+	c        GarbageCollectorClient
+	maxAge   time.Duration
+	interval time.Duration
+}
+
+// NewCollector creates a collector that deletes logs and scripts of jobs older than maxAge every interval
+// (default 24h if zero). Results and pinned jobs are kept.
+func NewCollector(c GarbageCollectorClient, maxAge, interval time.Duration) (Collector, error) {
+	if maxAge <= 0 {
+		return nil, fmt.Errorf("Invalid retention: %v", maxAge)
+	}
+	if interval <= 0 {
+		interval = kDefaultInterval
+	}
+	return &collectorImpl{
+		c:        c,
+		maxAge:   maxAge,
+		interval: interval,
+	}, nil
+}
+
+func (gc *collectorImpl) Run(ctx context.Context) error {
+	fmt.Printf("🧹 Garbage collector running (retention: %v)\n", gc.maxAge)
+
+	ticker := time.NewTicker(gc.interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := gc.c.CollectGarbage(gc.maxAge, false)
+		if err != nil {
+			fmt.Printf("🧹 Garbage collection error: %v\n", err)
+		}
+		if report != nil && report.JobsCollected > 0 {
+			fmt.Printf("🧹 %v\n", report)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+type mockClient struct {
+	sync.Mutex
+	calls []time.Duration
+	err   error
+}
+
+func (c *mockClient) CollectGarbage(maxAge time.Duration, dryRun bool) (*core.GCReport, error) {
+	c.Lock()
+	defer c.Unlock()
+	if dryRun {
+		return nil, fmt.Errorf("unexpected dry run")
+	}
+	c.calls = append(c.calls, maxAge)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &core.GCReport{JobsCollected: 1}, nil
+}
+
+func (c *mockClient) callsCount() int {
+	c.Lock()
+	defer c.Unlock()
+	return len(c.calls)
+}
+
+func TestCollector(t *testing.T) {
+	c := &mockClient{err: fmt.Errorf("test error")}
+
+	if _, err := NewCollector(c, 0, 0); err == nil {
+		t.Fatalf("Expected error for invalid retention")
+	}
+
+	maxAge := 7 * 24 * time.Hour
+	gc, err := NewCollector(c, maxAge, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- gc.Run(ctx)
+	}()
+
+	// Keeps running despite errors
+	deadline := time.Now().Add(5 * time.Second)
+	for c.callsCount() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for collections")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	for _, age := range c.calls {
+		if age != maxAge {
+			t.Fatalf("Unexpected retention: %v", age)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package scheduler
+
+import (
+	"github.com/mprimi/go-bench-away/v1/core"
+	"github.com/mprimi/go-bench-away/v1/reports"
+)
+
+type SchedulesClient interface {
+	ListSchedules() ([]*core.Schedule, error)
+	LoadSchedule(string) (*core.Schedule, uint64, error)
+	UpdateSchedule(*core.Schedule, uint64) (uint64, error)
+}
+
+type SubmitterClient interface {
+	SubmitJob(core.JobParameters) (*core.JobRecord, error)
+}
+
+type SeriesReportClient interface {
+	reports.JobRecordClient
+	UploadSeriesReportArtifact(string, []byte) (string, error)
+}
+
+type SchedulerClient interface {
+	SchedulesClient
+	SubmitterClient
+	SeriesReportClient
+}
@@ -0,0 +1,260 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+	"github.com/mprimi/go-bench-away/v1/reports"
+)
+
+const (
+	kDefaultInterval = 30 * time.Second
+	// Attempts to update a schedule record modified concurrently
+	kMaxUpdateAttempts = 5
+	// Maximum number of job records loaded concurrently when creating a series report
+	kMaxConcurrentLoads = 16
+)
+
+type Scheduler interface {
+	Run(context.Context) error
+}
+
+type schedulerImpl struct {
+	c        SchedulerClient
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewScheduler creates a scheduler that checks schedules every interval (default 30s if zero).
+// Multiple schedulers can run concurrently, each due job is submitted by only one of them.
+func NewScheduler(c SchedulerClient, interval time.Duration) Scheduler {
+	if interval <= 0 {
+		interval = kDefaultInterval
+	}
+	return &schedulerImpl{
+		c:        c,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+func (s *schedulerImpl) Run(ctx context.Context) error {
+	fmt.Printf("⏰ Scheduler running\n")
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.tick(); err != nil {
+			fmt.Printf("⏰ Scheduler error: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Process all schedules: submit due jobs, then regenerate reports of series with a newly completed job
+func (s *schedulerImpl) tick() error {
+	schedules, err := s.c.ListSchedules()
+	if err != nil {
+		return fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	for _, schedule := range schedules {
+		if schedule.IsDue(s.now()) {
+			if err := s.submitJob(schedule.Name); err != nil {
+				fmt.Printf("⏰ Schedule %s: failed to submit job: %v\n", schedule.Name, err)
+			}
+		}
+		if err := s.updateReport(schedule.Name); err != nil {
+			fmt.Printf("⏰ Schedule %s: failed to update report: %v\n", schedule.Name, err)
+		}
+	}
+	return nil
+}
+
+// Submit a job for the given schedule, unless another scheduler claimed this run first
+func (s *schedulerImpl) submitJob(name string) error {
+	schedule, revision, err := s.c.LoadSchedule(name)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	if !schedule.IsDue(now) {
+		return nil
+	}
+
+	// Claim the run by advancing the schedule, fails if another scheduler did it first
+	lastRun, nextRun := schedule.LastRun, schedule.NextRun
+	if err := schedule.Advance(now); err != nil {
+		return err
+	}
+	if _, err := s.c.UpdateSchedule(schedule, revision); errors.Is(err, core.ErrScheduleConflict) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to claim run: %w", err)
+	}
+
+	params := schedule.Parameters
+	params.Username = fmt.Sprintf("scheduler (%s)", schedule.Name)
+	job, err := s.c.SubmitJob(params)
+	if err != nil {
+		// Release the claim, so that the run is retried on the next tick
+		claimedNextRun := schedule.NextRun
+		releaseErr := s.modifySchedule(name, func(schedule *core.Schedule) {
+			if schedule.NextRun.Equal(claimedNextRun) {
+				schedule.LastRun, schedule.NextRun = lastRun, nextRun
+			}
+		})
+		if releaseErr != nil {
+			return fmt.Errorf("%w (run of %s skipped, failed to release it: %v)", err, nextRun, releaseErr)
+		}
+		return fmt.Errorf("%w (run of %s will be retried)", err, nextRun)
+	}
+
+	fmt.Printf("⏰ Schedule %s: submitted job %s, next run: %s\n", schedule.Name, job.Id, schedule.NextRun)
+
+	return s.modifySchedule(name, func(schedule *core.Schedule) {
+		schedule.AddJob(job.Id)
+	})
+}
+
+// Regenerate the series report, if the most recent job completed since the last report
+func (s *schedulerImpl) updateReport(name string) error {
+	schedule, _, err := s.c.LoadSchedule(name)
+	if err != nil {
+		return err
+	}
+
+	lastJobId := schedule.LastJobId()
+	if lastJobId == "" || lastJobId == schedule.ReportLastJobId {
+		return nil
+	}
+
+	lastJob, _, err := s.c.LoadJob(lastJobId)
+	if err != nil {
+		return err
+	}
+	if !lastJob.IsCompleted() {
+		return nil
+	}
+
+	// Record the report attempt even if it fails, to avoid retrying on each tick
+	reportKey, reportErr := s.writeReport(schedule)
+	if reportErr == nil && reportKey != "" {
+		fmt.Printf("⏰ Schedule %s: updated series report %s\n", name, reportKey)
+	}
+
+	err = s.modifySchedule(name, func(schedule *core.Schedule) {
+		if reportKey != "" {
+			schedule.Report = reportKey
+		}
+		schedule.ReportLastJobId = lastJobId
+	})
+	if reportErr != nil {
+		return reportErr
+	}
+	return err
+}
+
+// Create a trend report of the successful jobs in the series, and upload it.
+// Returns an empty key if no job in the series succeeded yet.
+func (s *schedulerImpl) writeReport(schedule *core.Schedule) (string, error) {
+	jobs := make([]*core.JobRecord, len(schedule.JobIds))
+	errs := core.ForEachParallel(context.Background(), len(jobs), kMaxConcurrentLoads, func(_ context.Context, i int) error {
+		job, _, err := s.c.LoadJob(schedule.JobIds[i])
+		if errors.Is(err, core.ErrJobNotFound) {
+			// Deleted (e.g. by retention), the series report no longer includes it
+			return nil
+		} else if err != nil {
+			return err
+		}
+		jobs[i] = job
+		return nil
+	})
+	if err := errs.Err(); err != nil {
+		return "", err
+	}
+
+	jobIds := []string{}
+	for _, job := range jobs {
+		if job != nil && job.Status == core.Succeeded {
+			jobIds = append(jobIds, job.Id)
+		}
+	}
+
+	if limit := schedule.ReportJobsLimit; limit > 0 && len(jobIds) > limit {
+		jobIds = jobIds[len(jobIds)-limit:]
+	}
+
+	if len(jobIds) == 0 {
+		return "", nil
+	}
+
+	dataTable, err := reports.CreateDataTable(s.c, jobIds...)
+	if err != nil {
+		return "", err
+	}
+
+	reportCfg := reports.ReportConfig{
+		Title: fmt.Sprintf("Series: %s", schedule.Name),
+	}
+
+	if len(schedule.ReportSpec) > 0 {
+		// Jobs selected in the spec (if any) are ignored, the report includes the series jobs
+		spec := &reports.ReportSpec{}
+		if err := spec.Load(bytes.NewReader(schedule.ReportSpec)); err != nil {
+			return "", err
+		}
+		if err := spec.ConfigureReport(&reportCfg); err != nil {
+			return "", err
+		}
+	} else {
+		reportCfg.AddSections(
+			reports.JobsTable(),
+			reports.TrendChart("", reports.TimeOp, ""),
+			reports.ResultsTable(reports.TimeOp, "", true),
+		)
+		if dataTable.HasSpeed() {
+			reportCfg.AddSections(
+				reports.TrendChart("", reports.Speed, ""),
+				reports.ResultsTable(reports.Speed, "", true),
+			)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := reports.WriteReport(&reportCfg, dataTable, &buf); err != nil {
+		return "", err
+	}
+
+	return s.c.UploadSeriesReportArtifact(schedule.Name, buf.Bytes())
+}
+
+// Load, modify and update a schedule record, retrying if it is modified concurrently
+func (s *schedulerImpl) modifySchedule(name string, modify func(*core.Schedule)) error {
+	var err error
+	for attempt := 0; attempt < kMaxUpdateAttempts; attempt++ {
+		schedule, revision, loadErr := s.c.LoadSchedule(name)
+		if loadErr != nil {
+			return loadErr
+		}
+		modify(schedule)
+		if _, err = s.c.UpdateSchedule(schedule, revision); !errors.Is(err, core.ErrScheduleConflict) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update schedule %s: %w", name, err)
+	}
+	return nil
+}
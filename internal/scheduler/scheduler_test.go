@@ -0,0 +1,302 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+// Job records and results used by report tests
+var testDataDir = filepath.Join("..", "..", "v1", "reports", "testdata")
+
+var testJobIds = []string{
+	"067997a3-761e-475e-9559-f10d7400b835",
+	"dd146049-0137-4ba0-89b1-0a2f8d0a2268",
+}
+
+type mockClient struct {
+	schedules          map[string]*core.Schedule
+	revisions          map[string]uint64
+	jobs               map[string]*core.JobRecord
+	submitted          []core.JobParameters
+	uploads            map[string][]byte
+	StubUpdateSchedule func(*core.Schedule, uint64) (uint64, error)
+	StubSubmitJob      func(core.JobParameters) (*core.JobRecord, error)
+}
+
+func newMockClient() *mockClient {
+	return &mockClient{
+		schedules: map[string]*core.Schedule{},
+		revisions: map[string]uint64{},
+		jobs:      map[string]*core.JobRecord{},
+		uploads:   map[string][]byte{},
+	}
+}
+
+func (c *mockClient) ListSchedules() ([]*core.Schedule, error) {
+	schedules := []*core.Schedule{}
+	for _, schedule := range c.schedules {
+		s, _ := core.LoadSchedule(schedule.Bytes())
+		schedules = append(schedules, s)
+	}
+	return schedules, nil
+}
+
+func (c *mockClient) LoadSchedule(name string) (*core.Schedule, uint64, error) {
+	schedule, found := c.schedules[name]
+	if !found {
+		return nil, 0, fmt.Errorf("schedule not found: %s", name)
+	}
+	s, _ := core.LoadSchedule(schedule.Bytes())
+	return s, c.revisions[name], nil
+}
+
+func (c *mockClient) UpdateSchedule(schedule *core.Schedule, revision uint64) (uint64, error) {
+	if c.StubUpdateSchedule != nil {
+		return c.StubUpdateSchedule(schedule, revision)
+	}
+	if c.revisions[schedule.Name] != revision {
+		return 0, core.ErrScheduleConflict
+	}
+	c.schedules[schedule.Name] = schedule
+	c.revisions[schedule.Name] += 1
+	return c.revisions[schedule.Name], nil
+}
+
+func (c *mockClient) SubmitJob(params core.JobParameters) (*core.JobRecord, error) {
+	if c.StubSubmitJob != nil {
+		return c.StubSubmitJob(params)
+	}
+	if len(c.submitted) >= len(testJobIds) {
+		return nil, fmt.Errorf("out of test jobs")
+	}
+	jobId := testJobIds[len(c.submitted)]
+	data, err := os.ReadFile(filepath.Join(testDataDir, fmt.Sprintf("%s.json", jobId)))
+	if err != nil {
+		return nil, err
+	}
+	job, err := core.LoadJob(data)
+	if err != nil {
+		return nil, err
+	}
+	job.Parameters = params
+	job.Status = core.Submitted
+	c.jobs[jobId] = job
+	c.submitted = append(c.submitted, params)
+	return job, nil
+}
+
+func (c *mockClient) LoadJob(jobId string) (*core.JobRecord, uint64, error) {
+	job, found := c.jobs[jobId]
+	if !found {
+		return nil, 0, fmt.Errorf("%w: '%s'", core.ErrJobNotFound, jobId)
+	}
+	return job, 1, nil
+}
+
+func (c *mockClient) LoadResultsArtifact(job *core.JobRecord, writer io.Writer) error {
+	file, err := os.Open(filepath.Join(testDataDir, fmt.Sprintf("%s_results.txt", job.Id)))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(writer, file)
+	return err
+}
+
+func (c *mockClient) UploadSeriesReportArtifact(name string, report []byte) (string, error) {
+	key := fmt.Sprintf("series/%s/report.html", name)
+	c.uploads[key] = report
+	return key, nil
+}
+
+func TestScheduler(t *testing.T) {
+	client := newMockClient()
+
+	params := core.JobParameters{
+		GitRemote: "https://github.com/nats-io/nats-server.git",
+		GitRef:    "main",
+		Reps:      3,
+	}
+	schedule, err := core.NewSchedule("nightly", "@daily", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.schedules[schedule.Name] = schedule
+
+	now := schedule.NextRun.Add(-1 * time.Minute)
+	s := NewScheduler(client, 0).(*schedulerImpl)
+	s.now = func() time.Time { return now }
+
+	// Not due yet
+	if err := s.tick(); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.submitted) != 0 {
+		t.Fatalf("Unexpected job submitted before schedule is due")
+	}
+
+	// Due
+	now = schedule.NextRun
+	if err := s.tick(); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.submitted) != 1 {
+		t.Fatalf("Expected 1 job submitted, got %d", len(client.submitted))
+	} else if client.submitted[0].GitRef != "main" || client.submitted[0].Username != "scheduler (nightly)" {
+		t.Fatalf("Unexpected job parameters: %+v", client.submitted[0])
+	}
+
+	schedule, _, _ = client.LoadSchedule("nightly")
+	if schedule.LastJobId() != testJobIds[0] {
+		t.Fatalf("Unexpected series jobs: %v", schedule.JobIds)
+	} else if !schedule.NextRun.Equal(now.Add(24 * time.Hour)) {
+		t.Fatalf("Unexpected next run: %v", schedule.NextRun)
+	}
+
+	// Job not completed, no report yet
+	if len(client.uploads) != 0 || schedule.Report != "" {
+		t.Fatalf("Unexpected report before job completion")
+	}
+
+	// Job completed, report is generated once
+	client.jobs[testJobIds[0]].Status = core.Succeeded
+	for i := 0; i < 2; i++ {
+		if err := s.tick(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	schedule, _, _ = client.LoadSchedule("nightly")
+	if len(client.submitted) != 1 {
+		t.Fatalf("Unexpected job submitted")
+	} else if schedule.Report != "series/nightly/report.html" || schedule.ReportLastJobId != testJobIds[0] {
+		t.Fatalf("Unexpected report state: %s, %s", schedule.Report, schedule.ReportLastJobId)
+	} else if !strings.Contains(string(client.uploads[schedule.Report]), "Series: nightly") {
+		t.Fatalf("Unexpected report content")
+	}
+
+	// Paused, no submission
+	schedule.Paused = true
+	client.schedules["nightly"] = schedule
+	now = schedule.NextRun
+	if err := s.tick(); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.submitted) != 1 {
+		t.Fatalf("Unexpected job submitted while paused")
+	}
+
+	// Run claimed by another scheduler, no submission
+	schedule.Paused = false
+	client.StubUpdateSchedule = func(*core.Schedule, uint64) (uint64, error) {
+		return 0, core.ErrScheduleConflict
+	}
+	if err := s.tick(); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.submitted) != 1 {
+		t.Fatalf("Unexpected job submitted for run claimed by another scheduler")
+	}
+
+	// Other errors claiming the run are not mistaken for a conflict
+	client.StubUpdateSchedule = func(*core.Schedule, uint64) (uint64, error) {
+		return 0, fmt.Errorf("timeout")
+	}
+	if err := s.submitJob("nightly"); err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("Expected error claiming run, got: %v", err)
+	}
+
+	// Failed submission releases the claimed run
+	client.StubUpdateSchedule = nil
+	client.StubSubmitJob = func(core.JobParameters) (*core.JobRecord, error) {
+		return nil, fmt.Errorf("queue unavailable")
+	}
+	nextRun := schedule.NextRun
+	if err := s.submitJob("nightly"); err == nil || !strings.Contains(err.Error(), "queue unavailable") {
+		t.Fatalf("Expected error submitting job, got: %v", err)
+	}
+	if released, _, _ := client.LoadSchedule("nightly"); !released.NextRun.Equal(nextRun) || !released.IsDue(now) {
+		t.Fatalf("Run not released after failed submission: %+v", released)
+	}
+	client.StubSubmitJob = nil
+	schedule = client.schedules["nightly"]
+
+	// Second run, report includes both jobs (with a custom spec)
+	client.StubUpdateSchedule = nil
+	schedule.ReportSpec = []byte(`{"title": "Custom series report", "sections": [{"type": "trend_chart", "metric": "time/op"}]}`)
+	if err := s.tick(); err != nil {
+		t.Fatal(err)
+	}
+	client.jobs[testJobIds[1]].Status = core.Succeeded
+	if err := s.tick(); err != nil {
+		t.Fatal(err)
+	}
+	schedule, _, _ = client.LoadSchedule("nightly")
+	if len(schedule.JobIds) != 2 || schedule.ReportLastJobId != testJobIds[1] {
+		t.Fatalf("Unexpected schedule state: %+v", schedule)
+	}
+	report := string(client.uploads[schedule.Report])
+	if !strings.Contains(report, "Custom series report") || !strings.Contains(report, testJobIds[0]) || !strings.Contains(report, testJobIds[1]) {
+		t.Fatalf("Unexpected report content")
+	}
+
+	// Deleted jobs are left out of the report
+	delete(client.jobs, testJobIds[0])
+	if _, err := s.writeReport(schedule); err != nil {
+		t.Fatal(err)
+	}
+	report = string(client.uploads[schedule.Report])
+	if strings.Contains(report, testJobIds[0]) || !strings.Contains(report, testJobIds[1]) {
+		t.Fatalf("Unexpected report content after job deletion")
+	}
+}
+
+func TestModifySchedule(t *testing.T) {
+	client := newMockClient()
+	schedule, err := core.NewSchedule("nightly", "@daily", core.JobParameters{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.schedules[schedule.Name] = schedule
+	s := NewScheduler(client, 0).(*schedulerImpl)
+
+	// Conflicts are retried
+	attempts := 0
+	client.StubUpdateSchedule = func(*core.Schedule, uint64) (uint64, error) {
+		attempts += 1
+		if attempts < 3 {
+			return 0, core.ErrScheduleConflict
+		}
+		return 1, nil
+	}
+	if err := s.modifySchedule("nightly", func(*core.Schedule) {}); err != nil || attempts != 3 {
+		t.Fatalf("Expected success after 3 attempts, got: %v after %d attempts", err, attempts)
+	}
+
+	// Other errors are not
+	attempts = 0
+	client.StubUpdateSchedule = func(*core.Schedule, uint64) (uint64, error) {
+		attempts += 1
+		return 0, fmt.Errorf("timeout")
+	}
+	if err := s.modifySchedule("nightly", func(*core.Schedule) {}); err == nil || attempts != 1 {
+		t.Fatalf("Expected error after 1 attempt, got: %v after %d attempts", err, attempts)
+	}
+
+	// Give up after too many conflicts
+	attempts = 0
+	client.StubUpdateSchedule = func(*core.Schedule, uint64) (uint64, error) {
+		attempts += 1
+		return 0, core.ErrScheduleConflict
+	}
+	if err := s.modifySchedule("nightly", func(*core.Schedule) {}); !errors.Is(err, core.ErrScheduleConflict) || attempts != kMaxUpdateAttempts {
+		t.Fatalf("Expected conflict error after %d attempts, got: %v after %d attempts", kMaxUpdateAttempts, err, attempts)
+	}
+}
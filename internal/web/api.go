@@ -0,0 +1,474 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+	"github.com/mprimi/go-bench-away/v1/reports"
+)
+
+const (
+	kApiPrefix           = "/api/v1/"
+	kApiDefaultPageLimit = 20
+	kApiMaxPageLimit     = 1000
+	// Maximum size of request bodies
+	kApiMaxRequestSize = 1 << 20
+)
+
+var apiJobRegexp = regexp.MustCompile(`^jobs/([[:xdigit:]]{8}-[[:xdigit:]]{4}-[[:xdigit:]]{4}-[[:xdigit:]]{4}-[[:xdigit:]]{12})(/cancel)?/?$`)
+
+// Error with the HTTP status code returned to the client
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func statusErrorf(status int, format string, args ...any) error {
+	return &statusError{status: status, err: fmt.Errorf(format, args...)}
+}
+
+// HTTP status code for an error
+func errorStatus(err error) int {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.status
+	} else if errors.Is(err, core.ErrJobNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// Request body of POST /api/v1/report
+type apiReportRequest struct {
+	Jobs   []string `json:"jobs"`
+	Format string   `json:"format"`
+	// Report specification (optional), same as custom-report
+	Spec json.RawMessage `json:"spec"`
+}
+
+// Response body of GET /api/v1/jobs
+type apiJobsPage struct {
+	Jobs   []*core.JobRecord `json:"jobs"`
+	Offset int               `json:"offset"`
+	Limit  int               `json:"limit"`
+	Total  int               `json:"total"`
+	More   bool              `json:"more"`
+}
+
+// Response body of GET /api/v1/queue
+type apiQueueStatus struct {
+	QueueName string `json:"queue_name"`
+	*core.QueueStatus
+}
+
+// API endpoints:
+//
+//	GET  /api/v1/jobs                  search jobs (newest first), with filters and pagination
+//	POST /api/v1/jobs                  submit a job (body: JobParameters)
+//	GET  /api/v1/jobs/<id>             get a job
+//	POST /api/v1/jobs/<id>/cancel      cancel a job
+//	GET  /api/v1/queue                 queue status
+//	GET  /api/v1/report?jobs=<id>,...  generate a report (format=html or json)
+//	POST /api/v1/report                generate a report (body: apiReportRequest)
+//
+// All endpoints require the viewer role, except submitting and cancelling jobs.
+func (h *handler) serveAPI(w http.ResponseWriter, r *http.Request, user *User) {
+	path := strings.TrimPrefix(r.URL.Path, kApiPrefix)
+
+	// Cross-site forms cannot set this content type, so this also protects from CSRF
+	if r.Method == http.MethodPost {
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			writeJSON(w, http.StatusUnsupportedMediaType, map[string]string{"error": "POST requests must have Content-Type: application/json"})
+			return
+		}
+	}
+
+	err := authorize(user, ViewerRole)
+	switch {
+	case err != nil:
+	case path == "jobs" || path == "jobs/":
+		switch r.Method {
+		case http.MethodGet:
+			err = h.apiListJobs(w, r)
+		case http.MethodPost:
+			err = h.apiSubmitJob(w, r, user)
+		default:
+			err = apiMethodNotAllowed(r)
+		}
+	case strings.HasPrefix(path, "jobs/"):
+		groupMatches := apiJobRegexp.FindStringSubmatch(path)
+		if groupMatches == nil {
+			err = statusErrorf(http.StatusNotFound, "Unknown resource: %s", r.URL.Path)
+		} else if groupMatches[2] == "" && r.Method == http.MethodGet {
+			err = h.apiGetJob(w, groupMatches[1])
+		} else if groupMatches[2] != "" && r.Method == http.MethodPost {
+			err = h.apiCancelJob(w, groupMatches[1], user)
+		} else {
+			err = apiMethodNotAllowed(r)
+		}
+	case path == "queue" || path == "queue/":
+		if r.Method != http.MethodGet {
+			err = apiMethodNotAllowed(r)
+		} else {
+			err = h.apiQueueStatus(w)
+		}
+	case path == "report" || path == "report/":
+		switch r.Method {
+		case http.MethodGet:
+			err = h.apiReport(w, r, apiReportRequest{
+				Jobs:   splitNonEmpty(r.URL.Query().Get("jobs")),
+				Format: r.URL.Query().Get("format"),
+			})
+		case http.MethodPost:
+			reportRequest := apiReportRequest{}
+			if err = decodeRequestBody(r, &reportRequest); err == nil {
+				err = h.apiReport(w, r, reportRequest)
+			}
+		default:
+			err = apiMethodNotAllowed(r)
+		}
+	default:
+		err = statusErrorf(http.StatusNotFound, "Unknown resource: %s", r.URL.Path)
+	}
+
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+	} else {
+		fmt.Printf("Ok\n")
+	}
+}
+
+func apiMethodNotAllowed(r *http.Request) error {
+	return statusErrorf(http.StatusMethodNotAllowed, "Invalid request method: %s", r.Method)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(v)
+}
+
+func decodeRequestBody(r *http.Request, v any) error {
+	decoder := json.NewDecoder(io.LimitReader(r.Body, kApiMaxRequestSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return statusErrorf(http.StatusBadRequest, "Invalid request body: %v", err)
+	}
+	return nil
+}
+
+func splitNonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// Parse an optional non-negative integer query parameter
+func intQueryParam(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, statusErrorf(http.StatusBadRequest, "Invalid %s: %s", name, value)
+	}
+	return n, nil
+}
+
+// Parse the search criteria of GET /api/v1/jobs and of the queue page (pagination excluded)
+func parseJobsQuery(values url.Values) (core.JobsQuery, error) {
+	q := core.JobsQuery{
+		Username:   values.Get("username"),
+		GitRemote:  values.Get("remote"),
+		Ref:        values.Get("ref"),
+		WorkerHost: values.Get("host"),
+		Queue:      values.Get("queue"),
+		Group:      values.Get("group"),
+	}
+	if statusName := values.Get("status"); statusName != "" {
+		status, err := core.ParseJobStatus(statusName)
+		if err != nil {
+			return q, statusErrorf(http.StatusBadRequest, "Invalid status: %s", statusName)
+		}
+		q.Status = &status
+	}
+	for _, param := range []struct {
+		name string
+		t    *time.Time
+	}{
+		{"since", &q.Since},
+		{"until", &q.Until},
+	} {
+		if value := values.Get(param.name); value != "" {
+			t, err := core.ParseQueryTime(value)
+			if err != nil {
+				return q, statusErrorf(http.StatusBadRequest, "Invalid %s: %v", param.name, err)
+			}
+			*param.t = t
+		}
+	}
+	return q, nil
+}
+
+// Filters of GET /api/v1/jobs not supported by the jobs index (empty fields match any job)
+type apiJobsFilter struct {
+	subdir, filter, goPath string
+}
+
+func (f *apiJobsFilter) isEmpty() bool {
+	return *f == apiJobsFilter{}
+}
+
+func (f *apiJobsFilter) matches(job *core.JobRecord) bool {
+	p := job.Parameters
+	return (f.subdir == "" || f.subdir == p.TestsSubDir) &&
+		(f.filter == "" || f.filter == p.TestsFilterExpr) &&
+		(f.goPath == "" || f.goPath == p.GoPath)
+}
+
+func (h *handler) apiListJobs(w http.ResponseWriter, r *http.Request) error {
+	query, err := parseJobsQuery(r.URL.Query())
+	if err != nil {
+		return err
+	}
+	filter := apiJobsFilter{
+		subdir: r.URL.Query().Get("subdir"),
+		filter: r.URL.Query().Get("filter"),
+		goPath: r.URL.Query().Get("go_path"),
+	}
+	offset, err := intQueryParam(r, "offset", 0)
+	if err != nil {
+		return err
+	}
+	limit, err := intQueryParam(r, "limit", kApiDefaultPageLimit)
+	if err != nil {
+		return err
+	}
+	if limit == 0 || limit > kApiMaxPageLimit {
+		return statusErrorf(http.StatusBadRequest, "Invalid limit: %d (max: %d)", limit, kApiMaxPageLimit)
+	}
+
+	var jobs []*core.JobRecord
+	var total int
+	if filter.isEmpty() {
+		query.Offset, query.Limit = offset, limit
+		jobs, total, err = h.client.SearchJobs(query)
+		if err != nil {
+			return err
+		}
+	} else {
+		// Apply the remaining filters to all matching jobs, then paginate
+		matching, _, err := h.client.SearchJobs(query)
+		if err != nil {
+			return err
+		}
+		filtered := make([]*core.JobRecord, 0, len(matching))
+		for _, job := range matching {
+			if filter.matches(job) {
+				filtered = append(filtered, job)
+			}
+		}
+		query.Offset, query.Limit = offset, limit
+		jobs, total = query.Page(filtered), len(filtered)
+	}
+
+	page := apiJobsPage{
+		Jobs:   jobs,
+		Offset: offset,
+		Limit:  limit,
+		Total:  total,
+		More:   offset+len(jobs) < total,
+	}
+	return writeJSON(w, http.StatusOK, page)
+}
+
+func (h *handler) apiGetJob(w http.ResponseWriter, jobId string) error {
+	job, _, err := h.client.LoadJob(jobId)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, job)
+}
+
+func (h *handler) apiSubmitJob(w http.ResponseWriter, r *http.Request, user *User) error {
+	if err := authorize(user, SubmitterRole); err != nil {
+		return err
+	}
+
+	params := core.JobParameters{}
+	if err := decodeRequestBody(r, &params); err != nil {
+		return err
+	}
+
+	if params.GitRemote == "" || params.GitRef == "" {
+		return statusErrorf(http.StatusBadRequest, "GitRemote and GitRef are required")
+	} else if params.Reps == 0 {
+		return statusErrorf(http.StatusBadRequest, "Reps must be greater than 0")
+	} else if params.Timeout <= 0 {
+		return statusErrorf(http.StatusBadRequest, "Timeout (nanoseconds) must be greater than 0")
+	} else if err := core.ValidateProfiles(params.Profiles); err != nil {
+		return statusErrorf(http.StatusBadRequest, "Profiles: %v", err)
+	} else if err := core.ValidateScriptParams(params.ScriptParams); err != nil {
+		return statusErrorf(http.StatusBadRequest, "ScriptParams: %v", err)
+	} else if err := params.ValidateTestOptions(); err != nil {
+		return statusErrorf(http.StatusBadRequest, "Test options: %v", err)
+	} else if err := params.ValidatePackages(); err != nil {
+		return statusErrorf(http.StatusBadRequest, "Packages: %v", err)
+	}
+
+	if params.TestsSubDir == "" {
+		params.TestsSubDir = "."
+	}
+	// Jobs are attributed to the authenticated user, if any
+	if user.Name != "" {
+		params.Username = user.Name
+	} else if params.Username == "" {
+		params.Username = fmt.Sprintf("api (%s)", r.RemoteAddr)
+	}
+
+	job, err := h.client.SubmitJob(params)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, job)
+}
+
+func (h *handler) apiCancelJob(w http.ResponseWriter, jobId string, user *User) error {
+	if err := h.cancelJob(jobId, user); err != nil {
+		return err
+	}
+
+	job, _, err := h.client.LoadJob(jobId)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, job)
+}
+
+func (h *handler) apiQueueStatus(w http.ResponseWriter) error {
+	qs, err := h.client.GetQueueStatus()
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, apiQueueStatus{
+		QueueName:   h.client.QueueName(),
+		QueueStatus: qs,
+	})
+}
+
+func (h *handler) apiReport(w http.ResponseWriter, r *http.Request, request apiReportRequest) error {
+	if request.Format == "" {
+		request.Format = "html"
+	} else if request.Format != "html" && request.Format != "json" {
+		return statusErrorf(http.StatusBadRequest, "Invalid report format: %s (html or json)", request.Format)
+	}
+
+	var spec *reports.ReportSpec
+	if len(request.Spec) > 0 {
+		spec = &reports.ReportSpec{}
+		if err := spec.Load(bytes.NewReader(request.Spec)); err != nil {
+			return statusErrorf(http.StatusBadRequest, "Invalid report spec: %v", err)
+		}
+	}
+
+	// Jobs in the request take precedence over jobs selected in the spec
+	jobIds := request.Jobs
+	if len(jobIds) == 0 && spec != nil && spec.Jobs != nil {
+		var err error
+		jobIds, err = spec.Jobs.JobIds(h.client)
+		if err != nil {
+			return statusErrorf(http.StatusBadRequest, "Failed to select jobs: %v", err)
+		}
+	}
+	if len(jobIds) == 0 {
+		return statusErrorf(http.StatusBadRequest, "No jobs selected")
+	}
+
+	dataTable, err := reports.CreateDataTableWithContext(r.Context(), h.client, jobIds...)
+	if err != nil {
+		if errors.Is(err, core.ErrJobNotFound) {
+			return err
+		}
+		return statusErrorf(http.StatusBadRequest, "%v", err)
+	}
+
+	cfg := reports.ReportConfig{}
+	if spec != nil {
+		if problems := spec.Validate(len(jobIds)); len(problems) > 0 {
+			return statusErrorf(http.StatusBadRequest, "Invalid report spec: %v", problems[0])
+		}
+		if err := spec.ConfigureReport(&cfg); err != nil {
+			return statusErrorf(http.StatusBadRequest, "Invalid report spec: %v", err)
+		}
+	} else {
+		addDefaultReportSections(&cfg, len(jobIds), dataTable.HasSpeed())
+	}
+
+	// Render into a buffer, so that errors can still be reported with the appropriate status
+	var buf bytes.Buffer
+	if request.Format == "json" {
+		err = reports.WriteJSONReport(&cfg, dataTable, &buf)
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		err = reports.WriteReport(&cfg, dataTable, &buf)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	if err != nil {
+		w.Header().Del("Content-Type")
+		return err
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// Sections of the report generated when no spec is given, depending on the number of jobs
+func addDefaultReportSections(cfg *reports.ReportConfig, numJobs int, hasSpeed bool) {
+	cfg.AddSections(reports.JobsTable())
+
+	metrics := []reports.Metric{reports.TimeOp}
+	if hasSpeed {
+		metrics = append(metrics, reports.Speed)
+	}
+
+	for _, metric := range metrics {
+		switch numJobs {
+		case 1:
+			cfg.AddSections(
+				reports.HorizontalBoxChart("", metric, ""),
+				reports.ResultsTable(metric, "", true),
+			)
+		case 2:
+			cfg.AddSections(
+				reports.HorizontalBarChart("", metric, ""),
+				reports.HorizontalDeltaChart("", metric, ""),
+				reports.ResultsDeltaTable(metric, "", true),
+			)
+		default:
+			cfg.AddSections(
+				reports.TrendChart("", metric, ""),
+				reports.ResultsTable(metric, "", true),
+			)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+// Job records and results used by report tests
+var testDataDir = filepath.Join("..", "..", "v1", "reports", "testdata")
+
+var testJobIds = []string{
+	"067997a3-761e-475e-9559-f10d7400b835",
+	"dd146049-0137-4ba0-89b1-0a2f8d0a2268",
+	"e98b2caa-df6d-4f12-815c-431db896a9f5",
+}
+
+type mockClient struct {
+	// Newest first
+	jobs []*core.JobRecord
+	// If set, profile loads signal they started, then wait for release
+	profileLoading chan struct{}
+	profileRelease chan struct{}
+	// Number of recent jobs that fail to load
+	failedRecentJobs int
+}
+
+func newMockClient(t *testing.T) *mockClient {
+	c := &mockClient{}
+	for i := len(testJobIds) - 1; i >= 0; i-- {
+		data, err := os.ReadFile(filepath.Join(testDataDir, fmt.Sprintf("%s.json", testJobIds[i])))
+		if err != nil {
+			t.Fatal(err)
+		}
+		job, err := core.LoadJob(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.jobs = append(c.jobs, job)
+	}
+	return c
+}
+
+func (c *mockClient) LoadJob(jobId string) (*core.JobRecord, uint64, error) {
+	for _, job := range c.jobs {
+		if job.Id == jobId {
+			return job, 1, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("%w: '%s'", core.ErrJobNotFound, jobId)
+}
+
+func (c *mockClient) GetQueueStatus() (*core.QueueStatus, error) {
+	return &core.QueueStatus{SubmittedCount: uint64(len(c.jobs))}, nil
+}
+
+func (c *mockClient) LoadRecentJobs(limit int) ([]*core.JobRecord, error) {
+	if limit > 0 && limit < len(c.jobs) {
+		return c.jobs[:limit], nil
+	}
+	return c.jobs, nil
+}
+
+func (c *mockClient) LoadRecentJobsWithContext(_ context.Context, limit int) ([]*core.JobRecord, core.ParallelErrors, error) {
+	jobs, err := c.LoadRecentJobs(limit)
+	errs := make(core.ParallelErrors, len(jobs))
+	for i := 0; i < c.failedRecentJobs; i++ {
+		errs = append(errs, fmt.Errorf("Failed to load job %d", i))
+	}
+	return jobs, errs, err
+}
+
+func (c *mockClient) SearchJobs(q core.JobsQuery) ([]*core.JobRecord, int, error) {
+	matching := []*core.JobRecord{}
+	for _, job := range c.jobs {
+		if q.Matches(job) {
+			matching = append(matching, job)
+		}
+	}
+	return q.Page(matching), len(matching), nil
+}
+
+func (c *mockClient) LoadResultsArtifact(job *core.JobRecord, w io.Writer) error {
+	file, err := os.Open(filepath.Join(testDataDir, fmt.Sprintf("%s_results.txt", job.Id)))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, file)
+	return err
+}
+
+func (c *mockClient) LoadLogArtifact(job *core.JobRecord, w io.Writer) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (c *mockClient) LoadScriptArtifact(job *core.JobRecord, w io.Writer) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (c *mockClient) LoadProfileArtifact(job *core.JobRecord, profile string, w io.Writer) error {
+	if _, found := job.Profiles[profile]; !found {
+		return fmt.Errorf("no %s profile", profile)
+	}
+	if c.profileRelease != nil {
+		c.profileLoading <- struct{}{}
+		<-c.profileRelease
+	}
+	return pprof.Lookup("heap").WriteTo(w, 0)
+}
+
+func (c *mockClient) LoadTestBinaryArtifact(job *core.JobRecord, w io.Writer) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (c *mockClient) LoadResourcesArtifact(job *core.JobRecord, w io.Writer) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (c *mockClient) CancelJob(jobId string) error {
+	job, _, err := c.LoadJob(jobId)
+	if err != nil {
+		return err
+	}
+	job.SetFinalStatus(core.Cancelled)
+	return nil
+}
+
+func (c *mockClient) SubmitJob(params core.JobParameters) (*core.JobRecord, error) {
+	job := core.NewJob(params)
+	c.jobs = append([]*core.JobRecord{job}, c.jobs...)
+	return job, nil
+}
+
+func (c *mockClient) QueueName() string {
+	return "test"
+}
+
+func apiRequest(t *testing.T, h http.Handler, method, path, body string, expectedStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if method == http.MethodPost {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != expectedStatus {
+		t.Fatalf("%s %s: expected status %d, got %d: %s", method, path, expectedStatus, w.Code, w.Body.String())
+	}
+	return w
+}
+
+func TestAPI_Jobs(t *testing.T) {
+	c := newMockClient(t)
+	h := NewHandler(c)
+
+	// List with pagination
+	page := apiJobsPage{}
+	w := apiRequest(t, h, http.MethodGet, "/api/v1/jobs?limit=2", "", http.StatusOK)
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	} else if len(page.Jobs) != 2 || !page.More || page.Jobs[0].Id != testJobIds[2] {
+		t.Fatalf("Unexpected page: %+v", page)
+	}
+
+	w = apiRequest(t, h, http.MethodGet, "/api/v1/jobs?limit=2&offset=2", "", http.StatusOK)
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	} else if len(page.Jobs) != 1 || page.More || page.Jobs[0].Id != testJobIds[0] {
+		t.Fatalf("Unexpected page: %+v", page)
+	}
+
+	// List with filters
+	w = apiRequest(t, h, http.MethodGet, "/api/v1/jobs?status=succeeded&ref=does-not-exist", "", http.StatusOK)
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	} else if len(page.Jobs) != 0 || page.More {
+		t.Fatalf("Unexpected page: %+v", page)
+	}
+
+	w = apiRequest(t, h, http.MethodGet, "/api/v1/jobs?ref=d14968cb&host=benchmark.example.com&since=2023-04-05&limit=1", "", http.StatusOK)
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	} else if len(page.Jobs) != 1 || page.Total != 1 || page.More || page.Jobs[0].Id != testJobIds[1] {
+		t.Fatalf("Unexpected page: %+v", page)
+	}
+
+	apiRequest(t, h, http.MethodGet, "/api/v1/jobs?status=foo", "", http.StatusBadRequest)
+	apiRequest(t, h, http.MethodGet, "/api/v1/jobs?since=yesterday", "", http.StatusBadRequest)
+	apiRequest(t, h, http.MethodGet, "/api/v1/jobs?limit=-1", "", http.StatusBadRequest)
+	apiRequest(t, h, http.MethodDelete, "/api/v1/jobs", "", http.StatusMethodNotAllowed)
+
+	// Get
+	job := core.JobRecord{}
+	w = apiRequest(t, h, http.MethodGet, "/api/v1/jobs/"+testJobIds[1], "", http.StatusOK)
+	if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+		t.Fatal(err)
+	} else if job.Id != testJobIds[1] {
+		t.Fatalf("Unexpected job: %+v", job)
+	}
+	apiRequest(t, h, http.MethodGet, "/api/v1/jobs/2fb41f25-7e17-4383-9e08-8ab115152db2", "", http.StatusNotFound)
+	apiRequest(t, h, http.MethodGet, "/api/v1/jobs/foo", "", http.StatusNotFound)
+
+	// Submit
+	params := core.JobParameters{
+		GitRemote: "https://github.com/nats-io/nats-server.git",
+		GitRef:    "main",
+		Reps:      3,
+		Timeout:   time.Hour,
+	}
+	body, _ := json.Marshal(params)
+	w = apiRequest(t, h, http.MethodPost, "/api/v1/jobs", string(body), http.StatusCreated)
+	if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+		t.Fatal(err)
+	} else if job.Status != core.Submitted || job.Parameters.GitRef != "main" || job.Parameters.TestsSubDir != "." {
+		t.Fatalf("Unexpected job: %+v", job)
+	}
+	submittedJobId := job.Id
+
+	apiRequest(t, h, http.MethodPost, "/api/v1/jobs", `{"GitRemote": "foo"}`, http.StatusBadRequest)
+	apiRequest(t, h, http.MethodPost, "/api/v1/jobs", `{"Unknown": "foo"}`, http.StatusBadRequest)
+	params.ScriptParams = map[string]string{"make target": "bench"}
+	body, _ = json.Marshal(params)
+	apiRequest(t, h, http.MethodPost, "/api/v1/jobs", string(body), http.StatusBadRequest)
+	params.ScriptParams = nil
+	params.CPUs = []int{0}
+	body, _ = json.Marshal(params)
+	apiRequest(t, h, http.MethodPost, "/api/v1/jobs", string(body), http.StatusBadRequest)
+
+	// Cancel
+	w = apiRequest(t, h, http.MethodPost, "/api/v1/jobs/"+submittedJobId+"/cancel", "", http.StatusOK)
+	if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+		t.Fatal(err)
+	} else if job.Status != core.Cancelled {
+		t.Fatalf("Unexpected job status: %s", job.Status)
+	}
+	apiRequest(t, h, http.MethodPost, "/api/v1/jobs/"+submittedJobId+"/cancel", "", http.StatusConflict)
+	apiRequest(t, h, http.MethodGet, "/api/v1/jobs/"+submittedJobId+"/cancel", "", http.StatusMethodNotAllowed)
+
+	// Queue
+	qs := apiQueueStatus{}
+	w = apiRequest(t, h, http.MethodGet, "/api/v1/queue", "", http.StatusOK)
+	if err := json.Unmarshal(w.Body.Bytes(), &qs); err != nil {
+		t.Fatal(err)
+	} else if qs.QueueName != "test" || qs.SubmittedCount != 4 {
+		t.Fatalf("Unexpected queue status: %+v", qs)
+	}
+
+	apiRequest(t, h, http.MethodGet, "/api/v1/foo", "", http.StatusNotFound)
+}
+
+func TestAPI_Report(t *testing.T) {
+	c := newMockClient(t)
+	h := NewHandler(c)
+
+	jobs := strings.Join(testJobIds[:2], ",")
+
+	w := apiRequest(t, h, http.MethodGet, "/api/v1/report?jobs="+jobs, "", http.StatusOK)
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") || !strings.Contains(w.Body.String(), testJobIds[1]) {
+		t.Fatalf("Unexpected HTML report")
+	}
+
+	w = apiRequest(t, h, http.MethodGet, "/api/v1/report?format=json&jobs="+jobs, "", http.StatusOK)
+	report := struct {
+		Jobs []struct {
+			Label string
+		}
+		Results []struct {
+			Metric string
+		}
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	} else if len(report.Jobs) != 2 || len(report.Results) == 0 {
+		t.Fatalf("Unexpected JSON report: %s", w.Body.String())
+	}
+
+	// Spec selecting jobs via query (test job records have status failed)
+	spec := `{"format": "json", "spec": {"title": "Trend", "labels": ["a", "b", "c"], "jobs": {"query": {"remote": "https://github.com/nats-io/nats-server.git", "status": "failed"}}}}`
+	w = apiRequest(t, h, http.MethodPost, "/api/v1/report", spec, http.StatusOK)
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	} else if len(report.Jobs) != 3 || report.Jobs[2].Label != "c" {
+		t.Fatalf("Unexpected JSON report: %s", w.Body.String())
+	}
+
+	apiRequest(t, h, http.MethodGet, "/api/v1/report", "", http.StatusBadRequest)
+	apiRequest(t, h, http.MethodGet, "/api/v1/report?format=pdf&jobs="+jobs, "", http.StatusBadRequest)
+	apiRequest(t, h, http.MethodGet, "/api/v1/report?jobs=2fb41f25-7e17-4383-9e08-8ab115152db2", "", http.StatusNotFound)
+	apiRequest(t, h, http.MethodPost, "/api/v1/report", `{"jobs": ["`+testJobIds[0]+`"], "spec": {"sections": [{"type": "foo"}]}}`, http.StatusBadRequest)
+}
@@ -0,0 +1,272 @@
+package web
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type Role int
+
+const (
+	NoRole Role = iota
+	// Can browse jobs, artifacts and reports
+	ViewerRole
+	// Viewer, and can submit jobs and cancel their own
+	SubmitterRole
+	// Submitter, and can cancel any job
+	AdminRole
+)
+
+func (r Role) String() string {
+	switch r {
+	case ViewerRole:
+		return "viewer"
+	case SubmitterRole:
+		return "submitter"
+	case AdminRole:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+func ParseRole(name string) (Role, error) {
+	for _, role := range []Role{ViewerRole, SubmitterRole, AdminRole} {
+		if strings.EqualFold(name, role.String()) {
+			return role, nil
+		}
+	}
+	return NoRole, fmt.Errorf("invalid role: '%s' (viewer, submitter or admin)", name)
+}
+
+type User struct {
+	Name string
+	Role Role
+}
+
+// Anonymous user with full access, used if authentication is not configured
+var anonymousUser = &User{Name: "", Role: AdminRole}
+
+// Authenticator identifies the user making a request.
+// Returns nil (and no error) if the request carries no credentials.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*User, error)
+	// Value of the WWW-Authenticate header of 401 responses (may be empty)
+	Challenge() string
+}
+
+// Read a file, skipping empty lines and comments (lines starting with '#')
+func readConfigLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// LoadRolesFile loads the roles of users, one per line: <username> <role>
+func LoadRolesFile(path string) (map[string]Role, error) {
+	lines, err := readConfigLines(path)
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[string]Role, len(lines))
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s: invalid line %d, expected: <username> <role>", path, i+1)
+		}
+		role, err := ParseRole(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: line %d: %w", path, i+1, err)
+		}
+		roles[fields[0]] = role
+	}
+	return roles, nil
+}
+
+// Role of a user authenticated by a mechanism without roles (htpasswd, proxy)
+func lookupRole(roles map[string]Role, defaultRole Role, username string) Role {
+	if role, found := roles[username]; found {
+		return role
+	}
+	return defaultRole
+}
+
+type tokenAuthenticator struct {
+	users map[string]*User
+}
+
+// LoadTokensFile creates an authenticator for static bearer tokens, one per line: <token> <username> <role>
+func LoadTokensFile(path string) (Authenticator, error) {
+	lines, err := readConfigLines(path)
+	if err != nil {
+		return nil, err
+	}
+	a := &tokenAuthenticator{users: make(map[string]*User, len(lines))}
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s: invalid line %d, expected: <token> <username> <role>", path, i+1)
+		}
+		role, err := ParseRole(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("%s: line %d: %w", path, i+1, err)
+		}
+		a.users[fields[0]] = &User{Name: fields[1], Role: role}
+	}
+	return a, nil
+}
+
+func (a *tokenAuthenticator) Authenticate(r *http.Request) (*User, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return nil, nil
+	}
+	token := strings.TrimPrefix(header, "Bearer ")
+	if token == header {
+		return nil, fmt.Errorf("unsupported authorization scheme")
+	}
+	for knownToken, user := range a.users {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(knownToken)) == 1 {
+			return user, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid token")
+}
+
+func (a *tokenAuthenticator) Challenge() string {
+	return "Bearer"
+}
+
+type htpasswdAuthenticator struct {
+	hashes      map[string]string
+	roles       map[string]Role
+	defaultRole Role
+}
+
+// LoadHtpasswdFile creates an authenticator for HTTP basic auth, with credentials from an htpasswd file.
+// Supported hashes are bcrypt and SHA1 ({SHA}).
+func LoadHtpasswdFile(path string, roles map[string]Role, defaultRole Role) (Authenticator, error) {
+	lines, err := readConfigLines(path)
+	if err != nil {
+		return nil, err
+	}
+	a := &htpasswdAuthenticator{
+		hashes:      make(map[string]string, len(lines)),
+		roles:       roles,
+		defaultRole: defaultRole,
+	}
+	for i, line := range lines {
+		username, hash, found := strings.Cut(line, ":")
+		if !found || username == "" {
+			return nil, fmt.Errorf("%s: invalid line %d, expected: <username>:<hash>", path, i+1)
+		}
+		if !strings.HasPrefix(hash, "$2") && !strings.HasPrefix(hash, "{SHA}") {
+			return nil, fmt.Errorf("%s: line %d: unsupported hash (use bcrypt or SHA1)", path, i+1)
+		}
+		a.hashes[username] = hash
+	}
+	return a, nil
+}
+
+func (a *htpasswdAuthenticator) Authenticate(r *http.Request) (*User, error) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, nil
+	}
+	hash, found := a.hashes[username]
+	if !found || !checkHtpasswdHash(hash, password) {
+		return nil, fmt.Errorf("invalid username or password")
+	}
+	return &User{Name: username, Role: lookupRole(a.roles, a.defaultRole, username)}, nil
+}
+
+func (a *htpasswdAuthenticator) Challenge() string {
+	return `Basic realm="go-bench-away"`
+}
+
+func checkHtpasswdHash(hash, password string) bool {
+	if strings.HasPrefix(hash, "{SHA}") {
+		sum := sha1.Sum([]byte(password))
+		expected := base64.StdEncoding.EncodeToString(sum[:])
+		return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(hash, "{SHA}")), []byte(expected)) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+type trustedProxyAuthenticator struct {
+	header         string
+	trustedProxies []*net.IPNet
+	roles          map[string]Role
+	defaultRole    Role
+}
+
+// NewTrustedProxyAuthenticator creates an authenticator for requests coming through an authenticating reverse proxy,
+// which sets the username in the given header. Requests from addresses outside the trusted networks (CIDR) are rejected.
+func NewTrustedProxyAuthenticator(header string, trustedProxies []string, roles map[string]Role, defaultRole Role) (Authenticator, error) {
+	if header == "" {
+		return nil, fmt.Errorf("missing proxy user header")
+	}
+	if len(trustedProxies) == 0 {
+		return nil, fmt.Errorf("missing trusted proxy networks")
+	}
+	a := &trustedProxyAuthenticator{
+		header:      header,
+		roles:       roles,
+		defaultRole: defaultRole,
+	}
+	for _, cidr := range trustedProxies {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy network: %w", err)
+		}
+		a.trustedProxies = append(a.trustedProxies, network)
+	}
+	return a, nil
+}
+
+func (a *trustedProxyAuthenticator) Authenticate(r *http.Request) (*User, error) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote address: %s", r.RemoteAddr)
+	}
+	ip := net.ParseIP(host)
+	trusted := false
+	for _, network := range a.trustedProxies {
+		trusted = trusted || (ip != nil && network.Contains(ip))
+	}
+	if !trusted {
+		return nil, fmt.Errorf("request not from a trusted proxy: %s", host)
+	}
+
+	username := r.Header.Get(a.header)
+	if username == "" {
+		return nil, nil
+	}
+	return &User{Name: username, Role: lookupRole(a.roles, a.defaultRole, username)}, nil
+}
+
+func (a *trustedProxyAuthenticator) Challenge() string {
+	return ""
+}
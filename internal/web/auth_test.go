@@ -0,0 +1,235 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func writeTestFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "auth.txt")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func authRequest(t *testing.T, h http.Handler, r *http.Request, expectedStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+	if r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, kApiPrefix) {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != expectedStatus {
+		t.Fatalf("%s %s: expected status %d, got %d: %s", r.Method, r.URL.Path, expectedStatus, w.Code, w.Body.String())
+	}
+	return w
+}
+
+func withToken(r *http.Request, token string) *http.Request {
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func TestParseRole(t *testing.T) {
+	for _, role := range []Role{ViewerRole, SubmitterRole, AdminRole} {
+		parsed, err := ParseRole(strings.ToUpper(role.String()))
+		if err != nil || parsed != role {
+			t.Fatalf("Failed to parse role %s: %v", role, err)
+		}
+	}
+	if _, err := ParseRole("root"); err == nil {
+		t.Fatalf("Expected error for invalid role")
+	}
+}
+
+func TestTokenAuthentication(t *testing.T) {
+	tokensFile := writeTestFile(t, `
+# token username role
+t0k3n-v viewer-user viewer
+t0k3n-s alice submitter
+t0k3n-s2 bob submitter
+t0k3n-a admin-user admin
+`)
+	authenticator, err := LoadTokensFile(tokensFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := newMockClient(t)
+	h := NewHandler(c, WithAuthenticator(authenticator))
+
+	// Unauthenticated or invalid token
+	w := authRequest(t, h, httptest.NewRequest(http.MethodGet, "/api/v1/jobs", nil), http.StatusUnauthorized)
+	if w.Header().Get("WWW-Authenticate") != "Bearer" {
+		t.Fatalf("Missing authentication challenge")
+	}
+	authRequest(t, h, withToken(httptest.NewRequest(http.MethodGet, "/queue", nil), "wrong"), http.StatusUnauthorized)
+
+	// Viewer can browse, but not submit
+	authRequest(t, h, withToken(httptest.NewRequest(http.MethodGet, "/api/v1/jobs", nil), "t0k3n-v"), http.StatusOK)
+	authRequest(t, h, withToken(httptest.NewRequest(http.MethodGet, "/queue", nil), "t0k3n-v"), http.StatusOK)
+	submitBody := `{"GitRemote": "https://github.com/nats-io/nats-server.git", "GitRef": "main", "Reps": 1, "Timeout": 1000000000, "Username": "someone-else"}`
+	authRequest(t, h, withToken(httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(submitBody)), "t0k3n-v"), http.StatusForbidden)
+
+	// Submitter submits, job is attributed to them
+	w = authRequest(t, h, withToken(httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(submitBody)), "t0k3n-s"), http.StatusCreated)
+	job := core.JobRecord{}
+	if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+		t.Fatal(err)
+	} else if job.Parameters.Username != "alice" {
+		t.Fatalf("Unexpected job username: %s", job.Parameters.Username)
+	}
+
+	// Only the owner or an admin can cancel
+	cancelPath := "/api/v1/jobs/" + job.Id + "/cancel"
+	authRequest(t, h, withToken(httptest.NewRequest(http.MethodPost, cancelPath, nil), "t0k3n-v"), http.StatusForbidden)
+	authRequest(t, h, withToken(httptest.NewRequest(http.MethodPost, cancelPath, nil), "t0k3n-s2"), http.StatusForbidden)
+	authRequest(t, h, withToken(httptest.NewRequest(http.MethodPost, cancelPath, nil), "t0k3n-s"), http.StatusOK)
+
+	w = authRequest(t, h, withToken(httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(submitBody)), "t0k3n-s"), http.StatusCreated)
+	if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+		t.Fatal(err)
+	}
+	authRequest(t, h, withToken(httptest.NewRequest(http.MethodPost, "/api/v1/jobs/"+job.Id+"/cancel", nil), "t0k3n-a"), http.StatusOK)
+
+	// Invalid tokens file
+	if _, err := LoadTokensFile(writeTestFile(t, "t0k3n alice superuser\n")); err == nil {
+		t.Fatalf("Expected error for invalid role")
+	}
+}
+
+func TestHtpasswdAuthentication(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	htpasswdFile := writeTestFile(t, "alice:"+string(bcryptHash)+"\n"+
+		"bob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n") // password
+	roles, err := LoadRolesFile(writeTestFile(t, "alice admin\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator, err := LoadHtpasswdFile(htpasswdFile, roles, ViewerRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		username, password string
+		expectedUser       *User
+	}{
+		{"alice", "s3cret", &User{Name: "alice", Role: AdminRole}},
+		{"bob", "password", &User{Name: "bob", Role: ViewerRole}},
+		{"alice", "password", nil},
+		{"carol", "password", nil},
+	}
+	for _, tc := range testCases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.SetBasicAuth(tc.username, tc.password)
+		user, err := authenticator.Authenticate(r)
+		if tc.expectedUser == nil && err == nil {
+			t.Fatalf("Expected error for %s:%s", tc.username, tc.password)
+		} else if tc.expectedUser != nil && (err != nil || *user != *tc.expectedUser) {
+			t.Fatalf("Unexpected user for %s: %+v, %v", tc.username, user, err)
+		}
+	}
+
+	// No credentials
+	if user, err := authenticator.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil)); user != nil || err != nil {
+		t.Fatalf("Expected no user and no error without credentials")
+	}
+
+	if _, err := LoadHtpasswdFile(writeTestFile(t, "alice:$apr1$abc$def\n"), nil, ViewerRole); err == nil {
+		t.Fatalf("Expected error for unsupported hash")
+	}
+}
+
+func TestTrustedProxyAuthentication(t *testing.T) {
+	// Test requests come from 192.0.2.1
+	authenticator, err := NewTrustedProxyAuthenticator("X-Forwarded-User", []string{"192.0.2.0/24"}, map[string]Role{"alice": SubmitterRole}, ViewerRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Forwarded-User", "alice")
+	if user, err := authenticator.Authenticate(r); err != nil || *user != (User{Name: "alice", Role: SubmitterRole}) {
+		t.Fatalf("Unexpected user: %+v, %v", user, err)
+	}
+
+	untrusted, err := NewTrustedProxyAuthenticator("X-Forwarded-User", []string{"10.0.0.0/8"}, nil, ViewerRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := untrusted.Authenticate(r); err == nil {
+		t.Fatalf("Expected error for request from untrusted address")
+	}
+
+	if _, err := NewTrustedProxyAuthenticator("X-Forwarded-User", []string{"foo"}, nil, ViewerRole); err == nil {
+		t.Fatalf("Expected error for invalid network")
+	}
+}
+
+func TestCancelFromWebUI(t *testing.T) {
+	c := newMockClient(t)
+	h := NewHandler(c)
+
+	job, err := c.SubmitJob(core.JobParameters{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelPath := "/job/" + job.Id + "/cancel"
+
+	// Queue page sets the CSRF cookie and includes the token in forms
+	w := authRequest(t, h, httptest.NewRequest(http.MethodGet, "/queue", nil), http.StatusOK)
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != kCSRFCookieName {
+		t.Fatalf("Expected CSRF cookie, got: %v", cookies)
+	}
+	token := cookies[0].Value
+	if !strings.Contains(w.Body.String(), token) {
+		t.Fatalf("CSRF token not found in page")
+	}
+
+	// GET is not allowed for actions
+	authRequest(t, h, httptest.NewRequest(http.MethodGet, cancelPath, nil), http.StatusMethodNotAllowed)
+
+	cancelRequest := func(cookieToken, formToken string) *http.Request {
+		form := url.Values{kCSRFFormField: {formToken}}
+		r := httptest.NewRequest(http.MethodPost, cancelPath, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if cookieToken != "" {
+			r.AddCookie(&http.Cookie{Name: kCSRFCookieName, Value: cookieToken})
+		}
+		return r
+	}
+
+	authRequest(t, h, cancelRequest("", token), http.StatusForbidden)
+	authRequest(t, h, cancelRequest(token, "forged"), http.StatusForbidden)
+	authRequest(t, h, cancelRequest(token, token), http.StatusSeeOther)
+
+	if job.Status != core.Cancelled {
+		t.Fatalf("Job was not cancelled")
+	}
+
+	// API POST requests must be JSON
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader("GitRemote=foo"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("Expected status %d, got %d", http.StatusUnsupportedMediaType, w.Code)
+	}
+}
@@ -0,0 +1,48 @@
+package web
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+)
+
+// Actions in the web UI are protected from cross-site request forgery by a token that is stored in a cookie and
+// included in each form: a cross-site form cannot read the cookie, so it cannot submit the matching value.
+const (
+	kCSRFCookieName = "gba_csrf"
+	kCSRFFormField  = "csrf_token"
+)
+
+// Return the CSRF token of the client, setting a new one if it does not have one yet
+func csrfTokenCookie(w http.ResponseWriter, r *http.Request) (string, error) {
+	if cookie, err := r.Cookie(kCSRFCookieName); err == nil && len(cookie.Value) == 64 {
+		return cookie.Value, nil
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     kCSRFCookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return token, nil
+}
+
+func checkCSRFToken(r *http.Request) error {
+	cookie, err := r.Cookie(kCSRFCookieName)
+	if err != nil || cookie.Value == "" {
+		return statusErrorf(http.StatusForbidden, "Missing CSRF cookie, reload the page and try again")
+	}
+	if subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(r.PostFormValue(kCSRFFormField))) != 1 {
+		return statusErrorf(http.StatusForbidden, "Invalid CSRF token, reload the page and try again")
+	}
+	return nil
+}
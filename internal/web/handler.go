@@ -1,12 +1,15 @@
 package web
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"net/http"
+	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/mprimi/go-bench-away/v1/core"
@@ -25,33 +28,97 @@ type handler struct {
 	client        WebClient
 	indexTemplate *template.Template
 	queueTemplate *template.Template
+	authenticator Authenticator
+	// Web interfaces of profiles, loaded on demand
+	profileViewers profileViewers
 }
 
-func NewHandler(c WebClient) http.Handler {
-	return &handler{
+type Option func(*handler)
+
+// WithAuthenticator requires requests to be authenticated, without it all requests have full access
+func WithAuthenticator(authenticator Authenticator) Option {
+	return func(h *handler) {
+		h.authenticator = authenticator
+	}
+}
+
+func NewHandler(c WebClient, opts ...Option) http.Handler {
+	h := &handler{
 		client:        c,
 		indexTemplate: template.Must(template.New("index").Parse(indexTmpl)),
 		queueTemplate: template.Must(template.New("queue").Parse(queueTmpl)),
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
-func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Reject anything that is not a GET
-	if r.Method != http.MethodGet {
-		http.Error(w, fmt.Sprintf("Invalid request method: %s", r.Method), http.StatusMethodNotAllowed)
-		return
+// Identify the user making the request, anonymous (with full access) if authentication is not configured
+func (h *handler) authenticate(r *http.Request) (*User, error) {
+	if h.authenticator == nil {
+		return anonymousUser, nil
+	}
+	user, err := h.authenticator.Authenticate(r)
+	if err != nil {
+		return nil, statusErrorf(http.StatusUnauthorized, "Authentication failed: %v", err)
+	} else if user == nil {
+		return nil, statusErrorf(http.StatusUnauthorized, "Authentication required")
+	}
+	return user, nil
+}
+
+func authorize(user *User, role Role) error {
+	if user.Role < role {
+		return statusErrorf(http.StatusForbidden, "Forbidden: user '%s' (%s) is not %s", user.Name, user.Role, role)
 	}
+	return nil
+}
 
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	url := r.URL
 	path := url.Path
 
 	fmt.Printf(" > %s %s\n", r.Method, path)
 
-	var err error
-	if path == "" || path == "/" {
+	user, err := h.authenticate(r)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		if challenge := h.authenticator.Challenge(); challenge != "" {
+			w.Header().Set("WWW-Authenticate", challenge)
+		}
+		if strings.HasPrefix(path, kApiPrefix) {
+			writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+		} else {
+			http.Error(w, err.Error(), errorStatus(err))
+		}
+		return
+	}
+
+	// JSON API, methods are checked for each endpoint
+	if strings.HasPrefix(path, kApiPrefix) {
+		h.serveAPI(w, r, user)
+		return
+	}
+
+	// Reject anything that is not a GET, except actions (which require a POST)
+	isAction := strings.HasPrefix(path, "/job/") && strings.HasSuffix(strings.TrimSuffix(path, "/"), "/cancel")
+	if (isAction && r.Method != http.MethodPost) || (!isAction && r.Method != http.MethodGet) {
+		http.Error(w, fmt.Sprintf("Invalid request method: %s", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	err = authorize(user, ViewerRole)
+	if err != nil {
+		// Error returned below
+	} else if path == "" || path == "/" {
 		err = h.serveIndex(w)
 	} else if path == "/queue" || path == "/queue/" {
-		err = h.serveQueue(w)
+		err = h.serveQueue(w, r, user)
+	} else if profileMatches := jobProfileRegexp.FindStringSubmatch(path); profileMatches != nil {
+		err = h.serveJobProfile(w, r, "", profileMatches[1], profileMatches[2], profileMatches[3])
+	} else if diffMatches := profileDiffRegexp.FindStringSubmatch(path); diffMatches != nil {
+		err = h.serveJobProfile(w, r, diffMatches[1], diffMatches[2], diffMatches[3], diffMatches[4])
 	} else if strings.HasPrefix(path, "/job/") {
 		groupMatches := jobResourceRegexp.FindStringSubmatch(path)
 		if groupMatches == nil || len(groupMatches) != 3 {
@@ -60,14 +127,23 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 		jobId, resource := groupMatches[1], groupMatches[2]
 
-		err = h.serveJobResource(w, jobId, resource)
+		if resource == "cancel" {
+			err = h.serveCancelJob(w, r, jobId, user)
+		} else {
+			err = h.serveJobResource(w, r, jobId, resource)
+		}
 	} else {
 		http.Error(w, "Bad request", http.StatusBadRequest)
 	}
 
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
-		http.Error(w, fmt.Sprintf("Internal error: %v", err), http.StatusInternalServerError)
+		status := errorStatus(err)
+		if status == http.StatusInternalServerError {
+			http.Error(w, fmt.Sprintf("Internal error: %v", err), status)
+		} else {
+			http.Error(w, err.Error(), status)
+		}
 	} else {
 		fmt.Printf("Ok\n")
 	}
@@ -83,24 +159,154 @@ func (h *handler) serveIndex(w http.ResponseWriter) error {
 	return h.indexTemplate.Execute(w, qs)
 }
 
-func (h *handler) serveQueue(w http.ResponseWriter) error {
+// Job in the queue page, with the token needed to submit actions
+type queueJob struct {
+	*core.JobRecord
+	CSRFToken string
+}
+
+// Number of jobs shown in the queue page
+const kQueuePageSize = 10
+
+// Query parameters of the queue page search form
+var queueSearchParams = []string{"status", "username", "remote", "ref", "host", "queue", "group", "since", "until"}
+
+// Page of search results in the queue page
+type queueSearch struct {
+	// False if showing the most recent jobs in the queue
+	Active  bool
+	Params  url.Values
+	Total   int
+	PrevURL string
+	NextURL string
+}
+
+func (h *handler) serveQueue(w http.ResponseWriter, r *http.Request, user *User) error {
 
-	jobRecords, err := h.client.LoadRecentJobs(10)
+	var jobRecords []*core.JobRecord
+	var err error
+	// Number of jobs that failed to load
+	failedJobs := 0
+
+	params := url.Values{}
+	search := &queueSearch{Params: params}
+	for _, name := range queueSearchParams {
+		if value := strings.TrimSpace(r.URL.Query().Get(name)); value != "" {
+			params.Set(name, value)
+		}
+	}
+
+	if len(params) == 0 {
+		// Most recent jobs in this queue
+		var errs core.ParallelErrors
+		jobRecords, errs, err = h.client.LoadRecentJobsWithContext(r.Context(), kQueuePageSize)
+		if err != nil {
+			return err
+		}
+		// Show the jobs that loaded
+		for _, err := range errs {
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				failedJobs++
+			}
+		}
+	} else {
+		// Search across all jobs
+		query, err := parseJobsQuery(params)
+		if err != nil {
+			return err
+		}
+		query.Offset, err = intQueryParam(r, "offset", 0)
+		if err != nil {
+			return err
+		}
+		query.Limit = kQueuePageSize
+
+		search.Active = true
+		jobRecords, search.Total, err = h.client.SearchJobs(query)
+		if err != nil {
+			return err
+		}
+
+		pageURL := func(offset int) string {
+			pageParams := url.Values{}
+			for name, values := range params {
+				pageParams[name] = values
+			}
+			pageParams.Set("offset", strconv.Itoa(offset))
+			return "/queue?" + pageParams.Encode()
+		}
+		if query.Offset > 0 {
+			prevOffset := query.Offset - kQueuePageSize
+			if prevOffset < 0 {
+				prevOffset = 0
+			}
+			search.PrevURL = pageURL(prevOffset)
+		}
+		if query.Offset+len(jobRecords) < search.Total {
+			search.NextURL = pageURL(query.Offset + kQueuePageSize)
+		}
+	}
+
+	csrfToken, err := csrfTokenCookie(w, r)
 	if err != nil {
 		return err
 	}
 
+	jobs := make([]queueJob, len(jobRecords))
+	for i, job := range jobRecords {
+		jobs[i] = queueJob{JobRecord: job, CSRFToken: csrfToken}
+	}
+
 	tv := struct {
-		QueueName string
-		Jobs      []*core.JobRecord
+		QueueName  string
+		Jobs       []queueJob
+		FailedJobs int
+		User       *User
+		Search     *queueSearch
 	}{
-		QueueName: h.client.QueueName(),
-		Jobs:      jobRecords,
+		QueueName:  h.client.QueueName(),
+		Jobs:       jobs,
+		FailedJobs: failedJobs,
+		User:       user,
+		Search:     search,
 	}
 	return h.queueTemplate.Execute(w, tv)
 }
 
-func (h *handler) serveJobResource(w http.ResponseWriter, jobId, resourceType string) error {
+// Cancel a job from the web UI, then go back to the queue page
+func (h *handler) serveCancelJob(w http.ResponseWriter, r *http.Request, jobId string, user *User) error {
+	if err := checkCSRFToken(r); err != nil {
+		return err
+	}
+	if err := h.cancelJob(jobId, user); err != nil {
+		return err
+	}
+	http.Redirect(w, r, "/queue", http.StatusSeeOther)
+	return nil
+}
+
+// Cancel a job, if the user is allowed to: submitters can only cancel their own jobs
+func (h *handler) cancelJob(jobId string, user *User) error {
+	if err := authorize(user, SubmitterRole); err != nil {
+		return err
+	}
+
+	job, _, err := h.client.LoadJob(jobId)
+	if err != nil {
+		return err
+	}
+
+	if user.Role < AdminRole && job.Parameters.Username != user.Name {
+		return statusErrorf(http.StatusForbidden, "Forbidden: user '%s' can only cancel their own jobs", user.Name)
+	} else if job.Status != core.Submitted {
+		return statusErrorf(http.StatusConflict, "Cannot cancel job in state %s", job.Status)
+	}
+
+	return h.client.CancelJob(jobId)
+}
+
+func (h *handler) serveJobResource(w http.ResponseWriter, r *http.Request, jobId, resourceType string) error {
 
 	jobRecord, _, err := h.client.LoadJob(jobId)
 	if err != nil {
@@ -119,12 +325,7 @@ func (h *handler) serveJobResource(w http.ResponseWriter, jobId, resourceType st
 		e.SetIndent("", "  ")
 		err = e.Encode(jobRecord)
 	case "plot":
-		err = h.serveJobResultsPlot(jobId, w)
-	case "cancel":
-		err = h.client.CancelJob(jobId)
-		if err == nil {
-			fmt.Fprintf(w, "Job %s cancelled", jobId)
-		}
+		err = h.serveJobResultsPlot(r.Context(), jobId, w)
 	}
 
 	if err != nil {
@@ -134,9 +335,9 @@ func (h *handler) serveJobResource(w http.ResponseWriter, jobId, resourceType st
 	return nil
 }
 
-func (h *handler) serveJobResultsPlot(jobId string, w http.ResponseWriter) error {
+func (h *handler) serveJobResultsPlot(ctx context.Context, jobId string, w http.ResponseWriter) error {
 
-	dataTable, err := reports.CreateDataTable(h.client, jobId)
+	dataTable, err := reports.CreateDataTableWithContext(ctx, h.client, jobId)
 	if err != nil {
 		return err
 	}
@@ -161,6 +362,18 @@ func (h *handler) serveJobResultsPlot(jobId string, w http.ResponseWriter) error
 		reports.ResultsTable(reports.TimeOp, "", true),
 	)
 
+	job, _, err := h.client.LoadJob(jobId)
+	if err != nil {
+		return err
+	}
+	if job.Resources != "" {
+		usage, err := reports.LoadResourceUsage(h.client, []*core.JobRecord{job})
+		if err != nil {
+			return err
+		}
+		cfg.AddSections(reports.ResourceUsageChart("", usage))
+	}
+
 	err = reports.WriteReport(&cfg, dataTable, w)
 	if err != nil {
 		return err
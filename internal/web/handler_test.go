@@ -1,7 +1,11 @@
 package web
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestJobResourcesRegexp(t *testing.T) {
@@ -55,3 +59,105 @@ func TestJobResourcesRegexp(t *testing.T) {
 		}
 	}
 }
+
+func TestQueueSearch(t *testing.T) {
+	c := newMockClient(t)
+	h := NewHandler(c)
+
+	w := apiRequest(t, h, http.MethodGet, "/queue", "", http.StatusOK)
+	if strings.Contains(w.Body.String(), "Search results") {
+		t.Fatalf("Unexpected search results without query")
+	}
+	if strings.Contains(w.Body.String(), "could not be loaded") {
+		t.Fatalf("Unexpected failed jobs notice")
+	}
+
+	c.failedRecentJobs = 2
+	w = apiRequest(t, h, http.MethodGet, "/queue", "", http.StatusOK)
+	if !strings.Contains(w.Body.String(), "2 jobs could not be loaded") || !strings.Contains(w.Body.String(), testJobIds[0]) {
+		t.Fatalf("Expected loaded jobs and failed jobs notice: %s", w.Body.String())
+	}
+	c.failedRecentJobs = 0
+
+	w = apiRequest(t, h, http.MethodGet, "/queue?ref=v2.9.11&username=benchmark-bot", "", http.StatusOK)
+	body := w.Body.String()
+	if !strings.Contains(body, "Search results (1)") || !strings.Contains(body, testJobIds[0]) || strings.Contains(body, testJobIds[1]) {
+		t.Fatalf("Unexpected search results: %s", body)
+	}
+	if !strings.Contains(body, `value="v2.9.11"`) {
+		t.Fatalf("Search form not populated")
+	}
+
+	apiRequest(t, h, http.MethodGet, "/queue?status=foo", "", http.StatusBadRequest)
+}
+
+func TestJobProfile(t *testing.T) {
+	c := newMockClient(t)
+	h := NewHandler(c)
+
+	jobId := testJobIds[0]
+	job, _, _ := c.LoadJob(jobId)
+	job.Profiles = map[string]string{"mem": "jobs/" + jobId + "/profile_mem.pprof"}
+
+	w := apiRequest(t, h, http.MethodGet, "/job/"+jobId+"/pprof", "", http.StatusFound)
+	if location := w.Header().Get("Location"); location != "/job/"+jobId+"/pprof/mem/" {
+		t.Fatalf("Unexpected redirect: %s", location)
+	}
+	apiRequest(t, h, http.MethodGet, "/job/"+jobId+"/pprof/mem", "", http.StatusFound)
+
+	w = apiRequest(t, h, http.MethodGet, "/job/"+jobId+"/pprof/mem/top", "", http.StatusOK)
+	if !strings.Contains(w.Body.String(), "pprof") {
+		t.Fatalf("Unexpected profile view: %s", w.Body.String())
+	}
+	apiRequest(t, h, http.MethodGet, "/job/"+jobId+"/pprof/mem/foo", "", http.StatusNotFound)
+	apiRequest(t, h, http.MethodGet, "/job/"+jobId+"/pprof/cpu/", "", http.StatusNotFound)
+	apiRequest(t, h, http.MethodGet, "/job/"+testJobIds[1]+"/pprof", "", http.StatusNotFound)
+
+	// Difference between profiles of two jobs
+	baseJobId := testJobIds[1]
+	baseJob, _, _ := c.LoadJob(baseJobId)
+	diffPath := "/diff/" + baseJobId + "/" + jobId + "/pprof"
+	apiRequest(t, h, http.MethodGet, diffPath+"/mem/", "", http.StatusNotFound)
+
+	baseJob.Profiles = map[string]string{"mem": "jobs/" + baseJobId + "/profile_mem.pprof"}
+	w = apiRequest(t, h, http.MethodGet, diffPath, "", http.StatusFound)
+	if location := w.Header().Get("Location"); location != diffPath+"/mem/" {
+		t.Fatalf("Unexpected redirect: %s", location)
+	}
+	apiRequest(t, h, http.MethodGet, diffPath+"/mem/flamegraph", "", http.StatusOK)
+
+	// Loaded viewers remain available while another one is created
+	c.profileLoading = make(chan struct{}, 8)
+	c.profileRelease = make(chan struct{})
+	reverseDiffPath := "/diff/" + jobId + "/" + baseJobId + "/pprof/mem/top"
+	serve := func(path string, status chan<- int) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		status <- w.Code
+	}
+	reverseDiffStatus := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go serve(reverseDiffPath, reverseDiffStatus)
+	}
+	<-c.profileLoading
+	viewStatus := make(chan int, 1)
+	go serve("/job/"+jobId+"/pprof/mem/top", viewStatus)
+	select {
+	case status := <-viewStatus:
+		if status != http.StatusOK {
+			t.Fatalf("Unexpected status: %d", status)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Loaded viewer blocked by viewer being created")
+	}
+	close(c.profileRelease)
+	// Concurrent requests for the same viewer share it
+	for i := 0; i < 2; i++ {
+		if status := <-reverseDiffStatus; status != http.StatusOK {
+			t.Fatalf("Unexpected status: %d", status)
+		}
+	}
+	if len(c.profileLoading) != 1 {
+		t.Fatalf("Unexpected number of profile loads: %d", len(c.profileLoading)+1)
+	}
+}
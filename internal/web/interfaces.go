@@ -1,6 +1,7 @@
 package web
 
 import (
+	"context"
 	"io"
 
 	"github.com/mprimi/go-bench-away/v1/core"
@@ -9,10 +10,15 @@ import (
 type WebClient interface {
 	LoadJob(jobId string) (*core.JobRecord, uint64, error)
 	GetQueueStatus() (*core.QueueStatus, error)
-	LoadRecentJobs(limit int) ([]*core.JobRecord, error)
+	LoadRecentJobsWithContext(ctx context.Context, limit int) ([]*core.JobRecord, core.ParallelErrors, error)
+	SearchJobs(q core.JobsQuery) ([]*core.JobRecord, int, error)
 	LoadResultsArtifact(job *core.JobRecord, w io.Writer) error
 	LoadLogArtifact(job *core.JobRecord, w io.Writer) error
 	LoadScriptArtifact(job *core.JobRecord, w io.Writer) error
+	LoadProfileArtifact(job *core.JobRecord, profile string, w io.Writer) error
+	LoadTestBinaryArtifact(job *core.JobRecord, w io.Writer) error
+	LoadResourcesArtifact(job *core.JobRecord, w io.Writer) error
 	CancelJob(id string) error
+	SubmitJob(params core.JobParameters) (*core.JobRecord, error)
 	QueueName() string
 }
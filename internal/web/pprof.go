@@ -0,0 +1,284 @@
+package web
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/google/pprof/driver"
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+const kJobIdExpr = `[[:xdigit:]]{8}-[[:xdigit:]]{4}-[[:xdigit:]]{4}-[[:xdigit:]]{4}-[[:xdigit:]]{12}`
+
+var jobProfileRegexp = regexp.MustCompile(`^/job/(` + kJobIdExpr + `)/pprof(?:/([a-z]+)(/.*)?)?$`)
+
+var profileDiffRegexp = regexp.MustCompile(`^/diff/(` + kJobIdExpr + `)/(` + kJobIdExpr + `)/pprof(?:/([a-z]+)(/.*)?)?$`)
+
+// Number of profile viewers kept ready, each holds a copy of the profile and test binary on disk
+const kMaxProfileViewers = 8
+
+// Web interface of pprof for a profile of a job
+type profileViewer struct {
+	key      string
+	dir      string
+	handlers map[string]http.Handler
+}
+
+// Bounded set of profile viewers, the least recently created is closed first
+type profileViewers struct {
+	sync.Mutex
+	viewers []*profileViewer
+	// Viewers being created (without holding the lock), requests for the same viewer wait for them
+	pending map[string]*pendingProfileViewer
+}
+
+type pendingProfileViewer struct {
+	done   chan struct{}
+	viewer *profileViewer
+	err    error
+}
+
+func (pv *profileViewer) close() {
+	if err := os.RemoveAll(pv.dir); err != nil {
+		fmt.Printf("Failed to remove profile directory %s: %v\n", pv.dir, err)
+	}
+}
+
+// Serve the pprof web interface for a profile of a job:
+//
+//	/job/<id>/pprof              redirects to the first available profile
+//	/job/<id>/pprof/<type>/...   pprof views of the given profile (graph, top, flame graph, ...)
+//
+// Or for the difference between profiles of two jobs (baseJobId not empty):
+//
+//	/diff/<base id>/<id>/pprof/<type>/...
+func (h *handler) serveJobProfile(w http.ResponseWriter, r *http.Request, baseJobId, jobId, profile, viewPath string) error {
+	job, _, err := h.client.LoadJob(jobId)
+	if err != nil {
+		return fmt.Errorf("Failed to load job '%s': %v", jobId, err)
+	}
+	var baseJob *core.JobRecord
+	if baseJobId != "" {
+		baseJob, _, err = h.client.LoadJob(baseJobId)
+		if err != nil {
+			return fmt.Errorf("Failed to load job '%s': %v", baseJobId, err)
+		}
+	}
+
+	hasProfile := func(profileType string) bool {
+		_, found := job.Profiles[profileType]
+		if baseJob != nil {
+			_, baseFound := baseJob.Profiles[profileType]
+			found = found && baseFound
+		}
+		return found
+	}
+
+	if profile == "" {
+		for _, profileType := range core.ProfileTypes {
+			if hasProfile(profileType) {
+				http.Redirect(w, r, strings.TrimSuffix(r.URL.Path, "/")+"/"+profileType+"/", http.StatusFound)
+				return nil
+			}
+		}
+		return statusErrorf(http.StatusNotFound, "No profiles available")
+	} else if !hasProfile(profile) {
+		return statusErrorf(http.StatusNotFound, "No %s profile available", profile)
+	} else if viewPath == "" {
+		// Views use relative links
+		http.Redirect(w, r, r.URL.Path+"/", http.StatusFound)
+		return nil
+	}
+
+	viewer, err := h.profileViewer(baseJob, job, profile)
+	if err != nil {
+		return err
+	}
+
+	viewHandler, found := viewer.handlers[viewPath]
+	if !found {
+		return statusErrorf(http.StatusNotFound, "Unknown profile view: %s", viewPath)
+	}
+	viewHandler.ServeHTTP(w, r)
+	return nil
+}
+
+// Get the viewer for a profile of a job (or the difference with the profile of a base job, if not nil), creating it
+// if necessary
+func (h *handler) profileViewer(baseJob, job *core.JobRecord, profile string) (*profileViewer, error) {
+	key := job.Id + "/" + profile
+	if baseJob != nil {
+		key = baseJob.Id + "/" + key
+	}
+
+	h.profileViewers.Lock()
+	for _, viewer := range h.profileViewers.viewers {
+		if viewer.key == key {
+			h.profileViewers.Unlock()
+			return viewer, nil
+		}
+	}
+	if pending, found := h.profileViewers.pending[key]; found {
+		h.profileViewers.Unlock()
+		<-pending.done
+		return pending.viewer, pending.err
+	}
+	pending := &pendingProfileViewer{done: make(chan struct{})}
+	if h.profileViewers.pending == nil {
+		h.profileViewers.pending = map[string]*pendingProfileViewer{}
+	}
+	h.profileViewers.pending[key] = pending
+	h.profileViewers.Unlock()
+
+	// Downloading artifacts and loading the profile can take a while, other viewers remain available meanwhile
+	pending.viewer, pending.err = h.newProfileViewer(key, baseJob, job, profile)
+
+	var evicted *profileViewer
+	h.profileViewers.Lock()
+	delete(h.profileViewers.pending, key)
+	if pending.err == nil {
+		if len(h.profileViewers.viewers) >= kMaxProfileViewers {
+			evicted = h.profileViewers.viewers[0]
+			h.profileViewers.viewers = h.profileViewers.viewers[1:]
+		}
+		h.profileViewers.viewers = append(h.profileViewers.viewers, pending.viewer)
+	}
+	h.profileViewers.Unlock()
+	close(pending.done)
+
+	if evicted != nil {
+		evicted.close()
+	}
+	return pending.viewer, pending.err
+}
+
+// Download a profile (and the test binary, to symbolize it, and the base profile, if any) and load it in pprof
+func (h *handler) newProfileViewer(key string, baseJob, job *core.JobRecord, profile string) (*profileViewer, error) {
+	dir, err := os.MkdirTemp("", "go-bench-away-pprof-")
+	if err != nil {
+		return nil, err
+	}
+	viewer := &profileViewer{
+		key: key,
+		dir: dir,
+	}
+
+	args := []string{"-http=localhost:0", "-no_browser"}
+
+	if baseJob != nil {
+		basePath := filepath.Join(dir, "base_"+core.ProfileArtifact(profile))
+		err = writeArtifactFile(basePath, func(w io.Writer) error { return h.client.LoadProfileArtifact(baseJob, profile, w) })
+		if err != nil {
+			viewer.close()
+			return nil, fmt.Errorf("Failed to load base %s profile: %v", profile, err)
+		}
+		args = append(args, "-diff_base="+basePath)
+	}
+
+	if job.TestBinary != "" {
+		binaryPath := filepath.Join(dir, core.TestBinaryArtifact)
+		err = writeArtifactFile(binaryPath, func(w io.Writer) error { return h.client.LoadTestBinaryArtifact(job, w) })
+		if err != nil {
+			viewer.close()
+			return nil, fmt.Errorf("Failed to load test binary: %v", err)
+		}
+		args = append(args, binaryPath)
+	}
+
+	profilePath := filepath.Join(dir, core.ProfileArtifact(profile))
+	err = writeArtifactFile(profilePath, func(w io.Writer) error { return h.client.LoadProfileArtifact(job, profile, w) })
+	if err != nil {
+		viewer.close()
+		return nil, fmt.Errorf("Failed to load %s profile: %v", profile, err)
+	}
+	args = append(args, profilePath)
+
+	err = driver.PProf(&driver.Options{
+		Flagset: newPProfFlags(args),
+		UI:      pprofUI{},
+		HTTPServer: func(serverArgs *driver.HTTPServerArgs) error {
+			viewer.handlers = serverArgs.Handlers
+			return nil
+		},
+	})
+	if err == nil && viewer.handlers == nil {
+		err = fmt.Errorf("web interface not started")
+	}
+	if err != nil {
+		viewer.close()
+		return nil, fmt.Errorf("Failed to load %s profile: %v", profile, err)
+	}
+	return viewer, nil
+}
+
+func writeArtifactFile(path string, load func(w io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := load(file); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Command-line flags of pprof, parsed from a fixed list of arguments
+type pprofFlags struct {
+	*flag.FlagSet
+	args []string
+}
+
+func newPProfFlags(args []string) *pprofFlags {
+	f := flag.NewFlagSet("pprof", flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	return &pprofFlags{FlagSet: f, args: args}
+}
+
+func (f *pprofFlags) StringList(name string, def string, usage string) *[]*string {
+	return &[]*string{f.String(name, def, usage)}
+}
+
+func (f *pprofFlags) ExtraUsage() string {
+	return ""
+}
+
+func (f *pprofFlags) AddExtraUsage(string) {}
+
+func (f *pprofFlags) Parse(usage func()) []string {
+	if err := f.FlagSet.Parse(f.args); err != nil || f.NArg() == 0 {
+		usage()
+		return nil
+	}
+	return f.Args()
+}
+
+// Non-interactive pprof UI, messages are logged
+type pprofUI struct{}
+
+func (pprofUI) ReadLine(string) (string, error) {
+	return "", io.EOF
+}
+
+func (pprofUI) Print(args ...interface{}) {}
+
+func (pprofUI) PrintErr(args ...interface{}) {
+	fmt.Printf("pprof: %s\n", strings.TrimSpace(fmt.Sprint(args...)))
+}
+
+func (pprofUI) IsTerminal() bool {
+	return false
+}
+
+func (pprofUI) WantBrowser() bool {
+	return false
+}
+
+func (pprofUI) SetAutoComplete(func(string) string) {}
@@ -0,0 +1,106 @@
+package worker
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// Timeout of git operations creating or refreshing a mirror
+	kGitMirrorTimeout = 15 * time.Minute
+	// Subdirectories of the worker cache directory
+	kGitMirrorsDir = "git"
+	kGoModCacheDir = "gomodcache"
+)
+
+// WithCacheDir enables caching across jobs in the given directory: a bare mirror of each git remote (refreshed before
+// each job, and used as the source of checkouts), and a shared Go module cache
+func WithCacheDir(dir string) Option {
+	return func(w *workerImpl) {
+		w.cacheDir = dir
+	}
+}
+
+// Path of the mirror of a git remote, named after the remote (e.g. nats-server-1a2b3c4d5e6f7a8b.git)
+func (w *workerImpl) gitMirrorPath(remote string) string {
+	name := strings.TrimSuffix(path.Base(strings.TrimRight(remote, "/")), ".git")
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+	hash := sha256.Sum256([]byte(remote))
+	return filepath.Join(w.cacheDir, kGitMirrorsDir, fmt.Sprintf("%s-%x.git", name, hash[:8]))
+}
+
+// Create the mirror of a git remote, or refresh it if it exists. Returns the path of the mirror, unless it could not
+// be created. If refreshing fails, the existing mirror is still returned (along with the error).
+func (w *workerImpl) updateGitMirror(remote string) (string, error) {
+	mirrorPath := w.gitMirrorPath(remote)
+	if err := os.MkdirAll(filepath.Dir(mirrorPath), 0750); err != nil {
+		return "", fmt.Errorf("Failed to create mirrors directory: %v", err)
+	}
+
+	// Workers sharing the cache directory update each mirror one at a time
+	lockFile, err := os.OpenFile(mirrorPath+".lock", os.O_CREATE|os.O_RDWR, 0640)
+	if err != nil {
+		return "", fmt.Errorf("Failed to create mirror lock: %v", err)
+	}
+	defer lockFile.Close()
+	if err := unix.Flock(int(lockFile.Fd()), unix.LOCK_EX); err != nil {
+		return "", fmt.Errorf("Failed to lock mirror: %v", err)
+	}
+	defer unix.Flock(int(lockFile.Fd()), unix.LOCK_UN)
+
+	ctx, cancel := context.WithTimeout(context.Background(), kGitMirrorTimeout)
+	defer cancel()
+
+	if _, err := os.Stat(mirrorPath); err == nil {
+		if err := runGit(ctx, mirrorPath, "fetch", "--quiet", "--prune", "origin"); err != nil {
+			return mirrorPath, fmt.Errorf("Failed to refresh mirror of %s: %v", remote, err)
+		}
+		return mirrorPath, nil
+	}
+
+	// Clone in a temporary directory, so that an interrupted clone does not leave a broken mirror behind
+	tempPath := mirrorPath + ".tmp"
+	os.RemoveAll(tempPath)
+	if err := runGit(ctx, "", "clone", "--quiet", "--mirror", remote, tempPath); err != nil {
+		os.RemoveAll(tempPath)
+		return "", fmt.Errorf("Failed to create mirror of %s: %v", remote, err)
+	}
+	if err := os.Rename(tempPath, mirrorPath); err != nil {
+		return "", fmt.Errorf("Failed to create mirror of %s: %v", remote, err)
+	}
+	return mirrorPath, nil
+}
+
+// Run git (in the given directory, if not empty), returns an error including its output if it fails
+func runGit(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// Never prompt for credentials
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Environment shared by all jobs when caching is enabled
+func (w *workerImpl) cacheEnv() []string {
+	if w.cacheDir == "" {
+		return nil
+	}
+	return []string{"GOMODCACHE=" + filepath.Join(w.cacheDir, kGoModCacheDir)}
+}
@@ -0,0 +1,123 @@
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+// Kernel command line flags recorded with the host environment (flags are matched by name, before '=')
+var hostKernelFlags = map[string]bool{
+	"isolcpus":                 true,
+	"nohz_full":                true,
+	"rcu_nocbs":                true,
+	"irqaffinity":              true,
+	"nosmt":                    true,
+	"mitigations":              true,
+	"intel_pstate":             true,
+	"amd_pstate":               true,
+	"idle":                     true,
+	"processor.max_cstate":     true,
+	"intel_idle.max_cstate":    true,
+	"transparent_hugepage":     true,
+	"cpufreq.default_governor": true,
+}
+
+// Probes the host environment, reading files from /proc and /sys under the root directory (only on Linux, on other
+// systems most properties are unknown)
+type hostProbe struct {
+	root string
+}
+
+func (hp hostProbe) readFile(path string) string {
+	data, err := os.ReadFile(filepath.Join(hp.root, path))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Probe the properties of the host (load is sampled separately)
+func (hp hostProbe) hostInfo() *core.HostInfo {
+	hi := &core.HostInfo{
+		CPUModel: hp.cpuModel(),
+		NumCPU:   runtime.NumCPU(),
+		Governor: hp.readFile("sys/devices/system/cpu/cpu0/cpufreq/scaling_governor"),
+		SMT:      hp.readFile("sys/devices/system/cpu/smt/control"),
+	}
+
+	if noTurbo := hp.readFile("sys/devices/system/cpu/intel_pstate/no_turbo"); noTurbo == "1" {
+		hi.Turbo = "off"
+	} else if noTurbo == "0" {
+		hi.Turbo = "on"
+	} else if boost := hp.readFile("sys/devices/system/cpu/cpufreq/boost"); boost == "1" {
+		hi.Turbo = "on"
+	} else if boost == "0" {
+		hi.Turbo = "off"
+	}
+
+	for _, flag := range strings.Fields(hp.readFile("proc/cmdline")) {
+		name, _, _ := strings.Cut(flag, "=")
+		if hostKernelFlags[name] {
+			hi.KernelFlags = append(hi.KernelFlags, flag)
+		}
+	}
+	return hi
+}
+
+func (hp hostProbe) cpuModel() string {
+	scanner := bufio.NewScanner(strings.NewReader(hp.readFile("proc/cpuinfo")))
+	for scanner.Scan() {
+		// x86: 'model name', ARM: 'Model' or 'CPU part'
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		key = strings.TrimSpace(key)
+		if found && (key == "model name" || key == "Model") {
+			return strings.TrimSpace(value)
+		}
+	}
+	return runtime.GOARCH
+}
+
+// Sample the current load average and available memory
+func (hp hostProbe) load() core.HostLoad {
+	hl := core.HostLoad{}
+	for i, field := range strings.Fields(hp.readFile("proc/loadavg")) {
+		if i >= len(hl.LoadAvg) {
+			break
+		}
+		hl.LoadAvg[i], _ = strconv.ParseFloat(field, 64)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(hp.readFile("proc/meminfo")))
+	for scanner.Scan() {
+		// MemAvailable:   12345678 kB
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 3 && fields[0] == "MemAvailable:" && fields[2] == "kB" {
+			kb, _ := strconv.ParseUint(fields[1], 10, 64)
+			hl.FreeMemory = kb << 10
+		}
+	}
+	return hl
+}
+
+// Parse the output of `go env -json`, keeping the variables recorded with the host environment
+func parseGoEnv(data []byte) map[string]string {
+	env := map[string]string{}
+	if err := json.Unmarshal(bytes.TrimSpace(data), &env); err != nil {
+		return nil
+	}
+	goEnv := map[string]string{}
+	for _, name := range core.HostGoEnvVars {
+		if value, found := env[name]; found && value != "" {
+			goEnv[name] = value
+		}
+	}
+	return goEnv
+}
@@ -17,6 +17,9 @@ type JobUpdaterClient interface {
 	UploadLogArtifact(string, string) (string, error)
 	UploadResultsArtifact(string, string) (string, error)
 	UploadScriptArtifact(string, string) (string, error)
+	UploadProfileArtifact(string, string, string) (string, error)
+	UploadTestBinaryArtifact(string, string) (string, error)
+	UploadResourcesArtifact(string, string) (string, error)
 }
 
 type WorkerClient interface {
@@ -0,0 +1,161 @@
+package worker
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+const (
+	// Clock ticks per second, unit of process CPU times in /proc (USER_HZ, 100 on all common architectures)
+	kClockTicksPerSecond = 100
+	// Default interval over which CPU usage of processes is measured
+	kDefaultPreflightSampleInterval = 3 * time.Second
+)
+
+// PreflightConfig sets the thresholds of the checks performed before running each job, zero values disable a check.
+// If any check fails, the worker waits and retries, then runs the job anyway, marking it with a warning.
+type PreflightConfig struct {
+	// Maximum 1-minute load average
+	MaxLoadAvg float64
+	// Required CPU frequency scaling governor (e.g. performance)
+	Governor string
+	// Maximum CPU usage (percent of one core) of any other process
+	MaxProcessCPU float64
+	// Minimum memory available (bytes)
+	MinFreeMemory uint64
+	// Number of times checks are repeated if they fail, and the delay between attempts
+	Retries    int
+	RetryDelay time.Duration
+	// Interval over which CPU usage of processes is measured (default: 3s)
+	SampleInterval time.Duration
+}
+
+func (pc *PreflightConfig) enabled() bool {
+	return pc.MaxLoadAvg > 0 || pc.Governor != "" || pc.MaxProcessCPU > 0 || pc.MinFreeMemory > 0
+}
+
+// WithPreflight enables checks of system quiescence before running each job
+func WithPreflight(cfg PreflightConfig) Option {
+	return func(w *workerImpl) {
+		if cfg.SampleInterval <= 0 {
+			cfg.SampleInterval = kDefaultPreflightSampleInterval
+		}
+		w.preflight = &cfg
+	}
+}
+
+// Run pre-flight checks, retrying after a delay if they fail
+func (w *workerImpl) runPreflight(job *core.JobRecord) *core.PreflightResult {
+	result := &core.PreflightResult{}
+	for {
+		result.Attempts++
+		result.Checks = w.preflightChecks()
+		if result.Passed() {
+			fmt.Printf("⚙️  Pre-flight checks passed for job %s\n", job.Id)
+			return result
+		} else if result.Attempts > w.preflight.Retries {
+			fmt.Printf("⚠️  Running job %s anyway, %s\n", job.Id, result.Warning())
+			return result
+		}
+		fmt.Printf("⏳ Pre-flight checks failed for job %s (%s), retrying in %v\n", job.Id, result.Warning(), w.preflight.RetryDelay)
+		time.Sleep(w.preflight.RetryDelay)
+	}
+}
+
+func (w *workerImpl) preflightChecks() []core.PreflightCheck {
+	cfg := w.preflight
+	checks := []core.PreflightCheck{}
+	load := w.host.load()
+
+	if cfg.MaxLoadAvg > 0 {
+		checks = append(checks, core.PreflightCheck{
+			Name:    "load",
+			Passed:  load.LoadAvg[0] <= cfg.MaxLoadAvg,
+			Message: fmt.Sprintf("load average %.2f (max: %.2f)", load.LoadAvg[0], cfg.MaxLoadAvg),
+		})
+	}
+
+	if cfg.Governor != "" {
+		governor := w.host.hostInfo().Governor
+		checks = append(checks, core.PreflightCheck{
+			Name:    "governor",
+			Passed:  governor == cfg.Governor,
+			Message: fmt.Sprintf("CPU frequency governor '%s' (required: %s)", governor, cfg.Governor),
+		})
+	}
+
+	if cfg.MinFreeMemory > 0 {
+		checks = append(checks, core.PreflightCheck{
+			Name:    "memory",
+			Passed:  load.FreeMemory >= cfg.MinFreeMemory,
+			Message: fmt.Sprintf("%dMB memory available (min: %dMB)", load.FreeMemory>>20, cfg.MinFreeMemory>>20),
+		})
+	}
+
+	if cfg.MaxProcessCPU > 0 {
+		before := w.host.processTimes()
+		time.Sleep(cfg.SampleInterval)
+		after := w.host.processTimes()
+		heavy := heavyProcesses(before, after, cfg.SampleInterval, cfg.MaxProcessCPU, os.Getpid())
+		message := fmt.Sprintf("no process above %.0f%% CPU", cfg.MaxProcessCPU)
+		if len(heavy) > 0 {
+			message = fmt.Sprintf("processes above %.0f%% CPU: %s", cfg.MaxProcessCPU, strings.Join(heavy, ", "))
+		}
+		checks = append(checks, core.PreflightCheck{
+			Name:    "processes",
+			Passed:  len(heavy) == 0,
+			Message: message,
+		})
+	}
+	return checks
+}
+
+// CPU time used by a process
+type processTime struct {
+	name  string
+	ticks uint64
+}
+
+// Sample the CPU time used by each running process (by PID), from /proc/<pid>/stat
+func (hp hostProbe) processTimes() map[int]processTime {
+	times := map[int]processTime{}
+	for _, pid := range hp.pids() {
+		if stat, ok := hp.procStat(pid); ok {
+			times[pid] = processTime{name: stat.name, ticks: stat.ticks}
+		}
+	}
+	return times
+}
+
+// Processes (other than the given one) whose CPU usage between two samples is above the threshold (percent of one
+// core), sorted by highest usage first
+func heavyProcesses(before, after map[int]processTime, interval time.Duration, maxPercent float64, selfPid int) []string {
+	type usage struct {
+		pid     int
+		name    string
+		percent float64
+	}
+	heavy := []usage{}
+	for pid, end := range after {
+		start, found := before[pid]
+		if pid == selfPid || !found || end.ticks < start.ticks {
+			continue
+		}
+		percent := 100 * float64(end.ticks-start.ticks) / kClockTicksPerSecond / interval.Seconds()
+		if percent > maxPercent {
+			heavy = append(heavy, usage{pid, end.name, percent})
+		}
+	}
+	sort.Slice(heavy, func(i, j int) bool { return heavy[i].percent > heavy[j].percent })
+
+	processes := make([]string, len(heavy))
+	for i, u := range heavy {
+		processes[i] = fmt.Sprintf("%s (%d) %.0f%%", u.name, u.pid, u.percent)
+	}
+	return processes
+}
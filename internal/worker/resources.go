@@ -0,0 +1,233 @@
+package worker
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+// Default interval between samples of resource usage while a job runs
+const kDefaultResourceSampleInterval = 1 * time.Second
+
+// WithResourceSampling sets the interval between samples of resource usage while a job runs (0 disables sampling)
+func WithResourceSampling(interval time.Duration) Option {
+	return func(w *workerImpl) {
+		w.sampleInterval = interval
+	}
+}
+
+// Fields of /proc/<pid>/stat
+type procStat struct {
+	name string
+	ppid int
+	// CPU time (clock ticks) of the process, and of its children that exited and were waited for
+	ticks         uint64
+	childrenTicks uint64
+	// Resident set size (pages)
+	rss uint64
+}
+
+// Read /proc/<pid>/stat: <pid> (<name>) <state> <ppid> ... <utime> <stime> <cutime> <cstime> ... <rss> ...
+func (hp hostProbe) procStat(pid int) (procStat, bool) {
+	stat := hp.readFile(filepath.Join("proc", strconv.Itoa(pid), "stat"))
+	// The name may contain spaces and parentheses
+	nameStart, nameEnd := strings.IndexByte(stat, '('), strings.LastIndexByte(stat, ')')
+	if nameStart < 0 || nameEnd < nameStart {
+		return procStat{}, false
+	}
+	fields := strings.Fields(stat[nameEnd+1:])
+	if len(fields) < 22 {
+		return procStat{}, false
+	}
+	// Fields after the name, starting from 0 (state)
+	values := map[int]uint64{}
+	for _, i := range []int{1, 11, 12, 13, 14, 21} {
+		value, err := strconv.ParseUint(fields[i], 10, 64)
+		if err != nil {
+			return procStat{}, false
+		}
+		values[i] = value
+	}
+	return procStat{
+		name:          stat[nameStart+1 : nameEnd],
+		ppid:          int(values[1]),
+		ticks:         values[11] + values[12],
+		childrenTicks: values[13] + values[14],
+		rss:           values[21],
+	}, true
+}
+
+// List the PIDs of running processes
+func (hp hostProbe) pids() []int {
+	entries, err := os.ReadDir(filepath.Join(hp.root, "proc"))
+	if err != nil {
+		return nil
+	}
+	pids := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		if pid, err := strconv.Atoi(entry.Name()); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// Read the number of voluntary and involuntary context switches of a process, from /proc/<pid>/status
+func (hp hostProbe) ctxSwitches(pid int) (uint64, uint64) {
+	var voluntary, involuntary uint64
+	scanner := bufio.NewScanner(strings.NewReader(hp.readFile(filepath.Join("proc", strconv.Itoa(pid), "status"))))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		count, _ := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		switch key {
+		case "voluntary_ctxt_switches":
+			voluntary = count
+		case "nonvoluntary_ctxt_switches":
+			involuntary = count
+		}
+	}
+	return voluntary, involuntary
+}
+
+// Read the total and idle CPU time of the host (clock ticks), from the first line of /proc/stat:
+// cpu <user> <nice> <system> <idle> <iowait> <irq> <softirq> <steal> ...
+func (hp hostProbe) hostCPUTicks() (uint64, uint64) {
+	line, _, _ := strings.Cut(hp.readFile("proc/stat"), "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, 0
+	}
+	var total, idle uint64
+	for i, field := range fields[1:] {
+		value, _ := strconv.ParseUint(field, 10, 64)
+		// Guest time (fields 9 and 10) is already included in user time
+		if i < 8 {
+			total += value
+		}
+		// idle and iowait
+		if i == 3 || i == 4 {
+			idle += value
+		}
+	}
+	return total, idle
+}
+
+// Samples the resource usage of a process tree at a fixed interval, until stopped
+type resourceSampler struct {
+	host     hostProbe
+	rootPid  int
+	interval time.Duration
+	started  time.Time
+	// Host CPU ticks at the previous sample
+	hostTotal uint64
+	hostIdle  uint64
+
+	mu    sync.Mutex
+	usage core.ResourceUsage
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+func (hp hostProbe) startResourceSampler(rootPid int, interval time.Duration) *resourceSampler {
+	rs := &resourceSampler{
+		host:     hp,
+		rootPid:  rootPid,
+		interval: interval,
+		started:  time.Now(),
+		usage:    core.ResourceUsage{Interval: interval},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	rs.hostTotal, rs.hostIdle = hp.hostCPUTicks()
+
+	go func() {
+		defer close(rs.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-rs.stop:
+				return
+			case <-ticker.C:
+				if sample, ok := rs.sample(); ok {
+					rs.mu.Lock()
+					rs.usage.Samples = append(rs.usage.Samples, sample)
+					rs.mu.Unlock()
+				}
+			}
+		}
+	}()
+	return rs
+}
+
+// Stop sampling, returns the samples collected
+func (rs *resourceSampler) Stop() *core.ResourceUsage {
+	close(rs.stop)
+	<-rs.done
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return &rs.usage
+}
+
+// Sample the resource usage of the process tree, returns false if the root process is gone
+func (rs *resourceSampler) sample() (core.ResourceSample, bool) {
+	stats := map[int]procStat{}
+	children := map[int][]int{}
+	for _, pid := range rs.host.pids() {
+		if stat, ok := rs.host.procStat(pid); ok {
+			stats[pid] = stat
+			children[stat.ppid] = append(children[stat.ppid], pid)
+		}
+	}
+	if _, found := stats[rs.rootPid]; !found {
+		return core.ResourceSample{}, false
+	}
+
+	sample := core.ResourceSample{
+		Elapsed: time.Since(rs.started),
+	}
+	pageSize := uint64(os.Getpagesize())
+	var ticks uint64
+	tree := []int{rs.rootPid}
+	for len(tree) > 0 {
+		pid := tree[0]
+		tree = append(tree[1:], children[pid]...)
+		stat := stats[pid]
+		// CPU time of exited children was added to their parent when waited for
+		ticks += stat.ticks + stat.childrenTicks
+		sample.RSS += stat.rss * pageSize
+		voluntary, involuntary := rs.host.ctxSwitches(pid)
+		sample.VoluntaryCtxSwitches += voluntary
+		sample.InvoluntaryCtxSwitches += involuntary
+	}
+	sample.CPUTime = time.Duration(ticks) * time.Second / kClockTicksPerSecond
+
+	hostTotal, hostIdle := rs.host.hostCPUTicks()
+	if hostTotal > rs.hostTotal {
+		busy := (hostTotal - rs.hostTotal) - (hostIdle - rs.hostIdle)
+		sample.HostCPU = 100 * float64(busy) / float64(hostTotal-rs.hostTotal)
+	}
+	rs.hostTotal, rs.hostIdle = hostTotal, hostIdle
+	return sample, true
+}
+
+// Write resource usage samples to a file, unless there are none (e.g. /proc is not available)
+func writeResourceUsage(usage *core.ResourceUsage, path string) error {
+	if len(usage.Samples) == 0 {
+		return nil
+	}
+	if err := os.WriteFile(path, usage.Bytes(), 0644); err != nil {
+		return fmt.Errorf("Failed to write resource usage: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"fmt"
+	"os"
+	"text/template"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+// Values available to benchmark script templates
+type scriptTemplateValues struct {
+	// Paths (absolute) of the job directory, and of the files where the script writes its outputs
+	JobDirPath    string
+	ResultsPath   string
+	ShaPath       string
+	GoVersionPath string
+	GoEnvPath     string
+	// Job parameters
+	GitRemote       string
+	GitRef          string
+	TestsSubDir     string
+	TestsFilterExpr string
+	Reps            string
+	MinRuntime      string
+	Timeout         string
+	GoPath          string
+	CleanupCommand  string
+	// Options of `go test` to collect profiles (optional)
+	ProfileOpts string
+	// Options of `go test` requested by the job, e.g. build tags, CPUs (shell-quoted words, optional)
+	TestOpts string
+	// Package patterns to benchmark, and the filter expression of each (shell-quoted words, same number)
+	Packages       string
+	PackageFilters string
+	// Path (absolute) of the local mirror of the git remote, to checkout from (optional)
+	GitMirror string
+	// Custom parameters of the job, a template referencing a parameter the job does not set fails.
+	// Values are set by whoever submits the job, templates must quote them (e.g. {{shquote .Params.NAME}})
+	Params map[string]string
+}
+
+// WithScriptTemplate replaces the default benchmark script template with the one in the given file
+func WithScriptTemplate(path string) Option {
+	return WithNamedScriptTemplate("", path)
+}
+
+// WithNamedScriptTemplate adds a benchmark script template (from the given file) that jobs can select by name
+func WithNamedScriptTemplate(name, path string) Option {
+	return func(w *workerImpl) {
+		w.scriptTemplatePaths[name] = path
+	}
+}
+
+// Load the script templates set with options
+func (w *workerImpl) loadScriptTemplates() error {
+	for name, path := range w.scriptTemplatePaths {
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Failed to read script template: %v", err)
+		}
+		tmpl, err := parseScriptTemplate(string(text))
+		if err != nil {
+			return fmt.Errorf("Invalid script template %s: %v", path, err)
+		}
+		if name == "" {
+			w.scriptTemplate = tmpl
+		} else {
+			w.namedScriptTemplates[name] = tmpl
+		}
+	}
+	return nil
+}
+
+// Functions available to script templates
+var scriptTemplateFuncs = template.FuncMap{
+	// Quote a value as a single shell word
+	"shquote": shellQuote,
+}
+
+func parseScriptTemplate(text string) (*template.Template, error) {
+	return template.New("benchmark_script").Option("missingkey=error").Funcs(scriptTemplateFuncs).Parse(text)
+}
+
+// Select the script template of a job
+func (w *workerImpl) jobScriptTemplate(job *core.JobRecord) (*template.Template, error) {
+	name := job.Parameters.ScriptTemplate
+	if name == "" {
+		return w.scriptTemplate, nil
+	}
+	tmpl, found := w.namedScriptTemplates[name]
+	if !found {
+		return nil, fmt.Errorf("Unknown script template: '%s'", name)
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,118 @@
+package worker
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+// Environment variables jobs can set by default
+var DefaultAllowedEnv = []string{"GOGC", "GOMEMLIMIT", "GOEXPERIMENT", "GODEBUG", "GOAMD64", "GOARM", "CGO_ENABLED"}
+
+// Extra `go test` flags jobs can set by default (by name)
+var DefaultAllowedTestFlags = []string{"race", "gcflags", "trimpath"}
+
+// WithAllowedEnv sets the environment variables jobs can set (default: DefaultAllowedEnv)
+func WithAllowedEnv(names []string) Option {
+	return func(w *workerImpl) {
+		w.allowedEnv = stringSet(names)
+	}
+}
+
+// WithAllowedTestFlags sets the extra `go test` flags jobs can set, by name (default: DefaultAllowedTestFlags)
+func WithAllowedTestFlags(names []string) Option {
+	return func(w *workerImpl) {
+		w.allowedTestFlags = stringSet(names)
+	}
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[strings.TrimPrefix(strings.TrimSpace(value), "-")] = true
+	}
+	return set
+}
+
+// Check the `go test` options and environment of a job are well-formed and allowed
+func (w *workerImpl) checkTestOptions(job *core.JobRecord) error {
+	if err := job.Parameters.ValidateTestOptions(); err != nil {
+		return err
+	}
+	if err := job.Parameters.ValidatePackages(); err != nil {
+		return err
+	}
+	for name := range job.Parameters.Env {
+		if !w.allowedEnv[name] {
+			return fmt.Errorf("environment variable %s is not allowed", name)
+		}
+	}
+	for _, flag := range job.Parameters.TestFlags {
+		if name := core.TestFlagName(flag); !w.allowedTestFlags[name] {
+			return fmt.Errorf("test flag -%s is not allowed", name)
+		}
+	}
+	return nil
+}
+
+// Options of `go test` requested by the job (build tags, CPUs, ...), as shell-quoted words
+func testOpts(job *core.JobRecord) string {
+	params := job.Parameters
+	opts := []string{}
+	if len(params.BuildTags) > 0 {
+		opts = append(opts, "-tags", strings.Join(params.BuildTags, ","))
+	}
+	if len(params.CPUs) > 0 {
+		cpus := make([]string, len(params.CPUs))
+		for i, cpu := range params.CPUs {
+			cpus[i] = strconv.Itoa(cpu)
+		}
+		opts = append(opts, "-cpu", strings.Join(cpus, ","))
+	}
+	if params.BenchMem {
+		opts = append(opts, "-benchmem")
+	}
+	opts = append(opts, params.TestFlags...)
+
+	for i, opt := range opts {
+		opts[i] = shellQuote(opt)
+	}
+	return strings.Join(opts, " ")
+}
+
+// Package patterns benchmarked by the job and the filter of each, as shell-quoted words
+func packageOpts(job *core.JobRecord) (string, string) {
+	packages := job.Parameters.Packages
+	if len(packages) == 0 {
+		packages = []core.PackageSpec{{Pattern: "."}}
+	}
+	patterns := make([]string, len(packages))
+	filters := make([]string, len(packages))
+	for i, ps := range packages {
+		filter := ps.Filter
+		if filter == "" {
+			filter = job.Parameters.TestsFilterExpr
+		}
+		patterns[i] = shellQuote(ps.Pattern)
+		filters[i] = shellQuote(filter)
+	}
+	return strings.Join(patterns, " "), strings.Join(filters, " ")
+}
+
+// Environment variables requested by the job, as NAME=value (sorted)
+func jobEnv(job *core.JobRecord) []string {
+	env := make([]string, 0, len(job.Parameters.Env))
+	for name, value := range job.Parameters.Env {
+		env = append(env, fmt.Sprintf("%s=%s", name, value))
+	}
+	sort.Strings(env)
+	return env
+}
+
+// Quote a word for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -11,6 +11,7 @@ import (
 	"regexp"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/mprimi/go-bench-away/v1/core"
 	"golang.org/x/sys/unix"
@@ -22,6 +23,8 @@ const (
 	kResultsFilename   = "results.txt"
 	kShaFilename       = "sha.txt"
 	kGoversionFilename = "go_version.txt"
+	kGoEnvFilename     = "go_env.json"
+	kResourcesFilename = core.ResourcesArtifact
 )
 
 //go:embed scripts/benchmark.sh.tmpl
@@ -35,12 +38,23 @@ type workerImpl struct {
 	c                       WorkerClient
 	jobsDir                 string
 	workerInfo              core.WorkerInfo
+	host                    hostProbe
+	preflight               *PreflightConfig
+	sampleInterval          time.Duration
 	scriptTemplate          *template.Template
+	namedScriptTemplates    map[string]*template.Template
+	scriptTemplatePaths     map[string]string
 	testSkipRun             bool
 	allowedGitRemoteRegexes []*regexp.Regexp
+	allowedEnv              map[string]bool
+	allowedTestFlags        map[string]bool
+	cacheDir                string
 }
 
-func NewWorker(c WorkerClient, jobsDir string, allowedGitRemoteExpr []string) (Worker, error) {
+// Option configures optional features of a worker
+type Option func(*workerImpl)
+
+func NewWorker(c WorkerClient, jobsDir string, allowedGitRemoteExpr []string, opts ...Option) (Worker, error) {
 	// Utsname byte arrays are filled with string termination characters,
 	// and naive string conversion preserves them.
 	bts := func(buf []byte) string {
@@ -67,7 +81,7 @@ func NewWorker(c WorkerClient, jobsDir string, allowedGitRemoteExpr []string) (W
 		}
 	}
 
-	return &workerImpl{
+	w := &workerImpl{
 		c:       c,
 		jobsDir: jobsDir,
 		workerInfo: core.WorkerInfo{
@@ -75,9 +89,29 @@ func NewWorker(c WorkerClient, jobsDir string, allowedGitRemoteExpr []string) (W
 			Uname:    fmt.Sprintf("%s_%s-%s", bts(buf.Sysname[:]), bts(buf.Release[:]), bts(buf.Machine[:])),
 			Version:  fmt.Sprintf("%s (%s)", core.Version, core.SHA),
 		},
-		scriptTemplate:          template.Must(template.New("benchmark_script").Parse(runScriptTmpl)),
+		host:                    hostProbe{root: "/"},
+		sampleInterval:          kDefaultResourceSampleInterval,
+		scriptTemplate:          template.Must(parseScriptTemplate(runScriptTmpl)),
+		namedScriptTemplates:    map[string]*template.Template{},
+		scriptTemplatePaths:     map[string]string{},
 		allowedGitRemoteRegexes: allowedGitRemoteRegexes,
-	}, nil
+		allowedEnv:              stringSet(DefaultAllowedEnv),
+		allowedTestFlags:        stringSet(DefaultAllowedTestFlags),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if err := w.loadScriptTemplates(); err != nil {
+		return nil, err
+	}
+	if w.cacheDir != "" {
+		cacheDir, err := filepath.Abs(w.cacheDir)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid cache directory: %v", err)
+		}
+		w.cacheDir = cacheDir
+	}
+	return w, nil
 }
 
 func (w *workerImpl) Run(ctx context.Context) error {
@@ -91,12 +125,19 @@ func (w *workerImpl) Run(ctx context.Context) error {
 
 func (w *workerImpl) processJob(job *core.JobRecord, revision uint64) (bool, error) {
 
+	// Parameters of a newer schema may have a meaning this worker does not know about
+	if err := job.CheckSchema(); err != nil {
+		return false, err
+	}
+
 	if job.Status != core.Submitted {
 		return false, fmt.Errorf("Cannot process job %s in status %v", job.Id, job.Status)
 	}
 
 	job.SetRunningStatus()
 	job.WorkerInfo = w.workerInfo
+	job.Host = w.host.hostInfo()
+	job.Host.Start = w.host.load()
 
 	newRevision, err := w.c.UpdateJob(job, revision)
 	if err != nil {
@@ -112,9 +153,15 @@ func (w *workerImpl) processJob(job *core.JobRecord, revision uint64) (bool, err
 		goto finalStatusUpdate
 	}
 
+	// Check the system is quiet enough, before running the job
+	if w.preflight != nil && w.preflight.enabled() {
+		job.Preflight = w.runPreflight(job)
+	}
+
 	// Run the job
 	{
 		jobTempDir, runErr := w.runJob(job)
+		job.Host.End = w.host.load()
 
 		// Update job status to final
 		if runErr != nil {
@@ -158,38 +205,42 @@ func (w *workerImpl) runJob(job *core.JobRecord) (string, error) {
 		return jobTempDir, nil
 	}
 
+	scriptTemplate, err := w.jobScriptTemplate(job)
+	if err != nil {
+		return jobTempDir, err
+	}
+
 	scriptPath := filepath.Join(jobTempDir, kScriptFilename)
 	logPath := filepath.Join(jobTempDir, kLogFilename)
 	resultsPath := filepath.Join(jobTempDir, kResultsFilename)
 	shaPath := filepath.Join(jobTempDir, kShaFilename)
 	goVersionPath := filepath.Join(jobTempDir, kGoversionFilename)
+	goEnvPath := filepath.Join(jobTempDir, kGoEnvFilename)
 
 	scriptFile, err := os.Create(scriptPath)
 	if err != nil {
 		return jobTempDir, fmt.Errorf("Failed to create script: %v", err)
 	}
 
-	scriptTemplateValues := struct {
-		JobDirPath      string
-		ResultsPath     string
-		ShaPath         string
-		GoVersionPath   string
-		GitRemote       string
-		GitRef          string
-		TestsSubDir     string
-		TestsFilterExpr string
-		Reps            string
-		MinRuntime      string
-		Timeout         string
-		GoPath          string
-		CleanupCommand  string
-	}{
+	// Checkout from a local mirror of the remote, if enabled
+	var gitMirror string
+	if w.cacheDir != "" {
+		gitMirror, err = w.updateGitMirror(job.Parameters.GitRemote)
+		if err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+		}
+	}
+
+	packages, packageFilters := packageOpts(job)
+	scriptValues := scriptTemplateValues{
 		JobDirPath:      jobTempDir,
 		ResultsPath:     resultsPath,
 		ShaPath:         shaPath,
 		GoVersionPath:   goVersionPath,
+		GoEnvPath:       goEnvPath,
 		GitRemote:       job.Parameters.GitRemote,
 		GitRef:          job.Parameters.GitRef,
+		GitMirror:       gitMirror,
 		TestsSubDir:     job.Parameters.TestsSubDir,
 		TestsFilterExpr: job.Parameters.TestsFilterExpr,
 		Reps:            fmt.Sprintf("%d", job.Parameters.Reps),
@@ -197,9 +248,14 @@ func (w *workerImpl) runJob(job *core.JobRecord) (string, error) {
 		Timeout:         fmt.Sprintf("%v", job.Parameters.Timeout),
 		GoPath:          job.Parameters.GoPath,
 		CleanupCommand:  job.Parameters.CleanupCmd,
+		ProfileOpts:     profileOpts(job, jobTempDir),
+		TestOpts:        testOpts(job),
+		Packages:        packages,
+		PackageFilters:  packageFilters,
+		Params:          job.Parameters.ScriptParams,
 	}
 
-	err = w.scriptTemplate.Execute(scriptFile, scriptTemplateValues)
+	err = scriptTemplate.Execute(scriptFile, scriptValues)
 	if err != nil {
 		return jobTempDir, fmt.Errorf("Failed to write job script: %v", err)
 	}
@@ -223,13 +279,24 @@ func (w *workerImpl) runJob(job *core.JobRecord) (string, error) {
 
 	cmd.Stdout = mw
 	cmd.Stderr = mw
+	cmd.Env = append(append(os.Environ(), w.cacheEnv()...), jobEnv(job)...)
 
 	err = cmd.Start()
 	if err != nil {
 		return jobTempDir, fmt.Errorf("Failed to launch job %s: %w", job.Id, err)
 	}
 
+	var sampler *resourceSampler
+	if w.sampleInterval > 0 {
+		sampler = w.host.startResourceSampler(cmd.Process.Pid, w.sampleInterval)
+	}
+
 	procState, waitErr := cmd.Process.Wait()
+	if sampler != nil {
+		if err := writeResourceUsage(sampler.Stop(), filepath.Join(jobTempDir, kResourcesFilename)); err != nil {
+			fmt.Printf("%v\n", err)
+		}
+	}
 	if waitErr != nil {
 		return jobTempDir, fmt.Errorf("Error waiting for termination of job %s: %s", job.Id, waitErr)
 	}
@@ -248,6 +315,10 @@ func (w *workerImpl) runJob(job *core.JobRecord) (string, error) {
 		job.GoVersion = "?"
 	}
 
+	if goEnvBytes, err := os.ReadFile(goEnvPath); err == nil && job.Host != nil {
+		job.Host.GoEnv = parseGoEnv(goEnvBytes)
+	}
+
 	if procState.ExitCode() != 0 {
 		return jobTempDir, fmt.Errorf("Non-zero exit code")
 	}
@@ -255,6 +326,18 @@ func (w *workerImpl) runJob(job *core.JobRecord) (string, error) {
 	return jobTempDir, nil
 }
 
+// Options of `go test` to write the profiles requested by the job, and the test binary, in the job directory
+func profileOpts(job *core.JobRecord, jobDirPath string) string {
+	if len(job.Parameters.Profiles) == 0 {
+		return ""
+	}
+	opts := []string{"-o", filepath.Join(jobDirPath, core.TestBinaryArtifact)}
+	for _, profile := range job.Parameters.Profiles {
+		opts = append(opts, core.ProfileFlag(profile), filepath.Join(jobDirPath, core.ProfileArtifact(profile)))
+	}
+	return strings.Join(opts, " ")
+}
+
 func (w *workerImpl) uploadArtifacts(job *core.JobRecord, jobDirPath string) error {
 
 	logPath := filepath.Join(jobDirPath, kLogFilename)
@@ -281,15 +364,62 @@ func (w *workerImpl) uploadArtifacts(job *core.JobRecord, jobDirPath string) err
 		job.Script = scriptArtifactKey
 	}
 
-	if logErr != nil || resultsErr != nil || scriptErr != nil {
+	profilesErr := w.uploadProfileArtifacts(job, jobDirPath)
+	if profilesErr != nil {
+		fmt.Printf("Profiles upload error: %v\n", profilesErr)
+	}
+
+	var resourcesErr error
+	resourcesPath := filepath.Join(jobDirPath, kResourcesFilename)
+	if _, err := os.Stat(resourcesPath); err == nil {
+		resourcesArtifactKey, err := w.c.UploadResourcesArtifact(job.Id, resourcesPath)
+		if err != nil {
+			fmt.Printf("Resources artifact upload error: %v\n", err)
+			resourcesErr = err
+		} else {
+			job.Resources = resourcesArtifactKey
+		}
+	}
+
+	if logErr != nil || resultsErr != nil || scriptErr != nil || profilesErr != nil || resourcesErr != nil {
 		return fmt.Errorf("Artifacts upload error")
 	}
 
 	return nil
 }
 
+// Upload the profiles requested by the job, and the test binary
+func (w *workerImpl) uploadProfileArtifacts(job *core.JobRecord, jobDirPath string) error {
+	if len(job.Parameters.Profiles) == 0 {
+		return nil
+	}
+
+	binaryPath := filepath.Join(jobDirPath, core.TestBinaryArtifact)
+	binaryArtifactKey, err := w.c.UploadTestBinaryArtifact(job.Id, binaryPath)
+	if err != nil {
+		return fmt.Errorf("test binary: %v", err)
+	}
+	job.TestBinary = binaryArtifactKey
+
+	for _, profile := range job.Parameters.Profiles {
+		profilePath := filepath.Join(jobDirPath, core.ProfileArtifact(profile))
+		profileArtifactKey, err := w.c.UploadProfileArtifact(job.Id, profile, profilePath)
+		if err != nil {
+			return fmt.Errorf("%s profile: %v", profile, err)
+		}
+		if err := job.SetArtifact(core.ProfileArtifact(profile), profileArtifactKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (w *workerImpl) isAllowed(job *core.JobRecord) (bool, error) {
 
+	if err := w.checkTestOptions(job); err != nil {
+		return false, err
+	}
+
 	if w.allowedGitRemoteRegexes != nil && len(w.allowedGitRemoteRegexes) > 0 {
 		for _, regex := range w.allowedGitRemoteRegexes {
 			if regex.MatchString(job.Parameters.GitRemote) {
@@ -1,9 +1,13 @@
 package worker
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,6 +19,7 @@ type mockClient struct {
 	StubUploadLogArtifact     func(string, string) (string, error)
 	StubUploadResultsArtifact func(string, string) (string, error)
 	StubUploadScriptArtifact  func(string, string) (string, error)
+	uploadedProfiles          []string
 }
 
 func (c *mockClient) UpdateJob(job *core.JobRecord, rev uint64) (uint64, error) {
@@ -30,6 +35,26 @@ func (c *mockClient) UploadScriptArtifact(jobId string, path string) (string, er
 	return c.StubUploadScriptArtifact(jobId, path)
 }
 
+func (c *mockClient) UploadProfileArtifact(jobId string, profile string, path string) (string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+	c.uploadedProfiles = append(c.uploadedProfiles, profile)
+	return "jobs/" + jobId + "/" + filepath.Base(path), nil
+}
+func (c *mockClient) UploadTestBinaryArtifact(jobId string, path string) (string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+	return "jobs/" + jobId + "/" + filepath.Base(path), nil
+}
+func (c *mockClient) UploadResourcesArtifact(jobId string, path string) (string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+	return "jobs/" + jobId + "/" + filepath.Base(path), nil
+}
+
 func (c *mockClient) DispatchJobs(ctx context.Context, handleJob func(*core.JobRecord, uint64) (bool, error)) error {
 	return nil
 }
@@ -168,3 +193,480 @@ func TestFilterDisallowedJobs(t *testing.T) {
 		)
 	}
 }
+
+func TestRefuseNewerSchema(t *testing.T) {
+
+	updates := 0
+	client := newMockClient().(*mockClient)
+	client.StubUpdateJob = func(*core.JobRecord, uint64) (uint64, error) {
+		updates++
+		return 0, nil
+	}
+
+	w, err := NewWorker(client, t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("Client init failed: %v", err)
+	}
+
+	wi := w.(*workerImpl)
+	wi.testSkipRun = true
+
+	job := core.NewJob(core.JobParameters{GitRemote: "https://github.com/mprimi/go-bench-away.git"})
+	job.SchemaVersion = core.JobSchemaVersion + 1
+
+	if _, err := wi.processJob(job, 1); !errors.Is(err, core.ErrUnsupportedSchema) {
+		t.Fatalf("Expected unsupported schema error, got: %v", err)
+	}
+	if updates != 0 || job.Status != core.Submitted {
+		t.Fatalf("Unexpected job update (%d updates, status %s)", updates, job.Status)
+	}
+}
+
+func TestUploadProfileArtifacts(t *testing.T) {
+	client := newMockClient().(*mockClient)
+	w, err := NewWorker(client, t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wi := w.(*workerImpl)
+
+	job := core.NewJob(core.JobParameters{Profiles: []string{"cpu", "mutex"}})
+	jobDir := t.TempDir()
+
+	opts := profileOpts(job, jobDir)
+	expectedOpts := "-o " + filepath.Join(jobDir, "test.bin") +
+		" -cpuprofile " + filepath.Join(jobDir, "profile_cpu.pprof") +
+		" -mutexprofile " + filepath.Join(jobDir, "profile_mutex.pprof")
+	if opts != expectedOpts {
+		t.Fatalf("Unexpected profile options: %s", opts)
+	}
+
+	// Profiles not written
+	if err := wi.uploadProfileArtifacts(job, jobDir); err == nil {
+		t.Fatalf("Expected error for missing profiles")
+	}
+
+	for _, name := range []string{"test.bin", "profile_cpu.pprof", "profile_mutex.pprof"} {
+		if err := os.WriteFile(filepath.Join(jobDir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := wi.uploadProfileArtifacts(job, jobDir); err != nil {
+		t.Fatal(err)
+	}
+	if len(job.Profiles) != 2 || job.Profiles["mutex"] != "jobs/"+job.Id+"/profile_mutex.pprof" || job.TestBinary == "" {
+		t.Fatalf("Unexpected artifacts: %v, %s", job.Profiles, job.TestBinary)
+	}
+
+	// No options or uploads if no profiles are requested
+	job = core.NewJob(core.JobParameters{})
+	if opts := profileOpts(job, jobDir); opts != "" {
+		t.Fatalf("Unexpected profile options: %s", opts)
+	}
+	client.uploadedProfiles = nil
+	if err := wi.uploadProfileArtifacts(job, jobDir); err != nil || len(client.uploadedProfiles) != 0 || job.TestBinary != "" {
+		t.Fatalf("Unexpected uploads: %v, %v", client.uploadedProfiles, err)
+	}
+}
+
+func TestHostProbe(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"proc/cpuinfo": "processor\t: 0\nvendor_id\t: GenuineIntel\nmodel name\t: Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz\n",
+		"proc/cmdline": "BOOT_IMAGE=/vmlinuz root=/dev/sda1 ro isolcpus=2-3 nohz_full=2-3 quiet\n",
+		"proc/loadavg": "0.52 0.34 0.20 1/123 4567\n",
+		"proc/meminfo": "MemTotal:       16384000 kB\nMemFree:         1024000 kB\nMemAvailable:    8192000 kB\n",
+		"sys/devices/system/cpu/cpu0/cpufreq/scaling_governor": "performance\n",
+		"sys/devices/system/cpu/intel_pstate/no_turbo":         "1\n",
+		"sys/devices/system/cpu/smt/control":                   "off\n",
+	}
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hp := hostProbe{root: root}
+	hi := hp.hostInfo()
+	if hi.CPUModel != "Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz" || hi.Governor != "performance" || hi.Turbo != "off" || hi.SMT != "off" {
+		t.Fatalf("Unexpected host info: %+v", hi)
+	}
+	if len(hi.KernelFlags) != 2 || hi.KernelFlags[0] != "isolcpus=2-3" || hi.KernelFlags[1] != "nohz_full=2-3" {
+		t.Fatalf("Unexpected kernel flags: %v", hi.KernelFlags)
+	}
+
+	load := hp.load()
+	if load.LoadAvg != [3]float64{0.52, 0.34, 0.20} || load.FreeMemory != 8192000<<10 {
+		t.Fatalf("Unexpected load: %+v", load)
+	}
+
+	// Missing files are unknown properties
+	hi = hostProbe{root: t.TempDir()}.hostInfo()
+	if hi.Governor != "" || hi.Turbo != "" || hi.KernelFlags != nil || hi.NumCPU == 0 {
+		t.Fatalf("Unexpected host info: %+v", hi)
+	}
+
+	goEnv := parseGoEnv([]byte(`{"CGO_ENABLED": "1", "GOAMD64": "", "GOARCH": "arm64"}` + "\n"))
+	if len(goEnv) != 2 || goEnv["GOARCH"] != "arm64" || goEnv["CGO_ENABLED"] != "1" {
+		t.Fatalf("Unexpected go env: %v", goEnv)
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	root := t.TempDir()
+	writeFile := func(path, content string) {
+		t.Helper()
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("proc/loadavg", "4.00 2.00 1.00 1/123 4567\n")
+	writeFile("proc/meminfo", "MemAvailable:    8192000 kB\n")
+	writeFile("proc/1234/stat", "1234 (my (busy) proc) R 1 1234 1234 0 -1 4194560 100 0 0 0 250 50 0 0 20 0 1 0 100 0 0\n")
+	writeFile("sys/devices/system/cpu/cpu0/cpufreq/scaling_governor", "powersave\n")
+
+	client := newMockClient()
+	w, err := NewWorker(client, t.TempDir(), nil, WithPreflight(PreflightConfig{
+		MaxLoadAvg:     1.0,
+		Governor:       "performance",
+		MinFreeMemory:  1 << 30,
+		Retries:        2,
+		RetryDelay:     time.Millisecond,
+		SampleInterval: time.Millisecond,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wi := w.(*workerImpl)
+	wi.host = hostProbe{root: root}
+	job := core.NewJob(core.JobParameters{})
+
+	result := wi.runPreflight(job)
+	if result.Passed() || result.Attempts != 3 || len(result.Checks) != 3 {
+		t.Fatalf("Unexpected pre-flight result: %+v", result)
+	}
+	expectedWarning := "Noisy environment: load average 4.00 (max: 1.00); CPU frequency governor 'powersave' (required: performance)"
+	if warning := result.Warning(); warning != expectedWarning {
+		t.Fatalf("Unexpected warning: %s", warning)
+	}
+
+	writeFile("proc/loadavg", "0.10 0.20 0.30 1/123 4567\n")
+	writeFile("sys/devices/system/cpu/cpu0/cpufreq/scaling_governor", "performance\n")
+	if result := wi.runPreflight(job); !result.Passed() || result.Attempts != 1 || result.Warning() != "" {
+		t.Fatalf("Unexpected pre-flight result: %+v", result)
+	}
+
+	// CPU usage of processes
+	times := wi.host.processTimes()
+	if len(times) != 1 || times[1234].name != "my (busy) proc" || times[1234].ticks != 300 {
+		t.Fatalf("Unexpected process times: %+v", times)
+	}
+	before := map[int]processTime{1: {"init", 10}, 1234: {"busy", 300}, 42: {"worker", 0}}
+	after := map[int]processTime{1: {"init", 12}, 1234: {"busy", 490}, 42: {"worker", 200}, 99: {"new", 500}}
+	heavy := heavyProcesses(before, after, 2*time.Second, 50, 42)
+	if len(heavy) != 1 || heavy[0] != "busy (1234) 95%" {
+		t.Fatalf("Unexpected heavy processes: %v", heavy)
+	}
+}
+
+func TestResourceSampler(t *testing.T) {
+	root := t.TempDir()
+	writeFile := func(path, content string) {
+		t.Helper()
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Process tree: run.sh (100) -> go test (101) -> test binary (102), and an unrelated process (200)
+	writeFile("proc/100/stat", "100 (run.sh) S 1 100 100 0 -1 4194560 100 0 0 0 10 5 20 5 20 0 1 0 100 0 100\n")
+	writeFile("proc/101/stat", "101 (go) S 100 100 100 0 -1 4194560 100 0 0 0 50 10 0 0 20 0 1 0 100 0 1000\n")
+	writeFile("proc/102/stat", "102 (pkg.test) R 101 100 100 0 -1 4194560 100 0 0 0 300 100 0 0 20 0 1 0 100 0 5000\n")
+	writeFile("proc/200/stat", "200 (other) R 1 200 200 0 -1 4194560 100 0 0 0 900 100 0 0 20 0 1 0 100 0 9000\n")
+	for _, pid := range []string{"100", "101", "102", "200"} {
+		writeFile("proc/"+pid+"/status", "Name:\tproc\nvoluntary_ctxt_switches:\t"+pid+"\nnonvoluntary_ctxt_switches:\t1\n")
+	}
+	writeFile("proc/stat", "cpu  100 0 100 700 100 0 0 0 0 0\ncpu0 100 0 100 700 100 0 0 0 0 0\n")
+
+	rs := hostProbe{root: root}.startResourceSampler(100, time.Hour)
+	writeFile("proc/stat", "cpu  400 0 200 1000 200 0 0 0 50 0\ncpu0 400 0 200 1000 200 0 0 0 50 0\n")
+	sample, ok := rs.sample()
+	if !ok {
+		t.Fatalf("Failed to sample process tree")
+	}
+	if sample.CPUTime != 5*time.Second {
+		t.Fatalf("Unexpected CPU time: %v", sample.CPUTime)
+	}
+	if pageSize := uint64(os.Getpagesize()); sample.RSS != 6100*pageSize {
+		t.Fatalf("Unexpected RSS: %d", sample.RSS)
+	}
+	if sample.VoluntaryCtxSwitches != 303 || sample.InvoluntaryCtxSwitches != 3 {
+		t.Fatalf("Unexpected context switches: %+v", sample)
+	}
+	// 800 ticks elapsed, of which 400 idle
+	if sample.HostCPU != 50 {
+		t.Fatalf("Unexpected host CPU: %v", sample.HostCPU)
+	}
+	if usage := rs.Stop(); usage.Interval != time.Hour || len(usage.Samples) != 0 {
+		t.Fatalf("Unexpected usage: %+v", usage)
+	}
+
+	// Root process terminated
+	if err := os.RemoveAll(filepath.Join(root, "proc", "100")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rs.sample(); ok {
+		t.Fatalf("Expected no sample after process terminated")
+	}
+
+	// Nothing written without samples
+	usagePath := filepath.Join(t.TempDir(), kResourcesFilename)
+	if err := writeResourceUsage(&core.ResourceUsage{}, usagePath); err != nil {
+		t.Fatal(err)
+	} else if _, err := os.Stat(usagePath); !os.IsNotExist(err) {
+		t.Fatalf("Unexpected resource usage file: %v", err)
+	}
+}
+
+func TestScriptTemplates(t *testing.T) {
+	templatesDir := t.TempDir()
+	writeTemplate := func(name, text string) string {
+		t.Helper()
+		path := filepath.Join(templatesDir, name)
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	defaultPath := writeTemplate("default.sh.tmpl", "cd {{.JobDirPath}} && go test -bench '{{.TestsFilterExpr}}'\n")
+	makePath := writeTemplate("make.sh.tmpl", "git checkout {{.GitRef}} && make {{shquote .Params.TARGET}} > {{.ResultsPath}}\n")
+
+	w, err := NewWorker(newMockClient(), t.TempDir(), nil, WithScriptTemplate(defaultPath), WithNamedScriptTemplate("make", makePath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wi := w.(*workerImpl)
+
+	render := func(job *core.JobRecord) (string, error) {
+		t.Helper()
+		tmpl, err := wi.jobScriptTemplate(job)
+		if err != nil {
+			return "", err
+		}
+		buf := bytes.Buffer{}
+		err = tmpl.Execute(&buf, scriptTemplateValues{
+			JobDirPath:      "/tmp/job",
+			ResultsPath:     "/tmp/job/results.txt",
+			GitRef:          job.Parameters.GitRef,
+			TestsFilterExpr: job.Parameters.TestsFilterExpr,
+			Params:          job.Parameters.ScriptParams,
+		})
+		return buf.String(), err
+	}
+
+	job := core.NewJob(core.JobParameters{GitRef: "v1.0", TestsFilterExpr: "BenchmarkFoo"})
+	if script, err := render(job); err != nil || script != "cd /tmp/job && go test -bench 'BenchmarkFoo'\n" {
+		t.Fatalf("Unexpected default script: %s (%v)", script, err)
+	}
+
+	job.Parameters.ScriptTemplate = "make"
+	job.Parameters.ScriptParams = map[string]string{"TARGET": "bench"}
+	if script, err := render(job); err != nil || script != "git checkout v1.0 && make 'bench' > /tmp/job/results.txt\n" {
+		t.Fatalf("Unexpected make script: %s (%v)", script, err)
+	}
+
+	// Quoted parameters are a single shell word
+	echoPath := writeTemplate("echo.sh.tmpl", "printf '%s\\n' {{shquote .Params.MESSAGE}}\n")
+	w, err = NewWorker(newMockClient(), t.TempDir(), nil, WithNamedScriptTemplate("make", makePath), WithNamedScriptTemplate("echo", echoPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wi = w.(*workerImpl)
+	message := "it's $(echo injected); `echo injected` && echo injected"
+	job.Parameters.ScriptTemplate = "echo"
+	job.Parameters.ScriptParams = map[string]string{"MESSAGE": message}
+	script, err := render(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command("bash", "-c", script).Output(); err != nil || string(out) != message+"\n" {
+		t.Fatalf("Unexpected output: %s (%v)", out, err)
+	}
+	job.Parameters.ScriptTemplate = "make"
+
+	// Parameter referenced by the template, not set by the job
+	job.Parameters.ScriptParams = nil
+	if _, err := render(job); err == nil {
+		t.Fatalf("Expected error for missing parameter")
+	}
+
+	job.Parameters.ScriptTemplate = "foo"
+	if _, err := render(job); err == nil {
+		t.Fatalf("Expected error for unknown template")
+	}
+
+	// Invalid or missing template files
+	invalidPath := writeTemplate("invalid.sh.tmpl", "{{.JobDirPath")
+	if _, err := NewWorker(newMockClient(), t.TempDir(), nil, WithNamedScriptTemplate("invalid", invalidPath)); err == nil {
+		t.Fatalf("Expected error for invalid template")
+	}
+	if _, err := NewWorker(newMockClient(), t.TempDir(), nil, WithScriptTemplate(filepath.Join(templatesDir, "missing"))); err == nil {
+		t.Fatalf("Expected error for missing template")
+	}
+}
+
+func TestTestOptions(t *testing.T) {
+	w, err := NewWorker(newMockClient(), t.TempDir(), nil, WithAllowedEnv([]string{"GOGC"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wi := w.(*workerImpl)
+
+	job := core.NewJob(core.JobParameters{
+		BuildTags: []string{"integration", "nocgo"},
+		CPUs:      []int{1, 2, 4},
+		BenchMem:  true,
+		TestFlags: []string{"-race", "-gcflags=all=-N -l", "-ldflags=-X 'main.version=1'"},
+		Env:       map[string]string{"GOGC": "off"},
+	})
+	if err := wi.checkTestOptions(job); err == nil || !strings.Contains(err.Error(), "-ldflags") {
+		t.Fatalf("Expected error for disallowed flag, got: %v", err)
+	}
+	wi.allowedTestFlags["ldflags"] = true
+	if err := wi.checkTestOptions(job); err != nil {
+		t.Fatal(err)
+	}
+	job.Parameters.Env["GOFLAGS"] = "-toolexec=foo"
+	if err := wi.checkTestOptions(job); err == nil || !strings.Contains(err.Error(), "GOFLAGS") {
+		t.Fatalf("Expected error for disallowed environment variable, got: %v", err)
+	}
+	delete(job.Parameters.Env, "GOFLAGS")
+
+	if env := jobEnv(job); len(env) != 1 || env[0] != "GOGC=off" {
+		t.Fatalf("Unexpected environment: %v", env)
+	}
+
+	// Options are split into words by the shell as expected
+	out, err := exec.Command("bash", "-c", "OPTS=("+testOpts(job)+"); printf '%s\\n' \"${OPTS[@]}\"").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "-tags\nintegration,nocgo\n-cpu\n1,2,4\n-benchmem\n-race\n-gcflags=all=-N -l\n-ldflags=-X 'main.version=1'\n"
+	if string(out) != expected {
+		t.Fatalf("Unexpected options:\n%s", out)
+	}
+	if opts := testOpts(core.NewJob(core.JobParameters{})); opts != "" {
+		t.Fatalf("Unexpected options: %s", opts)
+	}
+}
+
+func TestPackageOpts(t *testing.T) {
+	job := core.NewJob(core.JobParameters{TestsFilterExpr: "BenchmarkPublish"})
+
+	// Defaults to the package in the tests directory, with the job filter
+	if packages, filters := packageOpts(job); packages != "'.'" || filters != "'BenchmarkPublish'" {
+		t.Fatalf("Unexpected packages: %s, filters: %s", packages, filters)
+	}
+
+	// Packages without a filter use the job filter
+	job.Parameters.Packages = []core.PackageSpec{
+		{Pattern: "./server/..."},
+		{Pattern: "./jetstream", Filter: "Consume"},
+		{Pattern: "./it's", Filter: "Bench mark"},
+	}
+	packages, filters := packageOpts(job)
+
+	// Patterns and filters are split into words by the shell as expected
+	out, err := exec.Command("bash", "-c", "PACKAGES=("+packages+"); FILTERS=("+filters+"); printf '%s|%s\\n' \"${PACKAGES[0]}\" \"${FILTERS[0]}\" \"${PACKAGES[1]}\" \"${FILTERS[1]}\" \"${PACKAGES[2]}\" \"${FILTERS[2]}\"").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "./server/...|BenchmarkPublish\n./jetstream|Consume\n./it's|Bench mark\n"
+	if string(out) != expected {
+		t.Fatalf("Unexpected packages:\n%s", out)
+	}
+}
+
+func TestGitMirror(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	remote := filepath.Join(t.TempDir(), "project")
+	commit := func(msg string) string {
+		t.Helper()
+		for _, args := range [][]string{
+			{"init", "--quiet", remote},
+			{"-C", remote, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "--allow-empty", "-m", msg},
+		} {
+			if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v\n%s", args, err, out)
+			}
+		}
+		out, err := exec.Command("git", "-C", remote, "rev-parse", "HEAD").Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	cacheDir := t.TempDir()
+	w, err := NewWorker(newMockClient(), t.TempDir(), nil, WithCacheDir(cacheDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wi := w.(*workerImpl)
+
+	mirrorHas := func(mirrorPath, sha string) bool {
+		return exec.Command("git", "-C", mirrorPath, "cat-file", "-e", sha+"^{commit}").Run() == nil
+	}
+
+	// Mirror is created on first use
+	sha1 := commit("first")
+	mirrorPath, err := wi.updateGitMirror(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(mirrorPath, filepath.Join(cacheDir, kGitMirrorsDir, "project-")) {
+		t.Fatalf("Unexpected mirror path: %s", mirrorPath)
+	}
+	if !mirrorHas(mirrorPath, sha1) {
+		t.Fatalf("Mirror is missing commit %s", sha1)
+	}
+
+	// And refreshed after
+	sha2 := commit("second")
+	if refreshedPath, err := wi.updateGitMirror(remote); err != nil {
+		t.Fatal(err)
+	} else if refreshedPath != mirrorPath {
+		t.Fatalf("Mirror path changed: %s -> %s", mirrorPath, refreshedPath)
+	}
+	if !mirrorHas(mirrorPath, sha2) {
+		t.Fatalf("Mirror is missing commit %s", sha2)
+	}
+
+	// Remotes with the same name do not share a mirror
+	if wi.gitMirrorPath(remote) == wi.gitMirrorPath("https://example.com/project.git") {
+		t.Fatalf("Different remotes share a mirror")
+	}
+
+	// Unreachable remotes fail
+	if _, err := wi.updateGitMirror(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatalf("Expected error for missing remote")
+	}
+
+	if env := wi.cacheEnv(); len(env) != 1 || env[0] != "GOMODCACHE="+filepath.Join(cacheDir, kGoModCacheDir) {
+		t.Fatalf("Unexpected environment: %v", env)
+	}
+}
@@ -0,0 +1,189 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+const (
+	// Version of the archive format, incremented on incompatible changes
+	FormatVersion = 1
+	kManifestFile = "manifest.json"
+	kJobsDir      = "jobs"
+	kRecordFile   = "job.json"
+)
+
+// Manifest is the first entry of an archive, it lists the jobs and artifacts that follow
+type Manifest struct {
+	FormatVersion int
+	Created       time.Time
+	// Namespace the jobs were exported from
+	Namespace string
+	Jobs      []ManifestJob
+}
+
+type ManifestJob struct {
+	Id string
+	// Names of the artifacts included in the archive, after the job record (see core.JobRecord.Artifacts)
+	Artifacts []string
+}
+
+// Job is a job record with the content of its artifacts, by name
+type Job struct {
+	Record    *core.JobRecord
+	Artifacts map[string][]byte
+}
+
+func jobFilePath(jobId, fileName string) string {
+	return path.Join(kJobsDir, jobId, fileName)
+}
+
+// Export writes the given jobs, with all their artifacts, to a gzip-compressed tar archive
+func Export(w io.Writer, c ExportClient, namespace string, jobs []*core.JobRecord) (*Manifest, error) {
+	manifest := &Manifest{
+		FormatVersion: FormatVersion,
+		Created:       time.Now().UTC(),
+		Namespace:     namespace,
+		Jobs:          make([]ManifestJob, 0, len(jobs)),
+	}
+	for _, record := range jobs {
+		manifest.Jobs = append(manifest.Jobs, ManifestJob{Id: record.Id, Artifacts: record.ArtifactNames()})
+	}
+
+	gzw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gzw)
+
+	writeFile := func(name string, data []byte) error {
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: manifest.Created,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(kManifestFile, manifestData); err != nil {
+		return nil, err
+	}
+
+	for i, record := range jobs {
+		if err := writeFile(jobFilePath(record.Id, kRecordFile), record.Bytes()); err != nil {
+			return nil, err
+		}
+		artifacts := record.Artifacts()
+		for _, name := range manifest.Jobs[i].Artifacts {
+			buf := bytes.Buffer{}
+			if err := c.LoadArtifact(artifacts[name], &buf); err != nil {
+				return nil, fmt.Errorf("Failed to load %s of job %s: %v", name, record.Id, err)
+			}
+			if err := writeFile(jobFilePath(record.Id, name), buf.Bytes()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gzw.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// Import reads an archive created by Export, and invokes the given function for each job, in the order of the
+// manifest, once its record and all its artifacts are read. Stops at the first error returned by the function.
+func Import(r io.Reader, importJob func(*Job) error) (*Manifest, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid archive: %v", err)
+	}
+	defer gzr.Close()
+	tr := tar.NewReader(gzr)
+
+	readFile := func() (string, []byte, error) {
+		header, err := tr.Next()
+		if err != nil {
+			return "", nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			return "", nil, fmt.Errorf("Unexpected archive entry: %s", header.Name)
+		}
+		data, err := io.ReadAll(tr)
+		return header.Name, data, err
+	}
+
+	name, data, err := readFile()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid archive: %v", err)
+	} else if name != kManifestFile {
+		return nil, fmt.Errorf("Invalid archive: first entry is %s, expected %s", name, kManifestFile)
+	}
+
+	manifest := &Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("Invalid manifest: %v", err)
+	} else if manifest.FormatVersion != FormatVersion {
+		return nil, fmt.Errorf("Unsupported archive format version: %d (expected: %d)", manifest.FormatVersion, FormatVersion)
+	}
+
+	for _, entry := range manifest.Jobs {
+		job := &Job{Artifacts: map[string][]byte{}}
+		expectedFiles := append([]string{kRecordFile}, entry.Artifacts...)
+		for _, fileName := range expectedFiles {
+			name, data, err := readFile()
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("Truncated archive, missing %s", jobFilePath(entry.Id, fileName))
+			} else if err != nil {
+				return nil, fmt.Errorf("Invalid archive: %v", err)
+			} else if name != jobFilePath(entry.Id, fileName) {
+				return nil, fmt.Errorf("Unexpected archive entry: %s (expected: %s)", name, jobFilePath(entry.Id, fileName))
+			}
+
+			if fileName == kRecordFile {
+				job.Record, err = core.LoadJob(data)
+				if err != nil {
+					return nil, fmt.Errorf("Invalid job record %s: %v", name, err)
+				} else if job.Record.Id != entry.Id {
+					return nil, fmt.Errorf("Invalid job record %s: ID %s", name, job.Record.Id)
+				}
+				continue
+			}
+
+			if !core.IsArtifactName(fileName) {
+				return nil, fmt.Errorf("Unknown artifact: %s", name)
+			}
+			job.Artifacts[fileName] = data
+		}
+
+		if err := importJob(job); err != nil {
+			return nil, err
+		}
+	}
+
+	if name, _, err := readFile(); err == nil {
+		return nil, fmt.Errorf("Unexpected archive entry: %s", name)
+	} else if !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("Invalid archive: %v", err)
+	}
+
+	return manifest, nil
+}
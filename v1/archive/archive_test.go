@@ -0,0 +1,179 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+type mockClient struct {
+	artifacts map[string]string
+}
+
+func (c *mockClient) LoadArtifact(key string, w io.Writer) error {
+	data, found := c.artifacts[key]
+	if !found {
+		return fmt.Errorf("artifact not found: %s", key)
+	}
+	_, err := io.WriteString(w, data)
+	return err
+}
+
+func TestExportImport(t *testing.T) {
+	c := &mockClient{artifacts: map[string]string{}}
+
+	completedJob := core.NewJob(core.JobParameters{GitRef: "main", Group: "v2.10"})
+	completedJob.SetRunningStatus()
+	completedJob.SetFinalStatus(core.Succeeded)
+	completedJob.Log, completedJob.Results, completedJob.Script = "log-1", "results-1", "script-1"
+	completedJob.Profiles = map[string]string{"cpu": "cpu-1"}
+	c.artifacts["log-1"] = "log"
+	c.artifacts["cpu-1"] = "profile"
+	c.artifacts["results-1"] = "BenchmarkFoo 100 10 ns/op\n"
+	c.artifacts["script-1"] = "#!/bin/sh\n"
+
+	// Log and script garbage collected
+	collectedJob := core.NewJob(core.JobParameters{GitRef: "dev"})
+	collectedJob.SetRunningStatus()
+	collectedJob.SetFinalStatus(core.Succeeded)
+	collectedJob.Results = "results-2"
+	c.artifacts["results-2"] = "BenchmarkFoo 100 20 ns/op\n"
+
+	submittedJob := core.NewJob(core.JobParameters{GitRef: "feature"})
+
+	jobs := []*core.JobRecord{completedJob, collectedJob, submittedJob}
+
+	buf := bytes.Buffer{}
+	if _, err := Export(&buf, c, "test", jobs); err != nil {
+		t.Fatal(err)
+	}
+	exported := buf.Bytes()
+
+	imported := []*Job{}
+	manifest, err := Import(bytes.NewReader(exported), func(job *Job) error {
+		imported = append(imported, job)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Namespace != "test" || len(manifest.Jobs) != 3 || len(imported) != 3 {
+		t.Fatalf("Unexpected manifest: %+v", manifest)
+	}
+
+	for i, job := range imported {
+		if job.Record.Id != jobs[i].Id || !job.Record.Created.Equal(jobs[i].Created) {
+			t.Fatalf("Unexpected job record: %+v", job.Record)
+		}
+	}
+	if a := imported[0].Artifacts; len(a) != 4 || string(a[core.LogArtifact]) != "log" || string(a[core.ScriptArtifact]) != "#!/bin/sh\n" ||
+		string(a[core.ResultsArtifact]) != c.artifacts["results-1"] || string(a[core.ProfileArtifact("cpu")]) != "profile" {
+		t.Fatalf("Unexpected artifacts: %+v", a)
+	}
+	if a := imported[1].Artifacts; len(a) != 1 || string(a[core.ResultsArtifact]) != c.artifacts["results-2"] {
+		t.Fatalf("Unexpected artifacts: %+v", a)
+	}
+	if len(imported[2].Artifacts) != 0 || imported[2].Record.Status != core.Submitted {
+		t.Fatalf("Unexpected submitted job: %+v", imported[2])
+	}
+
+	// Error of the import function stops the import
+	count := 0
+	if _, err := Import(bytes.NewReader(exported), func(*Job) error {
+		count++
+		return fmt.Errorf("test error")
+	}); err == nil || count != 1 {
+		t.Fatalf("Expected import to stop at first error (%d jobs imported)", count)
+	}
+
+	// Missing artifact fails the export
+	delete(c.artifacts, "script-1")
+	if _, err := Export(io.Discard, c, "test", jobs); err == nil {
+		t.Fatalf("Expected error for missing artifact")
+	}
+}
+
+func TestImportInvalidArchive(t *testing.T) {
+	c := &mockClient{artifacts: map[string]string{"results": "BenchmarkFoo 100 10 ns/op\n"}}
+	job := core.NewJob(core.JobParameters{})
+	job.Results = "results"
+
+	buf := bytes.Buffer{}
+	if _, err := Export(&buf, c, "test", []*core.JobRecord{job}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rewrite an archive, transforming its entries
+	rewrite := func(transform func(name string, data []byte) (string, []byte)) []byte {
+		t.Helper()
+		gzr, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr := tar.NewReader(gzr)
+		out := bytes.Buffer{}
+		gzw := gzip.NewWriter(&out)
+		tw := tar.NewWriter(gzw)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			data, _ := io.ReadAll(tr)
+			name, data := transform(header.Name, data)
+			if name == "" {
+				continue
+			}
+			tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()})
+			tw.Write(data)
+		}
+		tw.Close()
+		gzw.Close()
+		return out.Bytes()
+	}
+
+	testCases := map[string][]byte{
+		"not gzip": []byte("foo"),
+		"truncated": rewrite(func(name string, data []byte) (string, []byte) {
+			if strings.HasSuffix(name, core.ResultsArtifact) {
+				return "", nil
+			}
+			return name, data
+		}),
+		"unsupported version": rewrite(func(name string, data []byte) (string, []byte) {
+			if name == kManifestFile {
+				data = bytes.Replace(data, []byte(`"FormatVersion": 1`), []byte(`"FormatVersion": 99`), 1)
+			}
+			return name, data
+		}),
+		"mismatched record": rewrite(func(name string, data []byte) (string, []byte) {
+			if strings.HasSuffix(name, kRecordFile) {
+				data = core.NewJob(core.JobParameters{}).Bytes()
+			}
+			return name, data
+		}),
+		"unexpected entry": rewrite(func(name string, data []byte) (string, []byte) {
+			if strings.HasSuffix(name, core.ResultsArtifact) {
+				name = jobFilePath(job.Id, "../../etc/passwd")
+			}
+			return name, data
+		}),
+	}
+
+	for name, data := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Import(bytes.NewReader(data), func(*Job) error { return nil }); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package archive
+
+import (
+	"io"
+)
+
+type ExportClient interface {
+	LoadArtifact(key string, w io.Writer) error
+}
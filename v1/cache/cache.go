@@ -0,0 +1,267 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+const (
+	kDefaultDirName = "go-bench-away"
+	kObjectsDir     = "objects"
+	kJobsDir        = "jobs"
+	// Format of object digests, same as NATS object store
+	kDigestPrefix = "SHA-256="
+	// Default size limit of the cache
+	DefaultMaxSize = 1 << 30
+)
+
+var errNotCached = errors.New("not in cache")
+
+// Cache is an on-disk cache of completed job records and their results artifacts.
+// Artifacts are content-addressed (by SHA-256 digest) and verified when read.
+type Cache struct {
+	dir     string
+	maxSize int64
+}
+
+// Cached job record, with its revision in the source (0 if unknown) and the digest of its results artifact (if it was
+// loaded)
+type cachedJob struct {
+	Record        *core.JobRecord
+	Revision      uint64 `json:",omitempty"`
+	ResultsDigest string `json:",omitempty"`
+}
+
+// DefaultDir returns the default location of the cache, in the user cache directory (e.g. ~/.cache/go-bench-away)
+func DefaultDir() (string, error) {
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userCacheDir, kDefaultDirName), nil
+}
+
+// Open opens (creating it if necessary) the cache in the given directory.
+// maxSize is the limit enforced by Trim, in bytes.
+func Open(dir string, maxSize int64) (*Cache, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("Invalid cache size limit: %d", maxSize)
+	}
+	for _, subDir := range []string{kObjectsDir, kJobsDir} {
+		if err := os.MkdirAll(filepath.Join(dir, subDir), 0755); err != nil {
+			return nil, fmt.Errorf("Failed to create cache directory: %v", err)
+		}
+	}
+	return &Cache{dir: dir, maxSize: maxSize}, nil
+}
+
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+func (c *Cache) jobPath(jobId string) string {
+	return filepath.Join(c.dir, kJobsDir, jobId+".json")
+}
+
+// Objects are stored in a file named after the hex-encoded hash
+func (c *Cache) objectPath(digest string) (string, error) {
+	sum, err := decodeDigest(digest)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(c.dir, kObjectsDir, hex.EncodeToString(sum)), nil
+}
+
+// Digest of some content, in the same format as NATS object store
+func computeDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return kDigestPrefix + base64.URLEncoding.EncodeToString(sum[:])
+}
+
+func decodeDigest(digest string) ([]byte, error) {
+	if !strings.HasPrefix(digest, kDigestPrefix) {
+		return nil, fmt.Errorf("Unsupported digest: '%s'", digest)
+	}
+	sum, err := base64.URLEncoding.DecodeString(strings.TrimPrefix(digest, kDigestPrefix))
+	if err != nil || len(sum) != sha256.Size {
+		return nil, fmt.Errorf("Invalid digest: '%s'", digest)
+	}
+	return sum, nil
+}
+
+// Write a file atomically, so that concurrent readers never see partial content
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Read a cached file, and mark it as recently used
+func readCachedFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errNotCached
+	} else if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return data, nil
+}
+
+func (c *Cache) loadJob(jobId string) (*cachedJob, error) {
+	data, err := readCachedFile(c.jobPath(jobId))
+	if err != nil {
+		return nil, err
+	}
+	entry := &cachedJob{}
+	if err := json.Unmarshal(data, entry); err != nil || entry.Record == nil || entry.Record.Id != jobId {
+		// Corrupt entry, discard it
+		os.Remove(c.jobPath(jobId))
+		return nil, errNotCached
+	}
+	return entry, nil
+}
+
+func (c *Cache) storeJob(entry *cachedJob) error {
+	if !entry.Record.IsCompleted() {
+		return fmt.Errorf("Job %s is not completed", entry.Record.Id)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.jobPath(entry.Record.Id), data)
+}
+
+// Load an object, verifying its content matches the digest
+func (c *Cache) loadObject(digest string) ([]byte, error) {
+	path, err := c.objectPath(digest)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readCachedFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if computeDigest(data) != digest {
+		// Corrupt object, discard it
+		os.Remove(path)
+		return nil, errNotCached
+	}
+	return data, nil
+}
+
+// Store an object, returns its digest
+func (c *Cache) storeObject(data []byte) (string, error) {
+	digest := computeDigest(data)
+	path, err := c.objectPath(digest)
+	if err != nil {
+		return "", err
+	}
+	return digest, writeFileAtomic(path, data)
+}
+
+type cachedFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (c *Cache) listFiles() ([]cachedFile, error) {
+	files := []cachedFile{}
+	for _, subDir := range []string{kObjectsDir, kJobsDir} {
+		entries, err := os.ReadDir(filepath.Join(c.dir, subDir))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if errors.Is(err, fs.ErrNotExist) {
+				// Removed concurrently
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			if info.Mode().IsRegular() {
+				files = append(files, cachedFile{
+					path:    filepath.Join(c.dir, subDir, entry.Name()),
+					size:    info.Size(),
+					modTime: info.ModTime(),
+				})
+			}
+		}
+	}
+	return files, nil
+}
+
+// Usage returns the number of files in the cache and their total size
+func (c *Cache) Usage() (int, int64, error) {
+	files, err := c.listFiles()
+	if err != nil {
+		return 0, 0, err
+	}
+	var size int64
+	for _, file := range files {
+		size += file.size
+	}
+	return len(files), size, nil
+}
+
+// Prune removes the least recently used files until the cache size is at most maxSize bytes (0 empties the cache).
+// Returns the number of files removed and their total size.
+func (c *Cache) Prune(maxSize int64) (int, int64, error) {
+	files, err := c.listFiles()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var size int64
+	for _, file := range files {
+		size += file.size
+	}
+
+	// Oldest first
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+
+	removed, freed := 0, int64(0)
+	for _, file := range files {
+		if size <= maxSize {
+			break
+		}
+		if err := os.Remove(file.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, freed, err
+		}
+		size -= file.size
+		removed++
+		freed += file.size
+	}
+	return removed, freed, nil
+}
+
+// Trim prunes the cache to its size limit
+func (c *Cache) Trim() (int, int64, error) {
+	return c.Prune(c.maxSize)
+}
@@ -0,0 +1,238 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mprimi/go-bench-away/v1/client"
+	"github.com/mprimi/go-bench-away/v1/core"
+	server "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+)
+
+type mockSource struct {
+	jobs       map[string]*core.JobRecord
+	revisions  map[string]uint64
+	results    map[string]string
+	jobLoads   int
+	fetches    int
+	badDigests bool
+}
+
+func (s *mockSource) LoadJob(jobId string) (*core.JobRecord, uint64, error) {
+	s.jobLoads++
+	job, found := s.jobs[jobId]
+	if !found {
+		return nil, 0, fmt.Errorf("%w: '%s'", core.ErrJobNotFound, jobId)
+	}
+	return job, s.revisions[jobId] + 1, nil
+}
+
+func (s *mockSource) LoadResultsArtifact(job *core.JobRecord, w io.Writer) error {
+	s.fetches++
+	_, err := io.WriteString(w, s.results[job.Id])
+	return err
+}
+
+func (s *mockSource) ResultsArtifactDigest(job *core.JobRecord) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(s.results[job.Id]))
+	if s.badDigests {
+		h.Write([]byte("!"))
+	}
+	return nats.GetObjectDigestValue(h), nil
+}
+
+func (s *mockSource) SearchJobs(q core.JobsQuery) ([]*core.JobRecord, int, error) {
+	return nil, 0, fmt.Errorf("not implemented")
+}
+
+func (s *mockSource) LoadProfileArtifact(job *core.JobRecord, profile string, w io.Writer) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (s *mockSource) LoadResourcesArtifact(job *core.JobRecord, w io.Writer) error {
+	return fmt.Errorf("not implemented")
+}
+
+func loadResults(t *testing.T, c *CachedClient, job *core.JobRecord) string {
+	t.Helper()
+	buf := bytes.Buffer{}
+	if err := c.LoadResultsArtifact(job, &buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestCachedClient(t *testing.T) {
+	cache, err := Open(t.TempDir(), DefaultMaxSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	completedJob := core.NewJob(core.JobParameters{})
+	completedJob.SetFinalStatus(core.Succeeded)
+	runningJob := core.NewJob(core.JobParameters{})
+	runningJob.SetRunningStatus()
+
+	source := &mockSource{
+		jobs: map[string]*core.JobRecord{
+			completedJob.Id: completedJob,
+			runningJob.Id:   runningJob,
+		},
+		results: map[string]string{
+			completedJob.Id: "BenchmarkFoo 100 10 ns/op\n",
+			runningJob.Id:   "BenchmarkBar 100 20 ns/op\n",
+		},
+	}
+	source.revisions = map[string]uint64{}
+	c := NewCachedClient(source, cache)
+
+	// Records are always loaded from the source, results of completed jobs are fetched once
+	for i := 0; i < 2; i++ {
+		if job, _, err := c.LoadJob(completedJob.Id); err != nil || job.Id != completedJob.Id {
+			t.Fatalf("Failed to load job: %v", err)
+		}
+		if results := loadResults(t, c, completedJob); results != source.results[completedJob.Id] {
+			t.Fatalf("Unexpected results: %s", results)
+		}
+	}
+	if source.jobLoads != 2 || source.fetches != 1 {
+		t.Fatalf("Unexpected source requests: %d loads, %d fetches", source.jobLoads, source.fetches)
+	}
+
+	// Changes to completed records (e.g. pinning) replace the cached copy
+	pinnedJob := *completedJob
+	pinnedJob.Pinned = true
+	source.jobs[completedJob.Id] = &pinnedJob
+	source.revisions[completedJob.Id]++
+	if job, revision, err := c.LoadJob(completedJob.Id); err != nil || !job.Pinned || revision != 2 {
+		t.Fatalf("Unexpected job: %+v, revision: %d, error: %v", job, revision, err)
+	}
+	if entry, err := cache.loadJob(completedJob.Id); err != nil || !entry.Record.Pinned || entry.Revision != 2 || entry.ResultsDigest == "" {
+		t.Fatalf("Unexpected cache entry: %+v, error: %v", entry, err)
+	}
+
+	// Incomplete jobs are not cached
+	for i := 0; i < 2; i++ {
+		if _, _, err := c.LoadJob(runningJob.Id); err != nil {
+			t.Fatal(err)
+		}
+		loadResults(t, c, runningJob)
+	}
+	if source.jobLoads != 5 || source.fetches != 3 {
+		t.Fatalf("Unexpected source requests: %d loads, %d fetches", source.jobLoads, source.fetches)
+	}
+
+	// Offline, only cached jobs are available
+	offlineClient := NewCachedClient(nil, cache)
+	if results := loadResults(t, offlineClient, completedJob); results != source.results[completedJob.Id] {
+		t.Fatalf("Unexpected results: %s", results)
+	}
+	if _, _, err := offlineClient.LoadJob(runningJob.Id); !errors.Is(err, core.ErrJobNotFound) {
+		t.Fatalf("Expected job not found offline, got: %v", err)
+	}
+	if _, _, err := offlineClient.SearchJobs(core.JobsQuery{}); err == nil {
+		t.Fatalf("Expected error for query offline")
+	}
+
+	// Corrupt cached object is discarded and downloaded again
+	digest, _ := source.ResultsArtifactDigest(completedJob)
+	objectPath, _ := cache.objectPath(digest)
+	if err := os.WriteFile(objectPath, []byte("corrupt"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if results := loadResults(t, c, completedJob); results != source.results[completedJob.Id] || source.fetches != 4 {
+		t.Fatalf("Unexpected results: %s (fetches: %d)", results, source.fetches)
+	}
+
+	// Downloaded content not matching the digest
+	source.badDigests = true
+	if err := c.LoadResultsArtifact(completedJob, io.Discard); err == nil {
+		t.Fatalf("Expected error for digest mismatch")
+	}
+}
+
+func TestCachePrune(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := Open(dir, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := cache.storeObject(bytes.Repeat([]byte{byte(i)}, 50)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if files, size, err := cache.Usage(); err != nil || files != 5 || size != 250 {
+		t.Fatalf("Unexpected usage: %d files, %d bytes, %v", files, size, err)
+	}
+
+	if removed, freed, err := cache.Trim(); err != nil || removed != 3 || freed != 150 {
+		t.Fatalf("Unexpected trim: %d files, %d bytes, %v", removed, freed, err)
+	}
+
+	if removed, _, err := cache.Prune(0); err != nil || removed != 2 {
+		t.Fatalf("Unexpected prune: %d files, %v", removed, err)
+	}
+
+	if entries, err := os.ReadDir(filepath.Join(dir, kObjectsDir)); err != nil || len(entries) != 0 {
+		t.Fatalf("Expected empty cache: %v", err)
+	}
+
+	if _, err := Open(dir, 0); err == nil {
+		t.Fatalf("Expected error for invalid size limit")
+	}
+}
+
+// Digests computed by the cache must match those of the artifacts store
+func TestResultsArtifactDigest(t *testing.T) {
+	opts := server.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+
+	s := server.RunServer(&opts)
+	defer s.Shutdown()
+
+	c, err := client.NewClient(s.ClientURL(), "", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.CreateArtifactsStore(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = client.NewClient(s.ClientURL(), "", "test", client.InitArtifactsStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	results := []byte("BenchmarkFoo 100 10 ns/op\n")
+	resultsPath := filepath.Join(t.TempDir(), "results.txt")
+	if err := os.WriteFile(resultsPath, results, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	job := core.NewJob(core.JobParameters{})
+	job.Results, err = c.UploadResultsArtifact(job.Id, resultsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if digest, err := c.ResultsArtifactDigest(job); err != nil {
+		t.Fatal(err)
+	} else if digest != computeDigest(results) {
+		t.Fatalf("Digest mismatch: %s vs. %s", digest, computeDigest(results))
+	}
+}
@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+// Source of job records and results artifacts (i.e. a client.Client)
+type Source interface {
+	LoadJob(jobId string) (*core.JobRecord, uint64, error)
+	LoadResultsArtifact(job *core.JobRecord, w io.Writer) error
+	ResultsArtifactDigest(job *core.JobRecord) (string, error)
+	SearchJobs(q core.JobsQuery) ([]*core.JobRecord, int, error)
+	LoadProfileArtifact(job *core.JobRecord, profile string, w io.Writer) error
+	LoadResourcesArtifact(job *core.JobRecord, w io.Writer) error
+}
+
+// CachedClient loads job records and results artifacts through the cache.
+// Only completed jobs are cached. Their records can still change (e.g. pinned, or artifacts deleted), so when online
+// the record is always loaded from the source, and the cached copy is only used offline.
+type CachedClient struct {
+	source Source
+	cache  *Cache
+}
+
+// NewCachedClient creates a client that loads from the source on cache miss.
+// If source is nil, the client is offline: it loads from cache only.
+func NewCachedClient(source Source, cache *Cache) *CachedClient {
+	return &CachedClient{
+		source: source,
+		cache:  cache,
+	}
+}
+
+func (cc *CachedClient) offline() bool {
+	return cc.source == nil
+}
+
+func (cc *CachedClient) logWarn(format string, args ...interface{}) {
+	fmt.Printf("[warning] cache: "+format+"\n", args...)
+}
+
+// LoadJob loads a job from the source, updating the cached copy if its revision changed.
+// Offline, loads the job from the cache, cached records have revision 0.
+func (cc *CachedClient) LoadJob(jobId string) (*core.JobRecord, uint64, error) {
+	entry, err := cc.cache.loadJob(jobId)
+	if err != nil && err != errNotCached {
+		return nil, 0, err
+	}
+
+	if cc.offline() {
+		if entry == nil {
+			return nil, 0, fmt.Errorf("%w: '%s' (not in cache, offline)", core.ErrJobNotFound, jobId)
+		}
+		return entry.Record, 0, nil
+	}
+
+	job, revision, err := cc.source.LoadJob(jobId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if job.IsCompleted() && (entry == nil || entry.Revision != revision) {
+		// Results do not change once a job is completed, keep their digest
+		updatedEntry := &cachedJob{Record: job, Revision: revision}
+		if entry != nil {
+			updatedEntry.ResultsDigest = entry.ResultsDigest
+		}
+		if err := cc.cache.storeJob(updatedEntry); err != nil {
+			cc.logWarn("Failed to cache job %s: %v", jobId, err)
+		}
+	}
+	return job, revision, nil
+}
+
+// LoadResultsArtifact loads the results of a job from the cache, if the cached copy matches the digest of the
+// artifact in the source (or unconditionally, if offline). Otherwise, loads it from the source and caches it.
+func (cc *CachedClient) LoadResultsArtifact(job *core.JobRecord, w io.Writer) error {
+	if !job.IsCompleted() {
+		if cc.offline() {
+			return fmt.Errorf("Job %s is not completed, results are not cached", job.Id)
+		}
+		return cc.source.LoadResultsArtifact(job, w)
+	}
+
+	var digest string
+	if cc.offline() {
+		entry, err := cc.cache.loadJob(job.Id)
+		if err == errNotCached || (err == nil && entry.ResultsDigest == "") {
+			return fmt.Errorf("Results of job %s not in cache (offline)", job.Id)
+		} else if err != nil {
+			return err
+		}
+		digest = entry.ResultsDigest
+	} else {
+		var err error
+		digest, err = cc.source.ResultsArtifactDigest(job)
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := cc.cache.loadObject(digest)
+	if err == errNotCached && !cc.offline() {
+		data, err = cc.fetchResults(job, digest)
+	} else if err == errNotCached {
+		err = fmt.Errorf("Results of job %s not in cache (offline)", job.Id)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// Download the results of a job from the source and cache them
+func (cc *CachedClient) fetchResults(job *core.JobRecord, expectedDigest string) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := cc.source.LoadResultsArtifact(job, &buf); err != nil {
+		return nil, err
+	}
+	data := buf.Bytes()
+
+	digest, err := cc.cache.storeObject(data)
+	if err != nil {
+		cc.logWarn("Failed to cache results of job %s: %v", job.Id, err)
+		return data, nil
+	} else if digest != expectedDigest {
+		return nil, fmt.Errorf("Results of job %s do not match digest %s", job.Id, expectedDigest)
+	}
+
+	// Keep the cached record (and its revision) if present, the given one may be older
+	entry, err := cc.cache.loadJob(job.Id)
+	if err != nil {
+		entry = &cachedJob{Record: job}
+	}
+	entry.ResultsDigest = digest
+	if err := cc.cache.storeJob(entry); err != nil {
+		cc.logWarn("Failed to cache job %s: %v", job.Id, err)
+	}
+	return data, nil
+}
+
+// SearchJobs searches jobs in the source, it is not available offline
+func (cc *CachedClient) SearchJobs(q core.JobsQuery) ([]*core.JobRecord, int, error) {
+	if cc.offline() {
+		return nil, 0, fmt.Errorf("Job queries are not available offline")
+	}
+	return cc.source.SearchJobs(q)
+}
+
+// LoadProfileArtifact loads a profile of a job from the source, profiles are not cached
+func (cc *CachedClient) LoadProfileArtifact(job *core.JobRecord, profile string, w io.Writer) error {
+	if cc.offline() {
+		return fmt.Errorf("Profiles are not available offline")
+	}
+	return cc.source.LoadProfileArtifact(job, profile, w)
+}
+
+// LoadResourcesArtifact loads the resource usage samples of a job from the source, they are not cached
+func (cc *CachedClient) LoadResourcesArtifact(job *core.JobRecord, w io.Writer) error {
+	if cc.offline() {
+		return fmt.Errorf("Resource usage is not available offline")
+	}
+	return cc.source.LoadResourcesArtifact(job, w)
+}
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -18,7 +20,7 @@ func (c *Client) LoadJob(jobId string) (*core.JobRecord, uint64, error) {
 
 	kve, err := c.jobsRepository.Get(jobRecordKey)
 	if err == nats.ErrKeyNotFound {
-		return nil, 0, fmt.Errorf("Job not found: '%s'", jobId)
+		return nil, 0, fmt.Errorf("%w: '%s'", core.ErrJobNotFound, jobId)
 	} else if err != nil {
 		return nil, 0, err
 	}
@@ -35,6 +37,18 @@ func (c *Client) LoadJob(jobId string) (*core.JobRecord, uint64, error) {
 	return job, revision, nil
 }
 
+// LoadJobs loads the given jobs concurrently.
+// Returns the jobs (nil if failed to load) and the error of each, in the same order as the IDs.
+func (c *Client) LoadJobs(ctx context.Context, jobIds []string) ([]*core.JobRecord, core.ParallelErrors) {
+	jobs := make([]*core.JobRecord, len(jobIds))
+	errs := core.ForEachParallel(ctx, len(jobIds), c.options.loadConcurrency, func(_ context.Context, i int) error {
+		job, _, err := c.LoadJob(jobIds[i])
+		jobs[i] = job
+		return err
+	})
+	return jobs, errs
+}
+
 func (c *Client) DownloadLogArtifact(job *core.JobRecord, filePath string) error {
 	if job.Log == "" {
 		return fmt.Errorf("Job %s has no log artifact", job.Id)
@@ -56,6 +70,28 @@ func (c *Client) DownloadScriptArtifact(job *core.JobRecord, filePath string) er
 	return c.artifactsStore.GetFile(job.Script, filePath)
 }
 
+func (c *Client) DownloadProfileArtifact(job *core.JobRecord, profile, filePath string) error {
+	key, found := job.Profiles[profile]
+	if !found {
+		return fmt.Errorf("Job %s has no %s profile", job.Id, profile)
+	}
+	return c.artifactsStore.GetFile(key, filePath)
+}
+
+func (c *Client) DownloadTestBinaryArtifact(job *core.JobRecord, filePath string) error {
+	if job.TestBinary == "" {
+		return fmt.Errorf("Job %s has no test binary artifact", job.Id)
+	}
+	return c.artifactsStore.GetFile(job.TestBinary, filePath)
+}
+
+func (c *Client) DownloadResourcesArtifact(job *core.JobRecord, filePath string) error {
+	if job.Resources == "" {
+		return fmt.Errorf("Job %s has no resources artifact", job.Id)
+	}
+	return c.artifactsStore.GetFile(job.Resources, filePath)
+}
+
 func (c *Client) readArtifact(key string, w io.Writer) error {
 	if key == "" {
 		return fmt.Errorf("missing artifact")
@@ -76,6 +112,40 @@ func (c *Client) LoadResultsArtifact(job *core.JobRecord, writer io.Writer) erro
 	return c.readArtifact(job.Results, writer)
 }
 
+// ResultsArtifactDigest returns the digest of a job results artifact (format: SHA-256=<base64>)
+func (c *Client) ResultsArtifactDigest(job *core.JobRecord) (string, error) {
+	if job.Results == "" {
+		return "", fmt.Errorf("Job %s has no results artifact", job.Id)
+	}
+	info, err := c.artifactsStore.GetInfo(job.Results)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get results artifact of job %s: %v", job.Id, err)
+	}
+	return info.Digest, nil
+}
+
+// LoadArtifact loads an artifact by key (see core.JobRecord.Artifacts)
+func (c *Client) LoadArtifact(key string, writer io.Writer) error {
+	return c.readArtifact(key, writer)
+}
+
+// LoadProfileArtifact loads a profile of the given type collected while running the job
+func (c *Client) LoadProfileArtifact(job *core.JobRecord, profile string, writer io.Writer) error {
+	key, found := job.Profiles[profile]
+	if !found {
+		return fmt.Errorf("Job %s has no %s profile", job.Id, profile)
+	}
+	return c.readArtifact(key, writer)
+}
+
+func (c *Client) LoadTestBinaryArtifact(job *core.JobRecord, writer io.Writer) error {
+	return c.readArtifact(job.TestBinary, writer)
+}
+
+func (c *Client) LoadResourcesArtifact(job *core.JobRecord, writer io.Writer) error {
+	return c.readArtifact(job.Resources, writer)
+}
+
 func (c *Client) LoadLogArtifact(job *core.JobRecord, writer io.Writer) error {
 	return c.readArtifact(job.Log, writer)
 }
@@ -105,6 +175,27 @@ func (c *Client) UploadScriptArtifact(jobId, scriptFilePath string) (string, err
 	return key, err
 }
 
+func (c *Client) UploadProfileArtifact(jobId, profile, profileFilePath string) (string, error) {
+	key := fmt.Sprintf(kArtifactKeyTmpl, jobId, core.ProfileArtifact(profile))
+	description := fmt.Sprintf("Job %s %s profile", jobId, profile)
+	err := c.uploadArtifact(key, description, profileFilePath)
+	return key, err
+}
+
+func (c *Client) UploadTestBinaryArtifact(jobId, binaryFilePath string) (string, error) {
+	key := fmt.Sprintf(kArtifactKeyTmpl, jobId, core.TestBinaryArtifact)
+	description := fmt.Sprintf("Job %s test binary", jobId)
+	err := c.uploadArtifact(key, description, binaryFilePath)
+	return key, err
+}
+
+func (c *Client) UploadResourcesArtifact(jobId, resourcesFilePath string) (string, error) {
+	key := fmt.Sprintf(kArtifactKeyTmpl, jobId, core.ResourcesArtifact)
+	description := fmt.Sprintf("Job %s resource usage", jobId)
+	err := c.uploadArtifact(key, description, resourcesFilePath)
+	return key, err
+}
+
 func (c *Client) uploadArtifact(key, description, filePath string) error {
 	objMeta := nats.ObjectMeta{
 		Name:        key,
@@ -120,3 +211,12 @@ func (c *Client) uploadArtifact(key, description, filePath string) error {
 	_, putErr := c.artifactsStore.Put(&objMeta, file)
 	return putErr
 }
+
+func (c *Client) putArtifact(key, description string, data []byte) error {
+	objMeta := nats.ObjectMeta{
+		Name:        key,
+		Description: description,
+	}
+	_, err := c.artifactsStore.Put(&objMeta, bytes.NewReader(data))
+	return err
+}
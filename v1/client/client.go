@@ -2,6 +2,7 @@ package client
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/nats-io/nats.go"
 )
@@ -13,6 +14,13 @@ const (
 	logArtifactKeyTemplate     = "jobs/%s/log.txt"
 	resultsArtifactKeyTemplate = "jobs/%s/results.txt"
 	scriptArtifactKeyTemplate  = "jobs/%s/run.sh"
+	kArtifactKeyTmpl           = "jobs/%s/%s"            // substitute Job ID and artifact name
+	kPinnedGroupKeyTmpl        = "pinned-groups/%s"      // substitute encoded group name
+	kNamespaceSchemaKey        = "namespace/schema"      // NamespaceSchema record
+	kScheduleRecordKeyTmpl     = "schedules/%s"          // substitute Schedule name
+	seriesReportKeyTemplate    = "series/%s/report.html" // substitute Schedule name
+	kDefaultLoadConcurrency    = 16                      // Max concurrent requests when loading multiple records
+	kUnsupportedJobRetryDelay  = 30 * time.Second        // Redelivery delay of jobs with a schema newer than supported
 )
 
 type Options struct {
@@ -24,10 +32,14 @@ type Options struct {
 	jobsQueueStreamName string
 	jobsSubmitSubject   string
 	jobsRepositoryName  string
+	jobsIndexName       string
 	artifactsStoreName  string
+	schedulesRepoName   string
 	initJobsRepository  bool
 	initArtifactsStore  bool
 	initJobsQueue       bool
+	initSchedulesRepo   bool
+	loadConcurrency     int
 	verbose             bool
 }
 
@@ -38,7 +50,9 @@ type Client struct {
 	nc             *nats.Conn
 	js             nats.JetStreamContext
 	jobsRepository nats.KeyValue
+	jobsIndex      nats.KeyValue
 	artifactsStore nats.ObjectStore
+	schedulesRepo  nats.KeyValue
 }
 
 func (c *Client) Close() {
@@ -58,8 +72,11 @@ func NewClient(serverUrl, credentials, namespace string, opts ...Option) (*Clien
 			jobsQueueStreamName: fmt.Sprintf("%s-jobs", namespace),
 			jobsSubmitSubject:   fmt.Sprintf("%s.jobs.submit", namespace),
 			jobsRepositoryName:  fmt.Sprintf("%s-jobs", namespace),
+			jobsIndexName:       fmt.Sprintf("%s-jobs-index", namespace),
 			artifactsStoreName:  fmt.Sprintf("%s-artifacts", namespace),
+			schedulesRepoName:   fmt.Sprintf("%s-schedules", namespace),
 			clientName:          "go-bench-away CLI", //TODO add user@hostname
+			loadConcurrency:     kDefaultLoadConcurrency,
 		},
 	}
 
@@ -130,6 +147,20 @@ func NewClient(serverUrl, credentials, namespace string, opts ...Option) (*Clien
 			return nil, err
 		}
 		client.jobsRepository = kv
+
+		if err := client.checkNamespaceSchema(); err != nil {
+			return nil, err
+		}
+
+		// Optional, searching falls back to scanning the repository
+		index, err := client.js.KeyValue(options.jobsIndexName)
+		if err == nats.ErrBucketNotFound {
+			client.logWarn("KV bucket not found: %s (need to run init?), job search will be slower", options.jobsIndexName)
+		} else if err != nil {
+			return nil, err
+		} else {
+			client.jobsIndex = index
+		}
 	}
 
 	client.logDebug("Bound jobs repository")
@@ -146,6 +177,18 @@ func NewClient(serverUrl, credentials, namespace string, opts ...Option) (*Clien
 
 	client.logDebug("Bound artifacts store")
 
+	if options.initSchedulesRepo {
+		kv, err := client.js.KeyValue(options.schedulesRepoName)
+		if err == nats.ErrBucketNotFound {
+			return nil, fmt.Errorf("KV bucket not found: %s (need to run init-schema?)", options.schedulesRepoName)
+		} else if err != nil {
+			return nil, err
+		}
+		client.schedulesRepo = kv
+	}
+
+	client.logDebug("Bound schedules repository")
+
 	// Disengage shutdown trap
 	initCompleted = true
 	return client, nil
@@ -171,6 +214,13 @@ func InitArtifactsStore() Option {
 	}
 }
 
+func InitSchedulesRepository() Option {
+	return func(o *Options) error {
+		o.initSchedulesRepo = true
+		return nil
+	}
+}
+
 func WithClientName(clientName string) Option {
 	return func(o *Options) error {
 		o.clientName = clientName
@@ -187,6 +237,17 @@ func WithAltQueue(queueName string) Option {
 	}
 }
 
+// WithLoadConcurrency sets the maximum number of concurrent requests when loading multiple job records
+func WithLoadConcurrency(concurrency int) Option {
+	return func(o *Options) error {
+		if concurrency < 1 {
+			return fmt.Errorf("Invalid load concurrency: %d", concurrency)
+		}
+		o.loadConcurrency = concurrency
+		return nil
+	}
+}
+
 func Verbose(verbose bool) Option {
 	return func(o *Options) error {
 		o.verbose = verbose
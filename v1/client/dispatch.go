@@ -85,6 +85,13 @@ dispatchLoop:
 		} else if job.Id != jobId {
 			dispatchErr = fmt.Errorf("Job ID mismatch in repository: %s vs %s", job.Id, jobId)
 			break dispatchLoop
+		} else if err := job.CheckSchema(); err != nil {
+			// Leave it for a worker running a newer version
+			c.logWarn("Skipping job %s: %v", jobId, err)
+			if err := msg.NakWithDelay(kUnsupportedJobRetryDelay); err != nil {
+				c.logWarn("Failed to NAK message: %v", err)
+			}
+			continue dispatchLoop
 		} else if job.Status != core.Submitted {
 			c.logWarn("Skipping job %s in state: %s", jobId, job.Status.String())
 			if err := msg.Ack(); err != nil {
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+
+	"github.com/nats-io/nats.go"
+)
+
+// ImportJob creates a job record as-is (preserving ID and timestamps), and uploads its artifacts, given by name
+// (see core.JobRecord.Artifacts). Artifacts of the record not given are removed from it.
+// If a job with the same ID exists, fails with core.ErrJobExists, unless overwrite is set.
+// Jobs still submitted are published in the queue of this client, so a worker will run them.
+func (c *Client) ImportJob(job *core.JobRecord, artifacts map[string][]byte, overwrite bool) error {
+	if err := job.CheckSchema(); err != nil {
+		return err
+	}
+
+	jobRecordKey := fmt.Sprintf(kJobRecordKeyTmpl, job.Id)
+
+	_, err := c.jobsRepository.Get(jobRecordKey)
+	if err == nil && !overwrite {
+		return fmt.Errorf("%w: '%s'", core.ErrJobExists, job.Id)
+	} else if err != nil && err != nats.ErrKeyNotFound {
+		return err
+	}
+
+	for _, name := range job.ArtifactNames() {
+		if err := job.SetArtifact(name, ""); err != nil {
+			return err
+		}
+	}
+
+	for name, data := range artifacts {
+		key := fmt.Sprintf(kArtifactKeyTmpl, job.Id, name)
+		if err := job.SetArtifact(name, key); err != nil {
+			return err
+		}
+		if err := c.putArtifact(key, fmt.Sprintf("Job %s %s", job.Id, name), data); err != nil {
+			return fmt.Errorf("Failed to upload artifact %s: %v", key, err)
+		}
+	}
+
+	if job.Status == core.Submitted {
+		job.Queue = c.options.jobsQueueName
+	}
+
+	if overwrite {
+		_, err = c.jobsRepository.Put(jobRecordKey, job.Bytes())
+	} else {
+		_, err = c.jobsRepository.Create(jobRecordKey, job.Bytes())
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to create job record: %v", err)
+	}
+
+	if err := c.indexJob(job); err != nil {
+		c.logWarn("Failed to index job %s: %v", job.Id, err)
+	}
+
+	if job.Status != core.Submitted {
+		return nil
+	}
+
+	return c.publishSubmitRequest(job.Id)
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+	server "github.com/nats-io/nats-server/v2/test"
+)
+
+func TestImportJob(t *testing.T) {
+
+	// Configure local server and start it
+	opts := server.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+
+	s := server.RunServer(&opts)
+	defer s.Shutdown()
+
+	namespace := "test"
+	credentials := ""
+	verbose := true
+
+	bareClient, err := NewClient(s.ClientURL(), credentials, namespace, Verbose(verbose))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bareClient.Close()
+
+	for _, create := range []func() error{
+		bareClient.CreateJobsQueue,
+		bareClient.CreateJobsRepository,
+		bareClient.CreateJobsIndex,
+		bareClient.CreateArtifactsStore,
+	} {
+		if err := create(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := NewClient(s.ClientURL(), credentials, namespace, Verbose(verbose), InitJobsQueue(), InitJobsRepository(), InitArtifactsStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// Job completed long ago on another server
+	completedJob := core.NewJob(core.JobParameters{GitRef: "main", Group: "v2.10"})
+	completedJob.Created = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	completedJob.SetRunningStatus()
+	completedJob.SetFinalStatus(core.Succeeded)
+	completedJob.Log, completedJob.Results, completedJob.Script = "other/log", "other/results", "other/script"
+	results := []byte("BenchmarkFoo 100 10 ns/op\n")
+	artifacts := map[string][]byte{
+		core.LogArtifact:            []byte("log"),
+		core.ResultsArtifact:        results,
+		core.ProfileArtifact("cpu"): []byte("profile"),
+	}
+
+	if err := c.ImportJob(completedJob, map[string][]byte{"foo.txt": nil}, false); err == nil {
+		t.Fatalf("Expected error for unknown artifact")
+	}
+	if err := c.ImportJob(completedJob, artifacts, false); err != nil {
+		t.Fatal(err)
+	}
+
+	job, _, err := c.LoadJob(completedJob.Id)
+	if err != nil {
+		t.Fatal(err)
+	} else if !job.Created.Equal(completedJob.Created) || job.Status != core.Succeeded || job.Script != "" {
+		t.Fatalf("Unexpected imported job: %+v", job)
+	}
+	buf := bytes.Buffer{}
+	if err := c.LoadProfileArtifact(job, "cpu", &buf); err != nil || buf.String() != "profile" {
+		t.Fatalf("Unexpected profile: %s (%v)", buf.String(), err)
+	}
+	buf.Reset()
+	if err := c.LoadResultsArtifact(job, &buf); err != nil || !bytes.Equal(buf.Bytes(), results) {
+		t.Fatalf("Unexpected results: %s (%v)", buf.String(), err)
+	}
+
+	// Imported jobs are searchable
+	if jobs, _, err := c.SearchJobs(core.JobsQuery{Group: "v2.10"}); err != nil || len(jobs) != 1 {
+		t.Fatalf("Unexpected search result: %v (%v)", jobs, err)
+	}
+
+	// Collision
+	if err := c.ImportJob(completedJob, map[string][]byte{core.ResultsArtifact: results}, false); !errors.Is(err, core.ErrJobExists) {
+		t.Fatalf("Expected job exists error, got: %v", err)
+	}
+	if err := c.ImportJob(completedJob, map[string][]byte{core.ResultsArtifact: results}, true); err != nil {
+		t.Fatal(err)
+	}
+	if job, _, err := c.LoadJob(completedJob.Id); err != nil || job.Log != "" || len(job.Profiles) != 0 {
+		t.Fatalf("Unexpected overwritten job: %+v (%v)", job, err)
+	}
+
+	// Only submitted jobs are queued
+	submittedJob := core.NewJob(core.JobParameters{GitRef: "dev"})
+	if err := c.ImportJob(submittedJob, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	jobs, err := c.LoadRecentJobs(0)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(jobs) != 1 || jobs[0].Id != submittedJob.Id || jobs[0].Queue != namespace {
+		t.Fatalf("Unexpected queued jobs: %v", jobs)
+	}
+}
@@ -0,0 +1,325 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+
+	"github.com/nats-io/nats.go"
+)
+
+// The jobs index is a KV bucket with two kinds of entries:
+//   - all.<job id>: summary of the job record, with just the fields used for searching
+//   - <field>.<encoded value>.<job id>: empty entry, one for each indexed field of each job
+//
+// Searching by field lists keys matching <field>.<encoded value>.* rather than scanning all job records.
+const (
+	kJobIndexSummaryKeyTmpl = "all.%s"   // substitute Job ID
+	kJobIndexFieldKeyTmpl   = "%s.%s.%s" // substitute field, encoded value, Job ID
+	kJobIndexFieldFilter    = "%s.%s.*"  // substitute field, encoded value
+	kJobIndexSummaryFilter  = "all.*"
+	// Above this number of candidates, summaries are loaded in bulk rather than one by one
+	kJobIndexMaxSummaryGets = 256
+)
+
+// Indexed fields
+const (
+	kIndexStatus = "status"
+	kIndexUser   = "user"
+	kIndexRemote = "remote"
+	kIndexRef    = "ref"
+	kIndexSHA    = "sha" // SHA prefix
+	kIndexHost   = "host"
+	kIndexQueue  = "queue"
+	kIndexGroup  = "group"
+)
+
+// Encode a value so that it is a valid single token of a KV key
+func encodeIndexValue(value string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+// Summary of a job stored in the index, sufficient to evaluate a core.JobsQuery
+func jobSummary(job *core.JobRecord) *core.JobRecord {
+	return &core.JobRecord{
+		Id:     job.Id,
+		Status: job.Status,
+		Parameters: core.JobParameters{
+			GitRemote: job.Parameters.GitRemote,
+			GitRef:    job.Parameters.GitRef,
+			Username:  job.Parameters.Username,
+			Group:     job.Parameters.Group,
+		},
+		Created:    job.Created,
+		SHA:        job.SHA,
+		WorkerInfo: core.WorkerInfo{Hostname: job.WorkerInfo.Hostname},
+		Queue:      job.Queue,
+	}
+}
+
+// Keys of the field entries for a job
+func jobIndexKeys(job *core.JobRecord) []string {
+	fields := [][2]string{
+		{kIndexStatus, job.Status.String()},
+		{kIndexUser, job.Parameters.Username},
+		{kIndexRemote, job.Parameters.GitRemote},
+		{kIndexRef, job.Parameters.GitRef},
+		{kIndexHost, job.WorkerInfo.Hostname},
+		{kIndexQueue, job.Queue},
+		{kIndexGroup, job.Parameters.Group},
+	}
+	if len(job.SHA) >= core.MinSHAPrefixLength {
+		fields = append(fields, [2]string{kIndexSHA, strings.ToLower(job.SHA[:core.MinSHAPrefixLength])})
+	}
+
+	keys := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field[1] == "" {
+			continue
+		}
+		keys = append(keys, fmt.Sprintf(kJobIndexFieldKeyTmpl, field[0], encodeIndexValue(field[1]), job.Id))
+	}
+	return keys
+}
+
+// Add or update the index entries of a job
+func (c *Client) indexJob(job *core.JobRecord) error {
+	if c.jobsIndex == nil {
+		return nil
+	}
+
+	summaryKey := fmt.Sprintf(kJobIndexSummaryKeyTmpl, job.Id)
+
+	// Entries of the previous version of this job
+	staleKeys := map[string]bool{}
+	kve, err := c.jobsIndex.Get(summaryKey)
+	if err == nil {
+		if previous, err := core.LoadJob(kve.Value()); err == nil {
+			for _, key := range jobIndexKeys(previous) {
+				staleKeys[key] = true
+			}
+		}
+	} else if err != nats.ErrKeyNotFound {
+		return err
+	}
+
+	for _, key := range jobIndexKeys(job) {
+		if staleKeys[key] {
+			delete(staleKeys, key)
+			continue
+		}
+		if _, err := c.jobsIndex.Put(key, nil); err != nil {
+			return err
+		}
+	}
+
+	for key := range staleKeys {
+		if err := c.jobsIndex.Purge(key); err != nil {
+			return err
+		}
+	}
+
+	_, err = c.jobsIndex.Put(summaryKey, jobSummary(job).Bytes())
+	return err
+}
+
+// List the job IDs of field entries matching the given filter
+func (c *Client) indexedJobIds(filter string) (map[string]bool, error) {
+	watcher, err := c.jobsIndex.Watch(filter, nats.MetaOnly(), nats.IgnoreDeletes())
+	if err != nil {
+		return nil, err
+	}
+	defer watcher.Stop()
+
+	jobIds := map[string]bool{}
+	for entry := range watcher.Updates() {
+		if entry == nil {
+			// End of initial values
+			break
+		}
+		jobIds[entry.Key()[strings.LastIndex(entry.Key(), ".")+1:]] = true
+	}
+	return jobIds, nil
+}
+
+// Load all job summaries (or records) from a bucket, filtered by the given function.
+// Entries with a key not starting with keyPrefix are skipped.
+func watchJobs(kv nats.KeyValue, filter, keyPrefix string, accept func(*core.JobRecord) bool) ([]*core.JobRecord, error) {
+	watcher, err := kv.Watch(filter, nats.IgnoreDeletes())
+	if err != nil {
+		return nil, err
+	}
+	defer watcher.Stop()
+
+	jobs := []*core.JobRecord{}
+	for entry := range watcher.Updates() {
+		if entry == nil {
+			// End of initial values
+			break
+		}
+		if !strings.HasPrefix(entry.Key(), keyPrefix) {
+			continue
+		}
+		job, err := core.LoadJob(entry.Value())
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load %s: %v", entry.Key(), err)
+		}
+		if accept(job) {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+// Load all job records from the repository, filtered by the given function
+func (c *Client) scanJobRecords(accept func(*core.JobRecord) bool) ([]*core.JobRecord, error) {
+	return watchJobs(c.jobsRepository, nats.AllKeys, fmt.Sprintf(kJobRecordKeyTmpl, ""), accept)
+}
+
+// Find the IDs of candidate jobs for a query, using the field entries of the index.
+// Returns nil if the query has no indexed criteria.
+func (c *Client) candidateJobIds(q *core.JobsQuery) (map[string]bool, error) {
+	filters := [][]string{}
+	if q.Status != nil {
+		filters = append(filters, []string{fmt.Sprintf(kJobIndexFieldFilter, kIndexStatus, encodeIndexValue(q.Status.String()))})
+	}
+	for _, field := range [][2]string{
+		{kIndexUser, q.Username},
+		{kIndexRemote, q.GitRemote},
+		{kIndexHost, q.WorkerHost},
+		{kIndexQueue, q.Queue},
+		{kIndexGroup, q.Group},
+	} {
+		if field[1] != "" {
+			filters = append(filters, []string{fmt.Sprintf(kJobIndexFieldFilter, field[0], encodeIndexValue(field[1]))})
+		}
+	}
+	if q.Ref != "" {
+		// Either the reference, or a SHA prefix
+		refFilters := []string{fmt.Sprintf(kJobIndexFieldFilter, kIndexRef, encodeIndexValue(q.Ref))}
+		if len(q.Ref) >= core.MinSHAPrefixLength {
+			shaPrefix := strings.ToLower(q.Ref[:core.MinSHAPrefixLength])
+			refFilters = append(refFilters, fmt.Sprintf(kJobIndexFieldFilter, kIndexSHA, encodeIndexValue(shaPrefix)))
+		}
+		filters = append(filters, refFilters)
+	}
+
+	if len(filters) == 0 {
+		return nil, nil
+	}
+
+	// Intersection of the criteria, each being the union of one or more filters
+	var candidates map[string]bool
+	for _, union := range filters {
+		matching := map[string]bool{}
+		for _, filter := range union {
+			jobIds, err := c.indexedJobIds(filter)
+			if err != nil {
+				return nil, err
+			}
+			for jobId := range jobIds {
+				if candidates == nil || candidates[jobId] {
+					matching[jobId] = true
+				}
+			}
+		}
+		candidates = matching
+		if len(candidates) == 0 {
+			break
+		}
+	}
+	return candidates, nil
+}
+
+// Find the summaries of jobs matching a query, using the index
+func (c *Client) searchIndex(q *core.JobsQuery) ([]*core.JobRecord, error) {
+	candidates, err := c.candidateJobIds(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if candidates == nil || len(candidates) > kJobIndexMaxSummaryGets {
+		return watchJobs(c.jobsIndex, kJobIndexSummaryFilter, "", func(job *core.JobRecord) bool {
+			return (candidates == nil || candidates[job.Id]) && q.Matches(job)
+		})
+	}
+
+	summaries := make([]*core.JobRecord, 0, len(candidates))
+	for jobId := range candidates {
+		kve, err := c.jobsIndex.Get(fmt.Sprintf(kJobIndexSummaryKeyTmpl, jobId))
+		if err == nats.ErrKeyNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		summary, err := core.LoadJob(kve.Value())
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load summary of job %s: %v", jobId, err)
+		}
+		if q.Matches(summary) {
+			summaries = append(summaries, summary)
+		}
+	}
+	return summaries, nil
+}
+
+// SearchJobs returns the page of jobs matching a query (newest first), and the total number of matching jobs.
+// If the jobs index is not available, all job records are scanned.
+func (c *Client) SearchJobs(q core.JobsQuery) ([]*core.JobRecord, int, error) {
+	var matching []*core.JobRecord
+	var err error
+	if c.jobsIndex != nil {
+		matching, err = c.searchIndex(&q)
+	} else {
+		matching, err = c.scanJobRecords(q.Matches)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("Failed to search jobs: %v", err)
+	}
+
+	sort.SliceStable(matching, func(i, j int) bool {
+		if matching[i].Created.Equal(matching[j].Created) {
+			return matching[i].Id < matching[j].Id
+		}
+		return matching[i].Created.After(matching[j].Created)
+	})
+
+	page := q.Page(matching)
+	if c.jobsIndex == nil {
+		// Already full records
+		return page, len(matching), nil
+	}
+
+	jobIds := make([]string, len(page))
+	for i, summary := range page {
+		jobIds[i] = summary.Id
+	}
+	jobs, errs := c.LoadJobs(context.Background(), jobIds)
+	if err := errs.Err(); err != nil {
+		return nil, 0, err
+	}
+	return jobs, len(matching), nil
+}
+
+// RebuildJobsIndex indexes all job records in the repository, returns the number of jobs indexed
+func (c *Client) RebuildJobsIndex() (int, error) {
+	if c.jobsIndex == nil {
+		return 0, fmt.Errorf("Jobs index not found: %s (need to run init?)", c.options.jobsIndexName)
+	}
+
+	jobs, err := c.scanJobRecords(func(*core.JobRecord) bool { return true })
+	if err != nil {
+		return 0, err
+	}
+
+	for _, job := range jobs {
+		if err := c.indexJob(job); err != nil {
+			return 0, fmt.Errorf("Failed to index job %s: %v", job.Id, err)
+		}
+	}
+	return len(jobs), nil
+}
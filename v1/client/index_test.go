@@ -0,0 +1,155 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+	server "github.com/nats-io/nats-server/v2/test"
+)
+
+func TestSearchJobs(t *testing.T) {
+
+	// Configure local server and start it
+	opts := server.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+
+	s := server.RunServer(&opts)
+	defer s.Shutdown()
+
+	namespace := "test"
+	credentials := ""
+	verbose := true
+
+	bareClient, err := NewClient(s.ClientURL(), credentials, namespace, Verbose(verbose))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bareClient.Close()
+
+	if err := bareClient.CreateJobsQueue(); err != nil {
+		t.Fatal(err)
+	}
+	if err := bareClient.CreateJobsRepository(); err != nil {
+		t.Fatal(err)
+	}
+
+	newTestClient := func() *Client {
+		client, err := NewClient(s.ClientURL(), credentials, namespace, Verbose(verbose), InitJobsQueue(), InitJobsRepository())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+
+	// Submit jobs before the index exists
+	unindexedClient := newTestClient()
+	defer unindexedClient.Close()
+
+	jobParams := []core.JobParameters{
+		{GitRemote: "https://github.com/nats-io/nats-server.git", GitRef: "main", Username: "alice", Group: "v2.10"},
+		{GitRemote: "https://github.com/nats-io/nats-server.git", GitRef: "dev", Username: "bob"},
+		{GitRemote: "https://github.com/nats-io/nats.go.git", GitRef: "main", Username: "alice", Group: "v2.10"},
+	}
+	jobs := []*core.JobRecord{}
+	for _, params := range jobParams {
+		job, err := unindexedClient.SubmitJob(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		jobs = append(jobs, job)
+		// Distinct creation times
+		time.Sleep(1 * time.Second)
+	}
+
+	// Complete the first job
+	job, revision, err := unindexedClient.LoadJob(jobs[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.SetRunningStatus()
+	job.SHA = "a1b2c3d4e5f6"
+	job.WorkerInfo.Hostname = "bench-1"
+	job.SetFinalStatus(core.Succeeded)
+	if _, err := unindexedClient.UpdateJob(job, revision); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create and populate the index
+	if err := bareClient.CreateJobsIndex(); err != nil {
+		t.Fatal(err)
+	}
+	client := newTestClient()
+	defer client.Close()
+
+	if n, err := client.RebuildJobsIndex(); err != nil {
+		t.Fatal(err)
+	} else if n != len(jobs) {
+		t.Fatalf("Expected %d jobs indexed, got %d", len(jobs), n)
+	}
+
+	// Submitted after the index was created
+	job, err = client.SubmitJob(core.JobParameters{GitRemote: "https://github.com/nats-io/nats.go.git", GitRef: "dev", Username: "carol"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs = append(jobs, job)
+
+	succeeded, submitted := core.Succeeded, core.Submitted
+
+	testCases := []struct {
+		description     string
+		query           core.JobsQuery
+		expectedJobs    []int // Indices in jobs, newest first
+		expectedMatches int
+	}{
+		{"All", core.JobsQuery{}, []int{3, 2, 1, 0}, 4},
+		{"First page", core.JobsQuery{Limit: 3}, []int{3, 2, 1}, 4},
+		{"Second page", core.JobsQuery{Limit: 3, Offset: 3}, []int{0}, 4},
+		{"Offset past end", core.JobsQuery{Offset: 10}, []int{}, 4},
+		{"User", core.JobsQuery{Username: "alice"}, []int{2, 0}, 2},
+		{"User and remote", core.JobsQuery{Username: "alice", GitRemote: "https://github.com/nats-io/nats.go.git"}, []int{2}, 1},
+		{"Status", core.JobsQuery{Status: &succeeded}, []int{0}, 1},
+		{"Status and user", core.JobsQuery{Status: &submitted, Username: "alice"}, []int{2}, 1},
+		{"Ref", core.JobsQuery{Ref: "dev"}, []int{3, 1}, 2},
+		{"SHA prefix", core.JobsQuery{Ref: "A1B2C3"}, []int{0}, 1},
+		{"Wrong SHA prefix", core.JobsQuery{Ref: "a1b2ff"}, []int{}, 0},
+		{"Worker host", core.JobsQuery{WorkerHost: "bench-1"}, []int{0}, 1},
+		{"Queue", core.JobsQuery{Queue: namespace}, []int{3, 2, 1, 0}, 4},
+		{"Other queue", core.JobsQuery{Queue: "other"}, []int{}, 0},
+		{"Group", core.JobsQuery{Group: "v2.10"}, []int{2, 0}, 2},
+		{"Since", core.JobsQuery{Since: jobs[2].Created}, []int{3, 2}, 2},
+		{"Until", core.JobsQuery{Until: jobs[1].Created}, []int{0}, 1},
+		{"No match", core.JobsQuery{Username: "dave"}, []int{}, 0},
+	}
+
+	for _, tc := range testCases {
+		// Results using the index and scanning the repository must be the same
+		for _, c := range []*Client{client, unindexedClient} {
+			results, total, err := c.SearchJobs(tc.query)
+			if err != nil {
+				t.Fatalf("%s: %v", tc.description, err)
+			}
+			if total != tc.expectedMatches || len(results) != len(tc.expectedJobs) {
+				t.Fatalf("%s: expected %d/%d jobs, got %d/%d", tc.description, len(tc.expectedJobs), tc.expectedMatches, len(results), total)
+			}
+			for i, jobIndex := range tc.expectedJobs {
+				if results[i].Id != jobs[jobIndex].Id {
+					t.Fatalf("%s: unexpected job at position %d: %s", tc.description, i, results[i].Id)
+				}
+			}
+		}
+	}
+
+	// Index follows status changes
+	if err := client.CancelJob(jobs[3].Id); err != nil {
+		t.Fatal(err)
+	}
+	if results, total, err := client.SearchJobs(core.JobsQuery{Status: &submitted}); err != nil {
+		t.Fatal(err)
+	} else if total != 2 || results[0].Id != jobs[2].Id {
+		t.Fatalf("Unexpected results after cancel: %d", total)
+	}
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/mprimi/go-bench-away/v1/core"
@@ -16,6 +17,7 @@ func (c *Client) SubmitJob(params core.JobParameters) (*core.JobRecord, error) {
 
 	// Create a job object from parameters
 	job := core.NewJob(params)
+	job.Queue = c.options.jobsQueueName
 
 	// Create a record in jobs repository
 	jobRecordKey := fmt.Sprintf(kJobRecordKeyTmpl, job.Id)
@@ -24,19 +26,31 @@ func (c *Client) SubmitJob(params core.JobParameters) (*core.JobRecord, error) {
 		return nil, fmt.Errorf("Failed to create job record: %v", err)
 	}
 
+	if err := c.indexJob(job); err != nil {
+		c.logWarn("Failed to index job %s: %v", job.Id, err)
+	}
+
 	// Submit job in the queue
+	if err := c.publishSubmitRequest(job.Id); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Publish a request to run a job in the queue
+func (c *Client) publishSubmitRequest(jobId string) error {
 	submitMsg := nats.NewMsg(c.options.jobsSubmitSubject)
 	// Message is empty, header points to job record in repository
-	submitMsg.Header.Add(kJobIdHeader, job.Id)
+	submitMsg.Header.Add(kJobIdHeader, jobId)
 	// For deduplication
-	submitMsg.Header.Add(nats.MsgIdHdr, job.Id)
+	submitMsg.Header.Add(nats.MsgIdHdr, jobId)
 
 	_, pubErr := c.js.PublishMsg(submitMsg)
 	if pubErr != nil {
-		return nil, fmt.Errorf("Failed to submit job: %v", pubErr)
+		return fmt.Errorf("Failed to submit job: %v", pubErr)
 	}
-
-	return job, nil
+	return nil
 }
 
 func (c *Client) CancelJob(jobId string) error {
@@ -59,52 +73,72 @@ func (c *Client) CancelJob(jobId string) error {
 	return nil
 }
 
+// LoadRecentJobs loads the jobs most recently submitted to the queue (newest first), 0 for unlimited.
+// Returns the jobs that loaded, along with an error if any failed to load.
 func (c *Client) LoadRecentJobs(limit int) ([]*core.JobRecord, error) {
-	jobs := []*core.JobRecord{}
+	jobs, errs, err := c.LoadRecentJobsWithContext(context.Background(), limit)
+	if err != nil {
+		return nil, err
+	}
+	return jobs, errs.Err()
+}
 
-	lastSubmitMsg, err := c.js.GetLastMsg(c.options.jobsQueueStreamName, c.options.jobsSubmitSubject)
+// LoadRecentJobsWithContext loads the jobs most recently submitted to the queue (newest first), 0 for unlimited.
+// Records are loaded concurrently. Returns the jobs that loaded, and the error of each submit request (newest first,
+// nil if loaded or skipped). Fails (with no jobs) only if the queue cannot be read.
+func (c *Client) LoadRecentJobsWithContext(ctx context.Context, limit int) ([]*core.JobRecord, core.ParallelErrors, error) {
+	lastSubmitMsg, err := c.js.GetLastMsg(c.options.jobsQueueStreamName, c.options.jobsSubmitSubject, nats.Context(ctx))
 	if err == nats.ErrMsgNotFound {
-		return []*core.JobRecord{}, nil
+		return []*core.JobRecord{}, core.ParallelErrors{}, nil
 	} else if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	startSeq := lastSubmitMsg.Sequence
 
-	// List job requests from newest to oldest
-	for i := startSeq; i > 0; i-- {
-		// Stop early if a limit is set
-		if limit > 0 && startSeq-i > uint64(limit-1) {
-			break
-		}
+	// Number of submit requests to load, from newest to oldest
+	count := startSeq
+	if limit > 0 && uint64(limit) < count {
+		count = uint64(limit)
+	}
 
-		rawMsg, err := c.js.GetMsg(c.options.jobsQueueStreamName, i)
+	loaded := make([]*core.JobRecord, count)
+	errs := core.ForEachParallel(ctx, int(count), c.options.loadConcurrency, func(ctx context.Context, i int) error {
+		seq := startSeq - uint64(i)
+		rawMsg, err := c.js.GetMsg(c.options.jobsQueueStreamName, seq, nats.Context(ctx))
 		if err != nil {
-			return nil, fmt.Errorf("Failed retrieve submit request %d: %v", i, err)
+			return fmt.Errorf("Failed retrieve submit request %d: %v", seq, err)
 		}
 
 		jobId := rawMsg.Header.Get(kJobIdHeader)
 		if jobId == "" {
 			// Missing job id header
-			continue
+			return nil
 		}
 
 		jobRecordKey := fmt.Sprintf(kJobRecordKeyTmpl, jobId)
 
 		kve, err := c.jobsRepository.Get(jobRecordKey)
 		if err != nil {
-			return nil, fmt.Errorf("Failed to job %s record: %v", jobId, err)
+			return fmt.Errorf("Failed to get job %s record: %v", jobId, err)
 		}
 
 		job, err := core.LoadJob(kve.Value())
 		if err != nil {
-			return nil, fmt.Errorf("Failed to load job %s: %v", jobId, err)
+			return fmt.Errorf("Failed to load job %s: %v", jobId, err)
 		}
 
-		jobs = append(jobs, job)
-	}
+		loaded[i] = job
+		return nil
+	})
 
-	return jobs, nil
+	jobs := make([]*core.JobRecord, 0, len(loaded))
+	for _, job := range loaded {
+		if job != nil {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, errs, nil
 }
 
 func (c *Client) GetQueueStatus() (*core.QueueStatus, error) {
@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -161,4 +162,29 @@ func TestSubmit(t *testing.T) {
 			t.Fatalf("expected error cancelling jobs[%d]", i)
 		}
 	}
+
+	// Corrupt one record, the remaining recent jobs should still load
+	if _, err := client.jobsRepository.Put(fmt.Sprintf(kJobRecordKeyTmpl, jobs[2].Id), []byte("{")); err != nil {
+		t.Fatal(err)
+	}
+
+	recentJobs, errs, err := client.LoadRecentJobsWithContext(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs.Failed() != 1 || errs[1] == nil {
+		t.Fatalf("Expected one failed load (newest first, index 1), got: %v", errs)
+	}
+	if len(recentJobs) != numJobs-1 {
+		t.Fatalf("Expected %d jobs, got %d", numJobs-1, len(recentJobs))
+	}
+	for _, job := range recentJobs {
+		if job.Id == jobs[2].Id {
+			t.Fatalf("Unexpected corrupted job in results")
+		}
+	}
+
+	if _, err := client.LoadRecentJobs(0); err == nil {
+		t.Fatalf("Expected error loading recent jobs with a corrupted record")
+	}
 }
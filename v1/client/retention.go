@@ -0,0 +1,178 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Pinned group record, stored in the jobs repository
+type pinnedGroup struct {
+	Name   string
+	Pinned time.Time
+}
+
+// PinJob sets or clears the pinned flag of a job, pinned jobs are exempt from garbage collection
+func (c *Client) PinJob(jobId string, pinned bool) error {
+	job, revision, err := c.LoadJob(jobId)
+	if err != nil {
+		return err
+	}
+	if job.Pinned == pinned {
+		return nil
+	}
+	job.Pinned = pinned
+	_, err = c.UpdateJob(job, revision)
+	return err
+}
+
+// PinGroup pins (or unpins) a group, jobs in a pinned group are exempt from garbage collection
+func (c *Client) PinGroup(group string, pinned bool) error {
+	if group == "" {
+		return fmt.Errorf("Group name cannot be empty")
+	}
+	groupKey := fmt.Sprintf(kPinnedGroupKeyTmpl, encodeIndexValue(group))
+	if !pinned {
+		return c.jobsRepository.Delete(groupKey)
+	}
+	data, err := json.Marshal(pinnedGroup{Name: group, Pinned: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	_, err = c.jobsRepository.Put(groupKey, data)
+	return err
+}
+
+// PinnedGroups returns the names of pinned groups, sorted
+func (c *Client) PinnedGroups() ([]string, error) {
+	keys, err := c.jobsRepository.Keys()
+	if err == nats.ErrNoKeysFound {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	groups := []string{}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, fmt.Sprintf(kPinnedGroupKeyTmpl, "")) {
+			continue
+		}
+		kve, err := c.jobsRepository.Get(key)
+		if err == nats.ErrKeyNotFound {
+			// Unpinned concurrently
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		group := pinnedGroup{}
+		if err := json.Unmarshal(kve.Value(), &group); err != nil {
+			return nil, fmt.Errorf("Failed to load pinned group %s: %v", key, err)
+		}
+		groups = append(groups, group.Name)
+	}
+	sort.Strings(groups)
+	return groups, nil
+}
+
+// Check if a job is pinned, or is in a pinned group (loading the latest state of the group)
+func (c *Client) isJobPinned(job *core.JobRecord) (bool, error) {
+	if job.Pinned || job.Parameters.Group == "" {
+		return job.Pinned, nil
+	}
+	groupKey := fmt.Sprintf(kPinnedGroupKeyTmpl, encodeIndexValue(job.Parameters.Group))
+	if _, err := c.jobsRepository.Get(groupKey); err == nats.ErrKeyNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CollectGarbage deletes the log and script artifacts of completed jobs older than maxAge, except pinned jobs.
+// Results are always kept. In dry run mode, nothing is deleted but the report shows what would be.
+func (c *Client) CollectGarbage(maxAge time.Duration, dryRun bool) (*core.GCReport, error) {
+	if maxAge <= 0 {
+		return nil, fmt.Errorf("Invalid retention: %v", maxAge)
+	}
+
+	pinnedGroups, err := c.PinnedGroups()
+	if err != nil {
+		return nil, err
+	}
+	isPinned := func(job *core.JobRecord) bool {
+		for _, group := range pinnedGroups {
+			if job.Parameters.Group == group {
+				return true
+			}
+		}
+		return job.Pinned
+	}
+
+	report := &core.GCReport{DryRun: dryRun}
+	cutoff := time.Now().Add(-maxAge)
+
+	candidates, err := c.scanJobRecords(func(job *core.JobRecord) bool {
+		report.JobsScanned++
+		if !job.IsCompleted() || !job.Completed.Before(cutoff) || (job.Log == "" && job.Script == "") {
+			return false
+		} else if isPinned(job) {
+			report.JobsPinned++
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to scan jobs: %v", err)
+	}
+
+	for _, candidate := range candidates {
+		// Reload, to update the latest revision, and check again if the job (or its group) was pinned since the scan
+		job, revision, err := c.LoadJob(candidate.Id)
+		if err != nil {
+			return report, err
+		}
+		if pinned, err := c.isJobPinned(job); err != nil {
+			return report, fmt.Errorf("Failed to check if job %s is pinned: %v", job.Id, err)
+		} else if pinned {
+			report.JobsPinned++
+			continue
+		}
+
+		for _, artifact := range []*string{&job.Log, &job.Script} {
+			if *artifact == "" {
+				continue
+			}
+			info, err := c.artifactsStore.GetInfo(*artifact)
+			if err == nats.ErrObjectNotFound {
+				// Already deleted, fix the record
+			} else if err != nil {
+				return report, fmt.Errorf("Failed to get artifact %s: %v", *artifact, err)
+			} else {
+				report.ArtifactsDeleted++
+				report.BytesReclaimed += info.Size
+				if !dryRun {
+					if err := c.artifactsStore.Delete(*artifact); err != nil && err != nats.ErrObjectNotFound {
+						return report, fmt.Errorf("Failed to delete artifact %s: %v", *artifact, err)
+					}
+				}
+			}
+			*artifact = ""
+		}
+		report.JobsCollected++
+
+		if !dryRun {
+			if _, err := c.UpdateJob(job, revision); err != nil {
+				// Artifacts are gone, the record will be fixed by the next collection
+				c.logWarn("Failed to update job %s: %v", job.Id, err)
+			}
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,167 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+	server "github.com/nats-io/nats-server/v2/test"
+)
+
+func TestCollectGarbage(t *testing.T) {
+
+	// Configure local server and start it
+	opts := server.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+
+	s := server.RunServer(&opts)
+	defer s.Shutdown()
+
+	namespace := "test"
+	credentials := ""
+	verbose := true
+
+	bareClient, err := NewClient(s.ClientURL(), credentials, namespace, Verbose(verbose))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bareClient.Close()
+
+	for _, create := range []func() error{
+		bareClient.CreateJobsQueue,
+		bareClient.CreateJobsRepository,
+		bareClient.CreateArtifactsStore,
+	} {
+		if err := create(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := NewClient(s.ClientURL(), credentials, namespace, Verbose(verbose), InitJobsQueue(), InitJobsRepository(), InitArtifactsStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	artifactPath := filepath.Join(t.TempDir(), "artifact.txt")
+	if err := os.WriteFile(artifactPath, make([]byte, 1000), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a completed job with all artifacts
+	createJob := func(params core.JobParameters, completed time.Time) *core.JobRecord {
+		t.Helper()
+		job, err := c.SubmitJob(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		job, revision, err := c.LoadJob(job.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		job.SetRunningStatus()
+		for _, upload := range []struct {
+			fn       func(string, string) (string, error)
+			artifact *string
+		}{
+			{c.UploadLogArtifact, &job.Log},
+			{c.UploadScriptArtifact, &job.Script},
+			{c.UploadResultsArtifact, &job.Results},
+		} {
+			if *upload.artifact, err = upload.fn(job.Id, artifactPath); err != nil {
+				t.Fatal(err)
+			}
+		}
+		job.SetFinalStatus(core.Succeeded)
+		job.Completed = completed
+		if _, err := c.UpdateJob(job, revision); err != nil {
+			t.Fatal(err)
+		}
+		return job
+	}
+
+	old := time.Now().Add(-48 * time.Hour)
+	oldJob := createJob(core.JobParameters{}, old)
+	pinnedJob := createJob(core.JobParameters{}, old)
+	groupJob := createJob(core.JobParameters{Group: "v2.10"}, old)
+	recentJob := createJob(core.JobParameters{}, time.Now())
+
+	if err := c.PinJob(pinnedJob.Id, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.PinGroup("v2.10", true); err != nil {
+		t.Fatal(err)
+	}
+	if groups, err := c.PinnedGroups(); err != nil || len(groups) != 1 || groups[0] != "v2.10" {
+		t.Fatalf("Unexpected pinned groups: %v (%v)", groups, err)
+	}
+	for _, jobId := range []string{pinnedJob.Id, groupJob.Id, oldJob.Id} {
+		job, _, err := c.LoadJob(jobId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pinned, err := c.isJobPinned(job); err != nil || pinned != (jobId != oldJob.Id) {
+			t.Fatalf("Unexpected pinned state of job %s: %v (%v)", jobId, pinned, err)
+		}
+	}
+
+	checkReport := func(report *core.GCReport, err error, expectedArtifacts int) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.JobsScanned != 4 || report.JobsPinned != 2 || report.ArtifactsDeleted != expectedArtifacts {
+			t.Fatalf("Unexpected report: %+v", report)
+		}
+		if report.BytesReclaimed != uint64(1000*expectedArtifacts) {
+			t.Fatalf("Unexpected reclaimed space: %d", report.BytesReclaimed)
+		}
+	}
+
+	// Dry run deletes nothing
+	report, err := c.CollectGarbage(24*time.Hour, true)
+	checkReport(report, err, 2)
+	if job, _, err := c.LoadJob(oldJob.Id); err != nil || job.Log == "" || job.Script == "" {
+		t.Fatalf("Unexpected job after dry run: %+v (%v)", job, err)
+	}
+
+	report, err = c.CollectGarbage(24*time.Hour, false)
+	checkReport(report, err, 2)
+
+	// Results are kept
+	job, _, err := c.LoadJob(oldJob.Id)
+	if err != nil {
+		t.Fatal(err)
+	} else if job.Log != "" || job.Script != "" || job.Results == "" {
+		t.Fatalf("Unexpected artifacts after collection: %+v", job)
+	}
+	if _, err := c.artifactsStore.GetInfo(oldJob.Log); err == nil {
+		t.Fatalf("Expected log artifact to be deleted")
+	}
+	if _, err := c.ResultsArtifactDigest(job); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pinned and recent jobs are untouched
+	for _, jobId := range []string{pinnedJob.Id, groupJob.Id, recentJob.Id} {
+		if job, _, err := c.LoadJob(jobId); err != nil || job.Log == "" || job.Script == "" {
+			t.Fatalf("Unexpected job after collection: %+v (%v)", job, err)
+		}
+	}
+
+	// Nothing left to collect
+	report, err = c.CollectGarbage(24*time.Hour, false)
+	checkReport(report, err, 0)
+
+	// Unpinned group is collected
+	if err := c.PinGroup("v2.10", false); err != nil {
+		t.Fatal(err)
+	}
+	if report, err := c.CollectGarbage(24*time.Hour, false); err != nil || report.JobsCollected != 1 {
+		t.Fatalf("Unexpected report: %+v (%v)", report, err)
+	}
+}
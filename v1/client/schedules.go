@@ -0,0 +1,105 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+
+	"github.com/nats-io/nats.go"
+)
+
+func (c *Client) CreateSchedule(schedule *core.Schedule) error {
+	scheduleRecordKey := fmt.Sprintf(kScheduleRecordKeyTmpl, schedule.Name)
+	_, err := c.schedulesRepo.Create(scheduleRecordKey, schedule.Bytes())
+	if err == nats.ErrKeyExists {
+		return fmt.Errorf("Schedule already exists: '%s'", schedule.Name)
+	} else if err != nil {
+		return fmt.Errorf("Failed to create schedule record: %v", err)
+	}
+	return nil
+}
+
+func (c *Client) LoadSchedule(name string) (*core.Schedule, uint64, error) {
+
+	c.logDebug("Loading schedule '%s'", name)
+
+	scheduleRecordKey := fmt.Sprintf(kScheduleRecordKeyTmpl, name)
+
+	kve, err := c.schedulesRepo.Get(scheduleRecordKey)
+	if err == nats.ErrKeyNotFound {
+		return nil, 0, fmt.Errorf("Schedule not found: '%s'", name)
+	} else if err != nil {
+		return nil, 0, err
+	}
+
+	schedule, err := core.LoadSchedule(kve.Value())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	revision := kve.Revision()
+
+	c.logDebug("Loaded schedule %s revision %d", name, revision)
+
+	return schedule, revision, nil
+}
+
+// UpdateSchedule fails with core.ErrScheduleConflict if the schedule revision is not the given one
+func (c *Client) UpdateSchedule(schedule *core.Schedule, revision uint64) (uint64, error) {
+	scheduleRecordKey := fmt.Sprintf(kScheduleRecordKeyTmpl, schedule.Name)
+	newRevision, err := c.schedulesRepo.Update(scheduleRecordKey, schedule.Bytes(), revision)
+	var apiErr *nats.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode == nats.JSErrCodeStreamWrongLastSequence {
+		return 0, fmt.Errorf("%w: '%s'", core.ErrScheduleConflict, schedule.Name)
+	}
+	return newRevision, err
+}
+
+func (c *Client) DeleteSchedule(name string) error {
+	// Check existence, deleting a non-existent key is not an error
+	if _, _, err := c.LoadSchedule(name); err != nil {
+		return err
+	}
+	scheduleRecordKey := fmt.Sprintf(kScheduleRecordKeyTmpl, name)
+	return c.schedulesRepo.Delete(scheduleRecordKey)
+}
+
+// ListSchedules returns all schedules, sorted by name
+func (c *Client) ListSchedules() ([]*core.Schedule, error) {
+	keys, err := c.schedulesRepo.Keys()
+	if err == nats.ErrNoKeysFound {
+		return []*core.Schedule{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(keys)
+
+	schedules := make([]*core.Schedule, 0, len(keys))
+	for _, key := range keys {
+		name := strings.TrimPrefix(key, fmt.Sprintf(kScheduleRecordKeyTmpl, ""))
+		schedule, _, err := c.LoadSchedule(name)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load schedule %s: %v", name, err)
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+// UploadSeriesReportArtifact stores (or replaces) the report of the series of jobs submitted by a schedule
+func (c *Client) UploadSeriesReportArtifact(name string, report []byte) (string, error) {
+	key := fmt.Sprintf(seriesReportKeyTemplate, name)
+	_, err := c.artifactsStore.PutBytes(key, report)
+	return key, err
+}
+
+func (c *Client) DownloadSeriesReportArtifact(schedule *core.Schedule, filePath string) error {
+	if schedule.Report == "" {
+		return fmt.Errorf("Schedule %s has no series report yet", schedule.Name)
+	}
+	return c.artifactsStore.GetFile(schedule.Report, filePath)
+}
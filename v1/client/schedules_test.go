@@ -0,0 +1,105 @@
+package client
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+	server "github.com/nats-io/nats-server/v2/test"
+)
+
+func TestSchedules(t *testing.T) {
+
+	// Configure local server and start it
+	opts := server.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+
+	s := server.RunServer(&opts)
+	defer s.Shutdown()
+
+	namespace := "test"
+	credentials := ""
+	verbose := true
+
+	bareClient, err := NewClient(s.ClientURL(), credentials, namespace, Verbose(verbose))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bareClient.Close()
+
+	// Binding before the repository exists fails
+	if _, err := NewClient(s.ClientURL(), credentials, namespace, InitSchedulesRepository()); err == nil {
+		t.Fatalf("Expected error binding non-existent schedules repository")
+	}
+
+	if err := bareClient.CreateSchedulesRepository(); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewClient(s.ClientURL(), credentials, namespace, Verbose(verbose), InitSchedulesRepository())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	schedules, err := client.ListSchedules()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(schedules) != 0 {
+		t.Fatalf("Expected no schedules, got %d", len(schedules))
+	}
+
+	for _, name := range []string{"weekly", "nightly"} {
+		schedule, err := core.NewSchedule(name, "@daily", core.JobParameters{GitRef: "main"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.CreateSchedule(schedule); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	duplicate, _ := core.NewSchedule("nightly", "@hourly", core.JobParameters{})
+	if err := client.CreateSchedule(duplicate); err == nil {
+		t.Fatalf("Expected error creating duplicate schedule")
+	}
+
+	schedule, revision, err := client.LoadSchedule("nightly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedule.Paused = true
+	if _, err := client.UpdateSchedule(schedule, revision); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stale revision
+	if _, err := client.UpdateSchedule(schedule, revision); !errors.Is(err, core.ErrScheduleConflict) {
+		t.Fatalf("Expected conflict updating schedule with stale revision, got: %v", err)
+	}
+
+	schedules, err = client.ListSchedules()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(schedules) != 2 {
+		t.Fatalf("Expected 2 schedules, got %d", len(schedules))
+	} else if schedules[0].Name != "nightly" || !schedules[0].Paused || schedules[1].Name != "weekly" {
+		t.Fatalf("Unexpected schedules: %+v, %+v", schedules[0], schedules[1])
+	}
+
+	if err := client.DeleteSchedule("weekly"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DeleteSchedule("weekly"); err == nil {
+		t.Fatalf("Expected error deleting non-existent schedule")
+	}
+	if _, _, err := client.LoadSchedule("weekly"); err == nil {
+		t.Fatalf("Expected error loading deleted schedule")
+	}
+
+	if err := bareClient.DeleteSchedulesRepository(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NamespaceSchemaVersion returns the job schema version of the namespace.
+// Namespaces initialized before schema versioning are version 1.
+func (c *Client) NamespaceSchemaVersion() (int, error) {
+	kve, err := c.jobsRepository.Get(kNamespaceSchemaKey)
+	if err == nats.ErrKeyNotFound {
+		return 1, nil
+	} else if err != nil {
+		return 0, err
+	}
+	schema := core.NamespaceSchema{}
+	if err := json.Unmarshal(kve.Value(), &schema); err != nil {
+		return 0, fmt.Errorf("Failed to load namespace schema: %v", err)
+	}
+	return schema.JobSchemaVersion, nil
+}
+
+func (c *Client) setNamespaceSchemaVersion(kv nats.KeyValue, version int) error {
+	data, err := json.Marshal(core.NamespaceSchema{JobSchemaVersion: version})
+	if err != nil {
+		return err
+	}
+	_, err = kv.Put(kNamespaceSchemaKey, data)
+	return err
+}
+
+// Warn if the namespace schema does not match the one of this client
+func (c *Client) checkNamespaceSchema() error {
+	version, err := c.NamespaceSchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version > core.JobSchemaVersion {
+		c.logWarn("Namespace schema version %d is newer than supported (%d), upgrade go-bench-away", version, core.JobSchemaVersion)
+	} else if version < core.JobSchemaVersion {
+		c.logWarn("Namespace schema version %d is older than current (%d), need to run init -migrate", version, core.JobSchemaVersion)
+	}
+	return nil
+}
+
+// MigrateJobs rewrites job records with an older schema version to the current one, then updates the namespace
+// schema version. Returns the number of records rewritten. Records with a newer schema version are left untouched.
+func (c *Client) MigrateJobs() (int, error) {
+	keys, err := c.jobsRepository.Keys()
+	if err == nats.ErrNoKeysFound {
+		keys = []string{}
+	} else if err != nil {
+		return 0, err
+	}
+
+	migrated, newer := 0, 0
+	for _, key := range keys {
+		if !strings.HasPrefix(key, fmt.Sprintf(kJobRecordKeyTmpl, "")) {
+			continue
+		}
+		kve, err := c.jobsRepository.Get(key)
+		if err == nats.ErrKeyNotFound {
+			continue
+		} else if err != nil {
+			return migrated, err
+		}
+		job, version, err := core.UpgradeJob(kve.Value())
+		if err != nil {
+			return migrated, fmt.Errorf("Failed to load %s: %v", key, err)
+		}
+		if version > core.JobSchemaVersion {
+			c.logWarn("Job %s has schema version %d, newer than supported (%d)", job.Id, version, core.JobSchemaVersion)
+			newer++
+			continue
+		} else if version == core.JobSchemaVersion {
+			continue
+		}
+		c.logDebug("Migrating job %s from schema version %d", job.Id, version)
+		if _, err := c.jobsRepository.Update(key, job.Bytes(), kve.Revision()); err != nil {
+			return migrated, fmt.Errorf("Failed to update %s (modified concurrently?): %v", key, err)
+		}
+		migrated++
+	}
+
+	namespaceVersion, err := c.NamespaceSchemaVersion()
+	if err != nil {
+		return migrated, err
+	}
+	if newer == 0 && namespaceVersion < core.JobSchemaVersion {
+		if err := c.setNamespaceSchemaVersion(c.jobsRepository, core.JobSchemaVersion); err != nil {
+			return migrated, err
+		}
+	}
+	return migrated, nil
+}
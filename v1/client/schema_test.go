@@ -0,0 +1,108 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+	server "github.com/nats-io/nats-server/v2/test"
+)
+
+func TestMigrateJobs(t *testing.T) {
+
+	// Configure local server and start it
+	opts := server.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+
+	s := server.RunServer(&opts)
+	defer s.Shutdown()
+
+	namespace := "test"
+	credentials := ""
+	verbose := true
+
+	bareClient, err := NewClient(s.ClientURL(), credentials, namespace, Verbose(verbose))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bareClient.Close()
+
+	if err := bareClient.CreateJobsRepository(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewClient(s.ClientURL(), credentials, namespace, Verbose(verbose), InitJobsRepository())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// New namespace has the current version
+	if version, err := c.NamespaceSchemaVersion(); err != nil || version != core.JobSchemaVersion {
+		t.Fatalf("Unexpected namespace version: %d (%v)", version, err)
+	}
+
+	// Simulate a namespace created before schema versioning
+	if err := c.jobsRepository.Delete(kNamespaceSchemaKey); err != nil {
+		t.Fatal(err)
+	}
+	if version, err := c.NamespaceSchemaVersion(); err != nil || version != 1 {
+		t.Fatalf("Unexpected namespace version: %d (%v)", version, err)
+	}
+
+	legacyJobIds := []string{
+		"067997a3-761e-475e-9559-f10d7400b835",
+		"dd146049-0137-4ba0-89b1-0a2f8d0a2268",
+	}
+	for _, jobId := range legacyJobIds {
+		record := fmt.Sprintf(`{"Id":"%s","Status":3,"Parameters":{"GitRef":"main"}}`, jobId)
+		if _, err := c.jobsRepository.Put(fmt.Sprintf(kJobRecordKeyTmpl, jobId), []byte(record)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newerJobId := "e98b2caa-df6d-4f12-815c-431db896a9f5"
+	newerRecord := fmt.Sprintf(`{"SchemaVersion":%d,"Id":"%s","Status":0}`, core.JobSchemaVersion+1, newerJobId)
+	if _, err := c.jobsRepository.Put(fmt.Sprintf(kJobRecordKeyTmpl, newerJobId), []byte(newerRecord)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Records of newer schema cannot be updated by this version
+	newerJob, revision, err := c.LoadJob(newerJobId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.UpdateJob(newerJob, revision); !errors.Is(err, core.ErrUnsupportedSchema) {
+		t.Fatalf("Expected unsupported schema error, got: %v", err)
+	}
+
+	if migrated, err := c.MigrateJobs(); err != nil || migrated != 2 {
+		t.Fatalf("Unexpected migration: %d (%v)", migrated, err)
+	}
+	for _, jobId := range legacyJobIds {
+		kve, err := c.jobsRepository.Get(fmt.Sprintf(kJobRecordKeyTmpl, jobId))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, version, err := core.UpgradeJob(kve.Value()); err != nil || version != core.JobSchemaVersion {
+			t.Fatalf("Unexpected version of migrated record: %d (%v)", version, err)
+		}
+	}
+
+	// Namespace not updated while records of a newer version exist
+	if version, err := c.NamespaceSchemaVersion(); err != nil || version != 1 {
+		t.Fatalf("Unexpected namespace version: %d (%v)", version, err)
+	}
+
+	if err := c.jobsRepository.Delete(fmt.Sprintf(kJobRecordKeyTmpl, newerJobId)); err != nil {
+		t.Fatal(err)
+	}
+	if migrated, err := c.MigrateJobs(); err != nil || migrated != 0 {
+		t.Fatalf("Unexpected migration: %d (%v)", migrated, err)
+	}
+	if version, err := c.NamespaceSchemaVersion(); err != nil || version != core.JobSchemaVersion {
+		t.Fatalf("Unexpected namespace version: %d (%v)", version, err)
+	}
+}
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"github.com/mprimi/go-bench-away/v1/core"
+
 	"github.com/nats-io/nats.go"
 )
 
@@ -23,11 +25,33 @@ func (c *Client) CreateJobsQueue() error {
 func (c *Client) CreateJobsRepository() error {
 	c.logDebug("Creating jobs repository %s", c.options.jobsRepositoryName)
 
+	// An existing repository may contain records of older schema versions, see MigrateJobs
+	_, err := c.js.KeyValue(c.options.jobsRepositoryName)
+	newRepository := err == nats.ErrBucketNotFound
+
 	cfg := nats.KeyValueConfig{
 		Bucket:      c.options.jobsRepositoryName,
 		Description: "Job records repository",
 	}
 
+	kv, err := c.js.CreateKeyValue(&cfg)
+	if err != nil {
+		return err
+	}
+	if newRepository {
+		return c.setNamespaceSchemaVersion(kv, core.JobSchemaVersion)
+	}
+	return nil
+}
+
+func (c *Client) CreateJobsIndex() error {
+	c.logDebug("Creating jobs index %s", c.options.jobsIndexName)
+
+	cfg := nats.KeyValueConfig{
+		Bucket:      c.options.jobsIndexName,
+		Description: "Job records search index",
+	}
+
 	_, err := c.js.CreateKeyValue(&cfg)
 	if err != nil {
 		return err
@@ -50,6 +74,21 @@ func (c *Client) CreateArtifactsStore() error {
 	return nil
 }
 
+func (c *Client) CreateSchedulesRepository() error {
+	c.logDebug("Creating schedules repository %s", c.options.schedulesRepoName)
+
+	cfg := nats.KeyValueConfig{
+		Bucket:      c.options.schedulesRepoName,
+		Description: "Schedules repository",
+	}
+
+	_, err := c.js.CreateKeyValue(&cfg)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func (c *Client) DeleteJobsQueue() error {
 	c.logDebug("Deleting jobs queue %s", c.options.jobsQueueName)
 
@@ -74,6 +113,18 @@ func (c *Client) DeleteJobsRepository() error {
 	return nil
 }
 
+func (c *Client) DeleteJobsIndex() error {
+	c.logDebug("Deleting jobs index %s", c.options.jobsIndexName)
+
+	err := c.js.DeleteKeyValue(c.options.jobsIndexName)
+	if err == nats.ErrStreamNotFound {
+		//noop
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
 func (c *Client) DeleteArtifactsStore() error {
 	c.logDebug("Deleting artifacts store %s", c.options.artifactsStoreName)
 
@@ -85,3 +136,15 @@ func (c *Client) DeleteArtifactsStore() error {
 	}
 	return nil
 }
+
+func (c *Client) DeleteSchedulesRepository() error {
+	c.logDebug("Deleting schedules repository %s", c.options.schedulesRepoName)
+
+	err := c.js.DeleteKeyValue(c.options.schedulesRepoName)
+	if err == nats.ErrStreamNotFound {
+		//noop
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
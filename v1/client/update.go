@@ -7,6 +7,20 @@ import (
 )
 
 func (c *Client) UpdateJob(job *core.JobRecord, revision uint64) (uint64, error) {
+	// Writing a record of a newer schema would drop the fields unknown to this version
+	if err := job.CheckSchema(); err != nil {
+		return 0, err
+	}
+
 	jobRecordKey := fmt.Sprintf(kJobRecordKeyTmpl, job.Id)
-	return c.jobsRepository.Update(jobRecordKey, job.Bytes(), revision)
+	revision, err := c.jobsRepository.Update(jobRecordKey, job.Bytes(), revision)
+	if err != nil {
+		return 0, err
+	}
+
+	// The record is the source of truth, a stale index entry can be fixed with reindex
+	if err := c.indexJob(job); err != nil {
+		c.logWarn("Failed to index job %s: %v", job.Id, err)
+	}
+	return revision, nil
 }
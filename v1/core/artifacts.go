@@ -0,0 +1,132 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Names of job artifacts. In the artifacts store, the key of an artifact is: jobs/<job id>/<name>
+const (
+	LogArtifact          = "log.txt"
+	ResultsArtifact      = "results.txt"
+	ScriptArtifact       = "run.sh"
+	TestBinaryArtifact   = "test.bin"
+	ResourcesArtifact    = "resources.json"
+	kProfileArtifactTmpl = "profile_%s.pprof"
+)
+
+// Profiles that can be collected while running benchmarks
+var ProfileTypes = []string{"cpu", "mem", "block", "mutex"}
+
+// Flags passed to `go test` to collect each type of profile
+var profileFlags = map[string]string{
+	"cpu":   "-cpuprofile",
+	"mem":   "-memprofile",
+	"block": "-blockprofile",
+	"mutex": "-mutexprofile",
+}
+
+// ProfileArtifact returns the name of the artifact of a profile of the given type
+func ProfileArtifact(profile string) string {
+	return fmt.Sprintf(kProfileArtifactTmpl, profile)
+}
+
+// ProfileFlag returns the `go test` flag that writes a profile of the given type
+func ProfileFlag(profile string) string {
+	return profileFlags[profile]
+}
+
+// ParseProfiles parses a comma-separated list of profile types (e.g. "cpu,mem")
+func ParseProfiles(list string) ([]string, error) {
+	profiles := []string{}
+	for _, profile := range strings.Split(list, ",") {
+		profile = strings.ToLower(strings.TrimSpace(profile))
+		if profile != "" {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles, ValidateProfiles(profiles)
+}
+
+// ValidateProfiles checks a list of profile types has no duplicates or unknown types
+func ValidateProfiles(profiles []string) error {
+	seen := map[string]bool{}
+	for _, profile := range profiles {
+		if _, known := profileFlags[profile]; !known {
+			return fmt.Errorf("invalid profile: '%s' (options: %s)", profile, strings.Join(ProfileTypes, ", "))
+		} else if seen[profile] {
+			return fmt.Errorf("duplicate profile: %s", profile)
+		}
+		seen[profile] = true
+	}
+	return nil
+}
+
+// Artifacts returns the store keys of the job artifacts, by artifact name
+func (jr *JobRecord) Artifacts() map[string]string {
+	artifacts := map[string]string{}
+	for name, key := range map[string]string{
+		LogArtifact:        jr.Log,
+		ResultsArtifact:    jr.Results,
+		ScriptArtifact:     jr.Script,
+		TestBinaryArtifact: jr.TestBinary,
+		ResourcesArtifact:  jr.Resources,
+	} {
+		if key != "" {
+			artifacts[name] = key
+		}
+	}
+	for profile, key := range jr.Profiles {
+		artifacts[ProfileArtifact(profile)] = key
+	}
+	return artifacts
+}
+
+// ArtifactNames returns the names of the job artifacts, sorted
+func (jr *JobRecord) ArtifactNames() []string {
+	names := []string{}
+	for name := range jr.Artifacts() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsArtifactName returns true if name is the name of a job artifact (e.g. LogArtifact)
+func IsArtifactName(name string) bool {
+	return (&JobRecord{}).SetArtifact(name, "") == nil
+}
+
+// SetArtifact sets the store key of an artifact by name, an empty key removes the artifact
+func (jr *JobRecord) SetArtifact(name, key string) error {
+	switch name {
+	case LogArtifact:
+		jr.Log = key
+	case ResultsArtifact:
+		jr.Results = key
+	case ScriptArtifact:
+		jr.Script = key
+	case TestBinaryArtifact:
+		jr.TestBinary = key
+	case ResourcesArtifact:
+		jr.Resources = key
+	default:
+		for _, profile := range ProfileTypes {
+			if name != ProfileArtifact(profile) {
+				continue
+			}
+			if key == "" {
+				delete(jr.Profiles, profile)
+			} else {
+				if jr.Profiles == nil {
+					jr.Profiles = map[string]string{}
+				}
+				jr.Profiles[profile] = key
+			}
+			return nil
+		}
+		return fmt.Errorf("unknown artifact: '%s'", name)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronExpr is a parsed cron-like expression with 5 fields: minute, hour, day of month, month, day of week.
+// Fields support '*', values, ranges ('1-5'), lists ('1,3,5') and steps ('*/15', '0-30/10').
+// Shorthands @hourly, @daily (or @midnight), @weekly, @monthly are also supported.
+// Times are evaluated in UTC.
+type CronExpr struct {
+	expr       string
+	minutes    []bool
+	hours      []bool
+	daysOfMon  []bool
+	months     []bool
+	daysOfWeek []bool
+	// Day of month and day of week restrict together only if both are specified (as in cron)
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+var cronShorthands = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+func ParseCronExpr(expr string) (*CronExpr, error) {
+	spec := strings.TrimSpace(expr)
+	if expanded, found := cronShorthands[spec]; found {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression '%s': expected 5 fields, got %d", expr, len(fields))
+	}
+
+	ranges := []struct {
+		name     string
+		min, max int
+	}{
+		{"minute", 0, 59},
+		{"hour", 0, 23},
+		{"day of month", 1, 31},
+		{"month", 1, 12},
+		{"day of week", 0, 6},
+	}
+
+	parsed := make([][]bool, len(fields))
+	for i, field := range fields {
+		values, err := parseCronField(field, ranges[i].min, ranges[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression '%s': %s: %v", expr, ranges[i].name, err)
+		}
+		parsed[i] = values
+	}
+
+	c := &CronExpr{
+		expr:          expr,
+		minutes:       parsed[0],
+		hours:         parsed[1],
+		daysOfMon:     parsed[2],
+		months:        parsed[3],
+		daysOfWeek:    parsed[4],
+		anyDayOfMonth: strings.HasPrefix(fields[2], "*"),
+		anyDayOfWeek:  strings.HasPrefix(fields[4], "*"),
+	}
+
+	// Reject expressions that never match (e.g. February 30th)
+	if _, found := c.next(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)); !found {
+		return nil, fmt.Errorf("invalid cron expression '%s': never matches", expr)
+	}
+	return c, nil
+}
+
+// Parse a comma-separated list of values, ranges and steps into a set indexed by value
+func parseCronField(field string, min, max int) ([]bool, error) {
+	set := make([]bool, max+1)
+	for _, part := range strings.Split(field, ",") {
+		rangeExpr, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rangeExpr = part[:i]
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step: %s", part)
+			}
+		}
+
+		low, high := min, max
+		switch {
+		case rangeExpr == "*":
+		case strings.Contains(rangeExpr, "-"):
+			bounds := strings.SplitN(rangeExpr, "-", 2)
+			var err1, err2 error
+			low, err1 = strconv.Atoi(bounds[0])
+			high, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("invalid range: %s", part)
+			}
+		default:
+			value, err := strconv.Atoi(rangeExpr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value: %s", part)
+			}
+			low, high = value, value
+			if step > 1 {
+				// 'N/step' means from N to max
+				high = max
+			}
+		}
+
+		if low < min || high > max || low > high {
+			return nil, fmt.Errorf("out of range [%d-%d]: %s", min, max, part)
+		}
+		for v := low; v <= high; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+func (c *CronExpr) String() string {
+	return c.expr
+}
+
+func (c *CronExpr) matchesDay(t time.Time) bool {
+	dayOfMonth, dayOfWeek := c.daysOfMon[t.Day()], c.daysOfWeek[int(t.Weekday())]
+	switch {
+	case c.anyDayOfMonth && c.anyDayOfWeek:
+		return true
+	case c.anyDayOfMonth:
+		return dayOfWeek
+	case c.anyDayOfWeek:
+		return dayOfMonth
+	default:
+		return dayOfMonth || dayOfWeek
+	}
+}
+
+// Next returns the first time matching the expression strictly after the given time
+func (c *CronExpr) Next(after time.Time) time.Time {
+	t, found := c.next(after)
+	if !found {
+		panic(fmt.Sprintf("no matching time found for cron expression: %s", c.expr))
+	}
+	return t
+}
+
+func (c *CronExpr) next(after time.Time) (time.Time, bool) {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	// Matching time is guaranteed within a few years (e.g. Feb 29th)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !c.months[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !c.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !c.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
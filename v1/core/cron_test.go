@@ -0,0 +1,67 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronExpr_Next(t *testing.T) {
+	// Wednesday
+	now := time.Date(2022, 11, 16, 10, 30, 15, 0, time.UTC)
+
+	testCases := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2022, 11, 16, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2022, 11, 16, 10, 45, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2022, 11, 17, 2, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2022, 11, 17, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2022, 11, 16, 11, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2022, 11, 17, 10, 30, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2022, 11, 17, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2022, 11, 20, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 2 *", time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{"15,45 8-9 * 1 *", time.Date(2023, 1, 1, 8, 15, 0, 0, time.UTC)},
+		// Day of month OR day of week, when both are restricted
+		{"0 0 20 * 5", time.Date(2022, 11, 18, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.expr,
+			func(t *testing.T) {
+				c, err := ParseCronExpr(tc.expr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if next := c.Next(now); !next.Equal(tc.expected) {
+					t.Fatalf("Expected: %v, actual: %v", tc.expected, next)
+				}
+			},
+		)
+	}
+}
+
+func TestCronExpr_ParseError(t *testing.T) {
+	invalid := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 7",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"foo * * * *",
+		"0 0 30 2 *",
+		"@yearly",
+	}
+	for _, expr := range invalid {
+		if _, err := ParseCronExpr(expr); err == nil {
+			t.Fatalf("Expected error for: '%s'", expr)
+		}
+	}
+}
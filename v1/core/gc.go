@@ -0,0 +1,34 @@
+package core
+
+import (
+	"fmt"
+)
+
+// GCReport summarizes a garbage collection of job artifacts
+type GCReport struct {
+	DryRun bool
+	// Jobs examined
+	JobsScanned int
+	// Jobs old enough to be collected, but pinned (directly or via their group)
+	JobsPinned int
+	// Jobs whose artifacts were (or would be, in dry run) deleted
+	JobsCollected    int
+	ArtifactsDeleted int
+	BytesReclaimed   uint64
+}
+
+func (r *GCReport) String() string {
+	verb := "Deleted"
+	if r.DryRun {
+		verb = "Would delete"
+	}
+	return fmt.Sprintf(
+		"%s %d artifacts of %d jobs, reclaiming %.1f MB (%d jobs scanned, %d pinned jobs skipped)",
+		verb,
+		r.ArtifactsDeleted,
+		r.JobsCollected,
+		float64(r.BytesReclaimed)/(1<<20),
+		r.JobsScanned,
+		r.JobsPinned,
+	)
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Go environment variables recorded with each job
+var HostGoEnvVars = []string{"GOARCH", "GOAMD64", "CGO_ENABLED"}
+
+// HostInfo describes the environment a job ran in: properties of the host that affect benchmark results, and
+// indicators of noise (load and free memory) at the start and end of the job. Empty values are unknown.
+type HostInfo struct {
+	CPUModel string
+	NumCPU   int
+	// CPU frequency scaling governor (e.g. performance, powersave)
+	Governor string
+	// Turbo boost and simultaneous multithreading (hyper-threading) state: on, off
+	Turbo string
+	SMT   string
+	// Kernel command line flags that affect scheduling and CPU frequency (e.g. isolcpus=2-3)
+	KernelFlags []string `json:",omitempty"`
+	// Go environment of the toolchain used to run the job (see HostGoEnvVars)
+	GoEnv map[string]string `json:",omitempty"`
+
+	Start HostLoad
+	End   HostLoad
+}
+
+// HostLoad is a sample of the system load
+type HostLoad struct {
+	// Load average (1, 5 and 15 minutes)
+	LoadAvg [3]float64
+	// Memory available (bytes)
+	FreeMemory uint64
+}
+
+// HostProperty is a named property of the host environment, as shown in reports
+type HostProperty struct {
+	Name  string
+	Value string
+}
+
+// Properties returns the properties of the host environment that can affect results, hosts with the same
+// properties are expected to produce comparable results. Load is not included.
+func (hi *HostInfo) Properties() []HostProperty {
+	unknown := func(value string) string {
+		if value == "" {
+			return "?"
+		}
+		return value
+	}
+
+	numCPU := "?"
+	if hi.NumCPU > 0 {
+		numCPU = fmt.Sprintf("%d", hi.NumCPU)
+	}
+
+	goEnv := make([]string, 0, len(hi.GoEnv))
+	for name, value := range hi.GoEnv {
+		goEnv = append(goEnv, fmt.Sprintf("%s=%s", name, value))
+	}
+	sort.Strings(goEnv)
+
+	return []HostProperty{
+		{"CPU", unknown(hi.CPUModel)},
+		{"Cores", numCPU},
+		{"Governor", unknown(hi.Governor)},
+		{"Turbo", unknown(hi.Turbo)},
+		{"SMT", unknown(hi.SMT)},
+		{"Kernel flags", unknown(strings.Join(hi.KernelFlags, " "))},
+		{"Go env", unknown(strings.Join(goEnv, " "))},
+	}
+}
+
+func (hl HostLoad) String() string {
+	return fmt.Sprintf("load %.2f %.2f %.2f, %.1fGB free", hl.LoadAvg[0], hl.LoadAvg[1], hl.LoadAvg[2], float64(hl.FreeMemory)/(1<<30))
+}
+
+// HostDifferences returns the names of host properties that differ between jobs (jobs without host information are
+// ignored)
+func HostDifferences(jobs []*JobRecord) []string {
+	values := map[string]map[string]bool{}
+	names := []string{}
+	for _, job := range jobs {
+		if job.Host == nil {
+			continue
+		}
+		for _, property := range job.Host.Properties() {
+			if values[property.Name] == nil {
+				values[property.Name] = map[string]bool{}
+				names = append(names, property.Name)
+			}
+			values[property.Name][property.Value] = true
+		}
+	}
+
+	differences := []string{}
+	for _, name := range names {
+		if len(values[name]) > 1 {
+			differences = append(differences, name)
+		}
+	}
+	return differences
+}
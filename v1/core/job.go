@@ -2,7 +2,9 @@ package core
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,6 +20,9 @@ const (
 	Cancelled
 )
 
+var ErrJobNotFound = errors.New("Job not found")
+var ErrJobExists = errors.New("Job already exists")
+
 type JobParameters struct {
 	GitRemote       string
 	GitRef          string
@@ -30,6 +35,26 @@ type JobParameters struct {
 	Username        string
 	GoPath          string
 	CleanupCmd      string
+	// Optional label grouping related jobs (e.g. a release, or a set of experiments)
+	Group string `json:",omitempty"`
+	// Profiles to collect while running benchmarks (see ProfileTypes)
+	Profiles []string `json:",omitempty"`
+	// Packages to benchmark, in the tests directory (if empty, the package in the tests directory)
+	Packages []PackageSpec `json:",omitempty"`
+	// Build tags (`go test -tags`)
+	BuildTags []string `json:",omitempty"`
+	// GOMAXPROCS values each benchmark is run with (`go test -cpu`)
+	CPUs []int `json:",omitempty"`
+	// Report memory allocation statistics of benchmarks (`go test -benchmem`)
+	BenchMem bool `json:",omitempty"`
+	// Extra `go test` flags (e.g. -race, -gcflags=-N -l), workers only accept allowed flags
+	TestFlags []string `json:",omitempty"`
+	// Environment variables set when running the job (e.g. GOGC, GOEXPERIMENT), workers only accept allowed variables
+	Env map[string]string `json:",omitempty"`
+	// Name of the worker script template used to run the job (empty for the default script)
+	ScriptTemplate string `json:",omitempty"`
+	// Custom parameters available to the script template (see ParseScriptParam)
+	ScriptParams map[string]string `json:",omitempty"`
 }
 
 type WorkerInfo struct {
@@ -39,6 +64,9 @@ type WorkerInfo struct {
 }
 
 type JobRecord struct {
+	// Version of the record schema, see JobSchemaVersion
+	SchemaVersion int
+
 	Id         string
 	Status     JobStatus
 	Parameters JobParameters
@@ -54,8 +82,23 @@ type JobRecord struct {
 	Log     string
 	Results string
 	Script  string
+	// Profiles by type (e.g. cpu), and the test binary to symbolize them
+	Profiles   map[string]string `json:",omitempty"`
+	TestBinary string            `json:",omitempty"`
+	// Resource usage sampled while running (see ResourceUsage)
+	Resources string `json:",omitempty"`
 
 	WorkerInfo WorkerInfo
+	// Environment of the host that ran the job
+	Host *HostInfo `json:",omitempty"`
+	// Checks of the system quiescence performed before running the job
+	Preflight *PreflightResult `json:",omitempty"`
+
+	// Queue the job was submitted to
+	Queue string `json:",omitempty"`
+
+	// Pinned jobs are exempt from garbage collection of artifacts
+	Pinned bool `json:",omitempty"`
 }
 
 func (jr JobStatus) String() string {
@@ -75,6 +118,16 @@ func (jr JobStatus) String() string {
 	}
 }
 
+// ParseJobStatus parses a status name (case-insensitive)
+func ParseJobStatus(name string) (JobStatus, error) {
+	for _, status := range []JobStatus{Submitted, Running, Failed, Succeeded, Cancelled} {
+		if strings.EqualFold(name, status.String()) {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid job status: %s", name)
+}
+
 func (jr JobStatus) Icon() string {
 	switch jr {
 	case Submitted:
@@ -116,24 +169,27 @@ func (jr *JobRecord) HasResults() bool {
 func NewJob(params JobParameters) *JobRecord {
 	jobId := uuid.New().String()
 	return &JobRecord{
-		Id:         jobId,
-		Status:     Submitted,
-		Parameters: params,
-		Created:    time.Now().Round(1 * time.Second).UTC(),
+		SchemaVersion: JobSchemaVersion,
+		Id:            jobId,
+		Status:        Submitted,
+		Parameters:    params,
+		Created:       time.Now().Round(1 * time.Second).UTC(),
 	}
 }
 
+// LoadJob loads a job record, upgrading it to the current schema version if necessary (see UpgradeJob)
 func LoadJob(data []byte) (*JobRecord, error) {
-	job := JobRecord{}
-	err := json.Unmarshal(data, &job)
-	if err != nil {
-		return nil, err
-	}
-	return &job, nil
+	job, _, err := UpgradeJob(data)
+	return job, err
 }
 
+// Bytes serializes the record, records without schema version (e.g. not created with NewJob) get the current one
 func (jr *JobRecord) Bytes() []byte {
-	bytes, err := json.Marshal(jr)
+	record := *jr
+	if record.SchemaVersion == 0 {
+		record.SchemaVersion = JobSchemaVersion
+	}
+	bytes, err := json.Marshal(&record)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to serialize job: %v", err))
 	}
@@ -144,3 +144,257 @@ func TestJobStatus_IconAndString(t *testing.T) {
 		}
 	}
 }
+
+func TestScheduleSerialization(t *testing.T) {
+	s, err := NewSchedule("nightly-main", "0 2 * * *", JobParameters{
+		GitRemote: "https://example.com/foo/bar",
+		GitRef:    "main",
+		Reps:      5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.NextRun.Hour() != 2 || s.NextRun.Minute() != 0 || !s.NextRun.After(s.Created) {
+		t.Fatalf("Unexpected next run: %v", s.NextRun)
+	}
+	if s.IsDue(s.Created) {
+		t.Fatalf("Schedule should not be due at creation")
+	}
+	if !s.IsDue(s.NextRun) {
+		t.Fatalf("Schedule should be due at next run")
+	}
+
+	runTime := s.NextRun
+	if err := s.Advance(runTime); err != nil {
+		t.Fatal(err)
+	}
+	s.AddJob("job-1")
+	if !s.NextRun.Equal(runTime.Add(24*time.Hour)) || s.LastJobId() != "job-1" {
+		t.Fatalf("Unexpected schedule after run: %+v", s)
+	}
+
+	s.Paused = true
+	if s.IsDue(s.NextRun) {
+		t.Fatalf("Paused schedule should not be due")
+	}
+
+	loaded, err := LoadSchedule(s.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(s, loaded) {
+		t.Fatalf("Schedules mismatch: \nS1: %v\nS2: %v", s, loaded)
+	}
+
+	// Oldest jobs are dropped from the series
+	for i := 2; i <= MaxScheduleJobs+1; i++ {
+		s.AddJob(fmt.Sprintf("job-%d", i))
+	}
+	if len(s.JobIds) != MaxScheduleJobs || s.JobIds[0] != "job-2" || s.LastJobId() != fmt.Sprintf("job-%d", MaxScheduleJobs+1) {
+		t.Fatalf("Unexpected series jobs: %d, %s ... %s", len(s.JobIds), s.JobIds[0], s.LastJobId())
+	}
+
+	if _, err := NewSchedule("nightly main", "0 2 * * *", JobParameters{}); err == nil {
+		t.Fatalf("Expected error for invalid name")
+	}
+	if _, err := NewSchedule("nightly", "0 2 * *", JobParameters{}); err == nil {
+		t.Fatalf("Expected error for invalid cron expression")
+	}
+}
+
+func TestJobsQuery(t *testing.T) {
+	job := NewJob(JobParameters{GitRemote: "https://github.com/nats-io/nats-server.git", GitRef: "main", Username: "alice"})
+	job.SHA = "a1b2c3d4e5f6"
+	job.Queue = "default"
+
+	failed := Failed
+	since, err := ParseQueryTime(job.Created.Format("2006-01-02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		query   JobsQuery
+		matches bool
+	}{
+		{JobsQuery{}, true},
+		{JobsQuery{Username: "alice", Queue: "default"}, true},
+		{JobsQuery{Username: "bob"}, false},
+		{JobsQuery{Ref: "main"}, true},
+		{JobsQuery{Ref: "A1B2C3"}, true},
+		{JobsQuery{Ref: "a1b"}, false},
+		{JobsQuery{Status: &failed}, false},
+		{JobsQuery{Since: since, Until: job.Created.Add(time.Second)}, true},
+		{JobsQuery{Until: job.Created}, false},
+	}
+	for i, tc := range testCases {
+		if tc.query.Matches(job) != tc.matches {
+			t.Fatalf("Test case %d: expected match: %v", i, tc.matches)
+		}
+	}
+
+	if status, err := ParseJobStatus("succeeded"); err != nil || status != Succeeded {
+		t.Fatalf("Failed to parse status: %v", err)
+	}
+	if _, err := ParseJobStatus("done"); err == nil {
+		t.Fatalf("Expected error for invalid status")
+	}
+	if _, err := ParseQueryTime("yesterday"); err == nil {
+		t.Fatalf("Expected error for invalid time")
+	}
+}
+
+func TestJobArtifacts(t *testing.T) {
+	profiles, err := ParseProfiles(" CPU,mem,,block ")
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(profiles, []string{"cpu", "mem", "block"}) {
+		t.Fatalf("Unexpected profiles: %v", profiles)
+	}
+	if _, err := ParseProfiles("cpu,trace"); err == nil {
+		t.Fatalf("Expected error for unknown profile")
+	}
+	if _, err := ParseProfiles("cpu,cpu"); err == nil {
+		t.Fatalf("Expected error for duplicate profile")
+	}
+
+	job := NewJob(JobParameters{Profiles: profiles})
+	for _, name := range []string{ResultsArtifact, TestBinaryArtifact, ProfileArtifact("cpu")} {
+		if err := job.SetArtifact(name, "jobs/"+job.Id+"/"+name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectedNames := []string{"profile_cpu.pprof", "results.txt", "test.bin"}
+	if names := job.ArtifactNames(); !reflect.DeepEqual(names, expectedNames) {
+		t.Fatalf("Unexpected artifacts: %v", names)
+	} else if job.Profiles["cpu"] != "jobs/"+job.Id+"/profile_cpu.pprof" {
+		t.Fatalf("Unexpected profiles: %v", job.Profiles)
+	}
+
+	if err := job.SetArtifact(ProfileArtifact("cpu"), ""); err != nil || len(job.Profiles) != 0 {
+		t.Fatalf("Failed to remove profile: %v", err)
+	}
+	if err := job.SetArtifact("profile_trace.pprof", "foo"); err == nil || IsArtifactName("job.json") {
+		t.Fatalf("Expected error for unknown artifact")
+	}
+}
+
+func TestHostDifferences(t *testing.T) {
+	host := func(governor string, goEnv map[string]string) *HostInfo {
+		return &HostInfo{
+			CPUModel: "Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz",
+			NumCPU:   28,
+			Governor: governor,
+			GoEnv:    goEnv,
+			Start:    HostLoad{LoadAvg: [3]float64{float64(len(governor)), 0, 0}},
+		}
+	}
+
+	jobs := []*JobRecord{
+		{Host: host("performance", map[string]string{"GOARCH": "amd64", "GOAMD64": "v1"})},
+		{Host: host("performance", map[string]string{"GOAMD64": "v1", "GOARCH": "amd64"})},
+		{},
+	}
+	if differences := HostDifferences(jobs); len(differences) != 0 {
+		t.Fatalf("Unexpected differences: %v", differences)
+	}
+
+	jobs = append(jobs, &JobRecord{Host: host("powersave", map[string]string{"GOARCH": "amd64", "GOAMD64": "v3"})})
+	if differences := HostDifferences(jobs); !reflect.DeepEqual(differences, []string{"Governor", "Go env"}) {
+		t.Fatalf("Unexpected differences: %v", differences)
+	}
+}
+
+func TestParseScriptParam(t *testing.T) {
+	if name, value, err := ParseScriptParam("GOPROXY=https://proxy.example.com,direct"); err != nil {
+		t.Fatal(err)
+	} else if name != "GOPROXY" || value != "https://proxy.example.com,direct" {
+		t.Fatalf("Unexpected parameter: %s=%s", name, value)
+	}
+	if name, value, err := ParseScriptParam("make_target="); err != nil || name != "make_target" || value != "" {
+		t.Fatalf("Unexpected parameter: %s=%s (%v)", name, value, err)
+	}
+	for _, param := range []string{"foo", "=bar", "make target=bench", "1st=foo"} {
+		if _, _, err := ParseScriptParam(param); err == nil {
+			t.Fatalf("Expected error parsing '%s'", param)
+		}
+	}
+	if err := ValidateScriptParams(map[string]string{"TARGET": "bench", "with-dash": "x"}); err == nil {
+		t.Fatalf("Expected error for invalid name")
+	}
+}
+
+func TestTestOptions(t *testing.T) {
+	if tags, err := ParseBuildTags("integration, nocgo,"); err != nil || len(tags) != 2 || tags[1] != "nocgo" {
+		t.Fatalf("Unexpected tags: %v (%v)", tags, err)
+	}
+	if _, err := ParseBuildTags("foo bar"); err == nil {
+		t.Fatalf("Expected error for invalid tag")
+	}
+	if cpus, err := ParseCPUList("1,2,4"); err != nil || len(cpus) != 3 || cpus[2] != 4 {
+		t.Fatalf("Unexpected CPUs: %v (%v)", cpus, err)
+	}
+	for _, list := range []string{"1,two", "0", "-1"} {
+		if _, err := ParseCPUList(list); err == nil {
+			t.Fatalf("Expected error parsing '%s'", list)
+		}
+	}
+	if name, value, err := ParseEnvVar("GODEBUG=gctrace=1"); err != nil || name != "GODEBUG" || value != "gctrace=1" {
+		t.Fatalf("Unexpected variable: %s=%s (%v)", name, value, err)
+	}
+	if _, _, err := ParseEnvVar("GOGC"); err == nil {
+		t.Fatalf("Expected error for missing value")
+	}
+	for flag, name := range map[string]string{"-race": "race", "--gcflags=-N -l": "gcflags", "race": "", "-": ""} {
+		if TestFlagName(flag) != name {
+			t.Fatalf("Unexpected name of flag '%s': '%s'", flag, TestFlagName(flag))
+		}
+	}
+
+	params := JobParameters{TestFlags: []string{"-race"}, Env: map[string]string{"GOGC": "off"}}
+	if err := params.ValidateTestOptions(); err != nil {
+		t.Fatal(err)
+	}
+	params.Env["NOT VALID"] = "1"
+	if err := params.ValidateTestOptions(); err == nil {
+		t.Fatalf("Expected error for invalid variable name")
+	}
+}
+
+func TestParsePackageSpec(t *testing.T) {
+	if ps, err := ParsePackageSpec("./server/...=BenchmarkJetStream.*"); err != nil {
+		t.Fatal(err)
+	} else if ps.Pattern != "./server/..." || ps.Filter != "BenchmarkJetStream.*" || ps.String() != "./server/...=BenchmarkJetStream.*" {
+		t.Fatalf("Unexpected package: %+v", ps)
+	}
+	if ps, err := ParsePackageSpec("./jetstream"); err != nil || ps.Pattern != "./jetstream" || ps.Filter != "" {
+		t.Fatalf("Unexpected package: %+v (%v)", ps, err)
+	}
+	for _, spec := range []string{"", "=Benchmark", "-race"} {
+		if _, err := ParsePackageSpec(spec); err == nil {
+			t.Fatalf("Expected error parsing '%s'", spec)
+		}
+	}
+
+	params := JobParameters{
+		Packages: []PackageSpec{{Pattern: "./server"}, {Pattern: "./jetstream"}},
+		Profiles: []string{"cpu"},
+	}
+	if err := params.ValidatePackages(); err == nil {
+		t.Fatalf("Expected error collecting profiles of multiple packages")
+	}
+	params.Packages = params.Packages[:1]
+	if err := params.ValidatePackages(); err != nil {
+		t.Fatal(err)
+	}
+	for _, pattern := range []string{"./server/...", "./..."} {
+		params.Packages = []PackageSpec{{Pattern: pattern}}
+		if err := params.ValidatePackages(); err == nil {
+			t.Fatalf("Expected error collecting profiles of pattern %s", pattern)
+		}
+	}
+	params.Profiles = nil
+	if err := params.ValidatePackages(); err != nil {
+		t.Fatal(err)
+	}
+}
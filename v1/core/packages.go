@@ -0,0 +1,60 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PackageSpec selects packages to benchmark, and the benchmarks to run in them
+type PackageSpec struct {
+	// Package pattern, relative to the tests directory (e.g. ./server/...)
+	Pattern string
+	// Filter expression of benchmarks in the packages (defaults to the job filter)
+	Filter string `json:",omitempty"`
+}
+
+func (ps PackageSpec) String() string {
+	if ps.Filter == "" {
+		return ps.Pattern
+	}
+	return fmt.Sprintf("%s=%s", ps.Pattern, ps.Filter)
+}
+
+// ParsePackageSpec parses a package pattern with an optional filter, in the form pattern[=filter]
+func ParsePackageSpec(spec string) (PackageSpec, error) {
+	pattern, filter, _ := strings.Cut(spec, "=")
+	ps := PackageSpec{Pattern: strings.TrimSpace(pattern), Filter: filter}
+	return ps, validatePackageSpec(ps)
+}
+
+// ValidatePackages checks the package specifications of a job are well-formed.
+// Profiles can only be collected when benchmarking a single package (go test refuses profiling flags otherwise), i.e.
+// a single pattern without wildcard.
+func (p *JobParameters) ValidatePackages() error {
+	for _, ps := range p.Packages {
+		if err := validatePackageSpec(ps); err != nil {
+			return err
+		}
+	}
+	if len(p.Profiles) == 0 {
+		return nil
+	}
+	if len(p.Packages) > 1 {
+		return fmt.Errorf("profiles can only be collected when benchmarking a single package")
+	}
+	for _, ps := range p.Packages {
+		if strings.Contains(ps.Pattern, "...") {
+			return fmt.Errorf("profiles can only be collected when benchmarking a single package, pattern '%s' can match multiple", ps.Pattern)
+		}
+	}
+	return nil
+}
+
+func validatePackageSpec(ps PackageSpec) error {
+	if ps.Pattern == "" {
+		return fmt.Errorf("invalid package '%s': empty pattern", ps)
+	} else if strings.HasPrefix(ps.Pattern, "-") {
+		return fmt.Errorf("invalid package '%s': pattern cannot start with '-'", ps)
+	}
+	return nil
+}
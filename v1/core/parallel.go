@@ -0,0 +1,71 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ParallelErrors holds the outcome of each item processed by ForEachParallel, nil for items that succeeded
+type ParallelErrors []error
+
+// Failed returns the number of items that failed
+func (pe ParallelErrors) Failed() int {
+	failed := 0
+	for _, err := range pe {
+		if err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Err returns nil if all items succeeded, otherwise the first error (wrapped, if more than one item failed)
+func (pe ParallelErrors) Err() error {
+	failed := pe.Failed()
+	for _, err := range pe {
+		if err == nil {
+			continue
+		} else if failed == 1 {
+			return err
+		}
+		return fmt.Errorf("%w (and %d more errors)", err, failed-1)
+	}
+	return nil
+}
+
+// ForEachParallel calls f for each item index in [0, n), with at most concurrency calls in progress at any time.
+// Items not yet started when the context is cancelled fail with the context error.
+// Returns after all calls have completed, with the error of each item.
+func ForEachParallel(ctx context.Context, n, concurrency int, f func(ctx context.Context, i int) error) ParallelErrors {
+	errs := make(ParallelErrors, n)
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	wg := sync.WaitGroup{}
+	semaphore := make(chan struct{}, concurrency)
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		case semaphore <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+				return
+			}
+			errs[i] = f(ctx, i)
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
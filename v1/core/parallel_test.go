@@ -0,0 +1,66 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEachParallel(t *testing.T) {
+	const n, concurrency = 20, 4
+
+	var inProgress, maxInProgress int32
+	results := make([]int, n)
+	errs := ForEachParallel(context.Background(), n, concurrency, func(_ context.Context, i int) error {
+		current := atomic.AddInt32(&inProgress, 1)
+		defer atomic.AddInt32(&inProgress, -1)
+		for {
+			max := atomic.LoadInt32(&maxInProgress)
+			if current <= max || atomic.CompareAndSwapInt32(&maxInProgress, max, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		if i%10 == 3 {
+			return fmt.Errorf("item %d: %w", i, ErrJobNotFound)
+		}
+		results[i] = i * i
+		return nil
+	})
+
+	if maxInProgress > concurrency {
+		t.Fatalf("Concurrency exceeded: %d", maxInProgress)
+	}
+	if errs.Failed() != 2 || errs[3] == nil || errs[13] == nil {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	if err := errs.Err(); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i, result := range results {
+		if errs[i] == nil && result != i*i {
+			t.Fatalf("Unexpected result %d: %d", i, result)
+		}
+	}
+
+	if err := ForEachParallel(context.Background(), n, concurrency, func(context.Context, int) error { return nil }).Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Items not started before cancellation fail
+	ctx, cancel := context.WithCancel(context.Background())
+	var started int32
+	errs = ForEachParallel(ctx, n, 1, func(_ context.Context, i int) error {
+		if atomic.AddInt32(&started, 1) == 2 {
+			cancel()
+		}
+		return nil
+	})
+	if started >= n || !errors.Is(errs.Err(), context.Canceled) || errs[n-1] == nil {
+		t.Fatalf("Expected cancellation, started: %d, errors: %v", started, errs)
+	}
+}
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PreflightResult is the outcome of the checks performed by the worker before running a job, to verify the system
+// is quiet enough to produce reliable results
+type PreflightResult struct {
+	// Checks of the last attempt
+	Checks []PreflightCheck
+	// Number of times checks were performed (the worker waits and retries if they fail)
+	Attempts int
+}
+
+// PreflightCheck is the outcome of a single check (e.g. load average below threshold)
+type PreflightCheck struct {
+	Name    string
+	Passed  bool
+	Message string
+}
+
+// Passed returns true if all checks passed
+func (pr *PreflightResult) Passed() bool {
+	for _, check := range pr.Checks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Warning describes the failed checks, it is empty if all checks passed
+func (pr *PreflightResult) Warning() string {
+	failed := []string{}
+	for _, check := range pr.Checks {
+		if !check.Passed {
+			failed = append(failed, check.Message)
+		}
+	}
+	if len(failed) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Noisy environment: %s", strings.Join(failed, "; "))
+}
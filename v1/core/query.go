@@ -0,0 +1,75 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JobsQuery selects jobs matching all the given criteria (zero-value fields match any job)
+type JobsQuery struct {
+	Status    *JobStatus
+	Username  string
+	GitRemote string
+	// Matches the job Git reference, or a prefix (at least 4 characters) of the commit SHA
+	Ref string
+	// Created at or after
+	Since time.Time
+	// Created before
+	Until      time.Time
+	WorkerHost string
+	Queue      string
+	Group      string
+
+	// Pagination, results are sorted newest first
+	Offset int
+	// Maximum number of results, 0 for unlimited
+	Limit int
+}
+
+// Minimum length of a SHA prefix matched by the Ref filter
+const MinSHAPrefixLength = 4
+
+func (q *JobsQuery) matchesRef(job *JobRecord) bool {
+	if q.Ref == "" || q.Ref == job.Parameters.GitRef {
+		return true
+	}
+	return len(q.Ref) >= MinSHAPrefixLength && job.SHA != "" && strings.HasPrefix(job.SHA, strings.ToLower(q.Ref))
+}
+
+func (q *JobsQuery) Matches(job *JobRecord) bool {
+	p := job.Parameters
+	return (q.Status == nil || *q.Status == job.Status) &&
+		(q.Username == "" || q.Username == p.Username) &&
+		(q.GitRemote == "" || q.GitRemote == p.GitRemote) &&
+		q.matchesRef(job) &&
+		(q.Since.IsZero() || !job.Created.Before(q.Since)) &&
+		(q.Until.IsZero() || job.Created.Before(q.Until)) &&
+		(q.WorkerHost == "" || q.WorkerHost == job.WorkerInfo.Hostname) &&
+		(q.Queue == "" || q.Queue == job.Queue) &&
+		(q.Group == "" || q.Group == p.Group)
+}
+
+// Page returns the slice of (already sorted) results selected by Offset and Limit
+func (q *JobsQuery) Page(jobs []*JobRecord) []*JobRecord {
+	if q.Offset >= len(jobs) {
+		return []*JobRecord{}
+	}
+	jobs = jobs[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(jobs) {
+		jobs = jobs[:q.Limit]
+	}
+	return jobs
+}
+
+// ParseQueryTime parses a time for the Since and Until filters, either RFC3339 or a date (YYYY-MM-DD, UTC)
+func ParseQueryTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time: '%s' (expected RFC3339 or YYYY-MM-DD)", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ResourceUsage is a time series of resource usage samples, taken at a fixed interval while a job runs
+type ResourceUsage struct {
+	Interval time.Duration
+	Samples  []ResourceSample
+}
+
+// ResourceSample is the resource usage of the job process tree (and of the host) at some point during a job
+type ResourceSample struct {
+	// Time since the job started
+	Elapsed time.Duration
+	// CPU time (user and system) of the process tree, including exited processes
+	CPUTime time.Duration
+	// Resident memory of the process tree (bytes)
+	RSS uint64
+	// Context switches of the process tree
+	VoluntaryCtxSwitches   uint64
+	InvoluntaryCtxSwitches uint64
+	// Utilization of all host CPUs since the previous sample (percent)
+	HostCPU float64
+}
+
+func LoadResourceUsage(data []byte) (*ResourceUsage, error) {
+	usage := ResourceUsage{}
+	err := json.Unmarshal(data, &usage)
+	if err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+func (ru *ResourceUsage) Bytes() []byte {
+	bytes, err := json.Marshal(ru)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to serialize resource usage: %v", err))
+	}
+	return bytes
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Schedule submits a job periodically, according to a cron expression.
+// Jobs submitted by a schedule form a named series (the schedule name).
+type Schedule struct {
+	Name     string
+	CronExpr string
+	// Template for the parameters of each job submitted
+	Parameters JobParameters
+	// Report specification (JSON) used to regenerate the series report after each job, empty for the default trend report
+	ReportSpec json.RawMessage `json:",omitempty"`
+	// Number of most recent (successful) jobs included in the series report, 0 for all the jobs retained in JobIds
+	ReportJobsLimit int
+	Paused          bool
+
+	Created time.Time
+	// Time of the last and next job submission
+	LastRun time.Time
+	NextRun time.Time
+
+	// Most recent jobs submitted (at most MaxScheduleJobs), oldest first
+	JobIds []string
+	// Key of the series report artifact, and ID of the last job included in it
+	Report          string
+	ReportLastJobId string
+}
+
+// MaxScheduleJobs is the number of most recent jobs retained in a schedule series, older ones are dropped
+const MaxScheduleJobs = 500
+
+// ErrScheduleConflict is returned when updating a schedule that was modified since it was loaded
+var ErrScheduleConflict = errors.New("Schedule was modified concurrently")
+
+var scheduleNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+func NewSchedule(name, cronExpr string, params JobParameters) (*Schedule, error) {
+	if !scheduleNameRegex.MatchString(name) {
+		return nil, fmt.Errorf("invalid schedule name: '%s' (allowed characters: letters, digits, '-', '_')", name)
+	}
+	cron, err := ParseCronExpr(cronExpr)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Round(1 * time.Second).UTC()
+	return &Schedule{
+		Name:       name,
+		CronExpr:   cronExpr,
+		Parameters: params,
+		Created:    now,
+		NextRun:    cron.Next(now),
+	}, nil
+}
+
+func LoadSchedule(data []byte) (*Schedule, error) {
+	schedule := Schedule{}
+	err := json.Unmarshal(data, &schedule)
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (s *Schedule) Bytes() []byte {
+	bytes, err := json.Marshal(s)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to serialize schedule: %v", err))
+	}
+	return bytes
+}
+
+// IsDue returns true if the next job of a (non-paused) schedule should be submitted
+func (s *Schedule) IsDue(now time.Time) bool {
+	return !s.Paused && !now.Before(s.NextRun)
+}
+
+// Advance records a run at the given time, and computes the time of the next one
+func (s *Schedule) Advance(now time.Time) error {
+	cron, err := ParseCronExpr(s.CronExpr)
+	if err != nil {
+		return err
+	}
+	s.LastRun = now.Round(1 * time.Second).UTC()
+	s.NextRun = cron.Next(now)
+	return nil
+}
+
+// LastJobId returns the ID of the most recent job in the series, or an empty string
+func (s *Schedule) LastJobId() string {
+	if len(s.JobIds) == 0 {
+		return ""
+	}
+	return s.JobIds[len(s.JobIds)-1]
+}
+
+// AddJob appends a job to the series, dropping the oldest jobs beyond MaxScheduleJobs
+func (s *Schedule) AddJob(jobId string) {
+	s.JobIds = append(s.JobIds, jobId)
+	if excess := len(s.JobIds) - MaxScheduleJobs; excess > 0 {
+		s.JobIds = append([]string{}, s.JobIds[excess:]...)
+	}
+}
+
+// Resume un-pauses the schedule, runs missed while paused are skipped
+func (s *Schedule) Resume(now time.Time) error {
+	cron, err := ParseCronExpr(s.CronExpr)
+	if err != nil {
+		return err
+	}
+	s.Paused = false
+	s.NextRun = cron.Next(now)
+	return nil
+}
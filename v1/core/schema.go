@@ -0,0 +1,96 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Version of the job record schema written by this version of the code.
+// Records without version (written before versioning was introduced) are version 1.
+//
+// The version is incremented when a change affects how a job runs: new or changed job parameters, which a worker
+// that does not know them would silently ignore (workers refuse jobs with a newer version). Each increment adds an
+// upgrade function for records of the previous version, even if it has nothing to convert.
+// Fields recorded by workers about a run (e.g. Host, Preflight, Resources) do not change the version, as long as they
+// are optional: omitted when empty, and not recorded in older records.
+const JobSchemaVersion = 6
+
+const kSchemaVersionField = "SchemaVersion"
+
+var ErrUnsupportedSchema = errors.New("Unsupported schema version")
+
+// Upgrade a raw record from version N to N+1, modifying it in place
+type jobUpgrade func(record map[string]json.RawMessage) error
+
+// Upgrade functions, jobUpgrades[i] upgrades records from version i+1 to i+2
+var jobUpgrades = []jobUpgrade{
+	// 1 -> 2: adds the version field (SchemaVersion) only, no other field changed
+	func(map[string]json.RawMessage) error { return nil },
+	// 2 -> 3: adds profiling (Parameters.Profiles, Profiles, TestBinary), older records have no profiles
+	func(map[string]json.RawMessage) error { return nil },
+	// 3 -> 4: adds script templates (Parameters.ScriptTemplate, ScriptParams), older records use the default script
+	func(map[string]json.RawMessage) error { return nil },
+	// 4 -> 5: adds `go test` options and environment (Parameters.BuildTags, CPUs, BenchMem, TestFlags, Env)
+	func(map[string]json.RawMessage) error { return nil },
+	// 5 -> 6: adds multiple packages (Parameters.Packages), older records benchmark the package in TestsSubDir
+	func(map[string]json.RawMessage) error { return nil },
+}
+
+// NamespaceSchema is stored in the jobs repository, it records the job schema version of all records in the namespace
+type NamespaceSchema struct {
+	JobSchemaVersion int
+}
+
+// UpgradeJob loads a job record, upgrading it to the current schema version if it is older.
+// Records with a newer version are loaded on a best-effort basis: fields unknown to this version are dropped.
+// Returns the version of the record before the upgrade.
+func UpgradeJob(data []byte) (*JobRecord, int, error) {
+	record := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, 0, err
+	}
+
+	version := 1
+	if rawVersion, found := record[kSchemaVersionField]; found {
+		if err := json.Unmarshal(rawVersion, &version); err != nil {
+			return nil, 0, fmt.Errorf("invalid schema version: %v", err)
+		}
+	}
+	if version < 1 {
+		return nil, 0, fmt.Errorf("invalid schema version: %d", version)
+	}
+
+	if version < JobSchemaVersion {
+		for v := version; v < JobSchemaVersion; v++ {
+			if err := jobUpgrades[v-1](record); err != nil {
+				return nil, 0, fmt.Errorf("failed to upgrade job record from schema version %d: %v", v, err)
+			}
+		}
+		record[kSchemaVersionField] = json.RawMessage(fmt.Sprintf("%d", JobSchemaVersion))
+		var err error
+		if data, err = json.Marshal(record); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	job := JobRecord{}
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, 0, err
+	}
+	return &job, version, nil
+}
+
+// CheckSchema returns an error if the record schema is newer than this version of the code understands
+func (jr *JobRecord) CheckSchema() error {
+	if jr.SchemaVersion > JobSchemaVersion {
+		return fmt.Errorf(
+			"%w: job %s has schema version %d, newer than supported (%d)",
+			ErrUnsupportedSchema,
+			jr.Id,
+			jr.SchemaVersion,
+			JobSchemaVersion,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestUpgradeJob(t *testing.T) {
+
+	// Record written before schema versioning
+	legacyRecord := `{"Id":"067997a3-761e-475e-9559-f10d7400b835","Status":3,"Parameters":{"GitRef":"main","Reps":3}}`
+
+	job, version, err := UpgradeJob([]byte(legacyRecord))
+	if err != nil {
+		t.Fatal(err)
+	} else if version != 1 || job.SchemaVersion != JobSchemaVersion {
+		t.Fatalf("Unexpected version: %d -> %d", version, job.SchemaVersion)
+	} else if job.Status != Succeeded || job.Parameters.Reps != 3 || job.Parameters.Group != "" {
+		t.Fatalf("Unexpected job: %+v", job)
+	}
+	if err := job.CheckSchema(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Upgraded record is written with the current version
+	written := map[string]interface{}{}
+	if err := json.Unmarshal(job.Bytes(), &written); err != nil {
+		t.Fatal(err)
+	} else if written["SchemaVersion"] != float64(JobSchemaVersion) {
+		t.Fatalf("Unexpected version: %v", written["SchemaVersion"])
+	}
+
+	// Record without version, but not from a loaded record
+	if job, version, err := UpgradeJob((&JobRecord{Id: "foo"}).Bytes()); err != nil || version != JobSchemaVersion || job.SchemaVersion != JobSchemaVersion {
+		t.Fatalf("Unexpected version: %d (%v)", version, err)
+	}
+
+	// Newer records are loaded, but rejected by CheckSchema
+	newerRecord := `{"SchemaVersion":99,"Id":"067997a3-761e-475e-9559-f10d7400b835","Status":0,"NewField":"foo"}`
+	job, version, err = UpgradeJob([]byte(newerRecord))
+	if err != nil {
+		t.Fatal(err)
+	} else if version != 99 || job.SchemaVersion != 99 {
+		t.Fatalf("Unexpected version: %d -> %d", version, job.SchemaVersion)
+	}
+	if err := job.CheckSchema(); !errors.Is(err, ErrUnsupportedSchema) {
+		t.Fatalf("Expected unsupported schema error, got: %v", err)
+	}
+
+	for _, invalidRecord := range []string{
+		`{"SchemaVersion":0}`,
+		`{"SchemaVersion":"2"}`,
+		`[]`,
+	} {
+		if _, _, err := UpgradeJob([]byte(invalidRecord)); err == nil {
+			t.Fatalf("Expected error for record: %s", invalidRecord)
+		}
+	}
+
+	if len(jobUpgrades) != JobSchemaVersion-1 {
+		t.Fatalf("Expected %d upgrade functions, found %d", JobSchemaVersion-1, len(jobUpgrades))
+	}
+
+	// Fields recorded by workers without a version change are omitted when empty
+	written = map[string]interface{}{}
+	if err := json.Unmarshal((&JobRecord{Id: "foo"}).Bytes(), &written); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"Host", "Preflight", "Resources"} {
+		if _, found := written[field]; found {
+			t.Fatalf("Unexpected empty field %s in record", field)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Names of script parameters, usable in worker script templates as {{.Params.NAME}}
+var scriptParamNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ParseScriptParam parses a script parameter in the form name=value
+func ParseScriptParam(param string) (string, string, error) {
+	name, value, found := strings.Cut(param, "=")
+	if !found {
+		return "", "", fmt.Errorf("invalid script parameter: '%s' (expected: name=value)", param)
+	}
+	name = strings.TrimSpace(name)
+	if !scriptParamNameRegexp.MatchString(name) {
+		return "", "", fmt.Errorf("invalid script parameter name: '%s' (letters, digits and underscores)", name)
+	}
+	return name, value, nil
+}
+
+// ValidateScriptParams checks the names of script parameters are valid
+func ValidateScriptParams(params map[string]string) error {
+	for name := range params {
+		if !scriptParamNameRegexp.MatchString(name) {
+			return fmt.Errorf("invalid script parameter name: '%s' (letters, digits and underscores)", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// Names of environment variables
+	envVarNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// Build tags, as accepted by `go test -tags`
+	buildTagRegexp = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+	// Extra `go test` flags: -name or -name=value
+	testFlagRegexp = regexp.MustCompile(`^--?([a-z][a-z0-9.]*)(=.*)?$`)
+)
+
+// ParseBuildTags parses a comma-separated list of build tags (e.g. "integration,nocgo")
+func ParseBuildTags(list string) ([]string, error) {
+	tags := []string{}
+	for _, tag := range strings.Split(list, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, validateBuildTags(tags)
+}
+
+// ParseCPUList parses a comma-separated list of GOMAXPROCS values benchmarks are run with (e.g. "1,2,4")
+func ParseCPUList(list string) ([]int, error) {
+	cpus := []int{}
+	for _, value := range strings.Split(list, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		cpu, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU count: '%s'", value)
+		}
+		cpus = append(cpus, cpu)
+	}
+	return cpus, validateCPUList(cpus)
+}
+
+// ParseEnvVar parses an environment variable in the form NAME=value
+func ParseEnvVar(envVar string) (string, string, error) {
+	name, value, found := strings.Cut(envVar, "=")
+	if !found || !envVarNameRegexp.MatchString(name) {
+		return "", "", fmt.Errorf("invalid environment variable: '%s' (expected: NAME=value)", envVar)
+	}
+	return name, value, nil
+}
+
+// TestFlagName returns the name of an extra `go test` flag (e.g. "gcflags" for "-gcflags=-N -l"), or an empty string
+// if the flag is not well-formed
+func TestFlagName(flag string) string {
+	match := testFlagRegexp.FindStringSubmatch(flag)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// ValidateTestOptions checks the `go test` options and environment of a job are well-formed.
+// Workers further restrict which environment variables and extra flags jobs can set.
+func (p *JobParameters) ValidateTestOptions() error {
+	if err := validateBuildTags(p.BuildTags); err != nil {
+		return err
+	}
+	if err := validateCPUList(p.CPUs); err != nil {
+		return err
+	}
+	for name := range p.Env {
+		if !envVarNameRegexp.MatchString(name) {
+			return fmt.Errorf("invalid environment variable name: '%s'", name)
+		}
+	}
+	for _, flag := range p.TestFlags {
+		if TestFlagName(flag) == "" {
+			return fmt.Errorf("invalid test flag: '%s' (expected: -name or -name=value)", flag)
+		}
+	}
+	return nil
+}
+
+func validateBuildTags(tags []string) error {
+	for _, tag := range tags {
+		if !buildTagRegexp.MatchString(tag) {
+			return fmt.Errorf("invalid build tag: '%s'", tag)
+		}
+	}
+	return nil
+}
+
+func validateCPUList(cpus []int) error {
+	for _, cpu := range cpus {
+		if cpu <= 0 {
+			return fmt.Errorf("invalid CPU count: %d", cpu)
+		}
+	}
+	return nil
+}
@@ -3,4 +3,10 @@ package reports
 const (
 	kDeltaTestAlpha = 0.1
 	kCentilePercent = 90.0
+	// Displayed in place of values missing from a job
+	kMissingValueLabel = "—"
+	// Maximum number of jobs (and results) loaded concurrently
+	kMaxConcurrentLoads = 16
+	// Label of benchmark results identifying the package (pkg: <path>)
+	kPackageLabel = "pkg"
 )
@@ -1,7 +1,9 @@
 package reports
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/mprimi/go-bench-away/v1/core"
 	"golang.org/x/perf/benchstat"
@@ -17,6 +19,8 @@ type dataTableImpl struct {
 	collection  benchstat.Collection
 	timeOpTable *benchstat.Table
 	speedTable  *benchstat.Table
+	uncertainty Uncertainty
+	benchmarks  BenchmarksSet
 }
 
 func (dt *dataTableImpl) HasSpeed() bool {
@@ -24,6 +28,11 @@ func (dt *dataTableImpl) HasSpeed() bool {
 }
 
 func CreateDataTable(client JobRecordClient, jobIds ...string) (DataTable, error) {
+	return CreateDataTableWithContext(context.Background(), client, jobIds...)
+}
+
+// CreateDataTableWithContext loads the given jobs and their results (concurrently) and creates a data table
+func CreateDataTableWithContext(ctx context.Context, client JobRecordClient, jobIds ...string) (DataTable, error) {
 	if len(jobIds) == 0 {
 		return nil, fmt.Errorf("No jobs provided")
 	} else if countUnique(jobIds) != len(jobIds) {
@@ -40,13 +49,28 @@ func CreateDataTable(client JobRecordClient, jobIds ...string) (DataTable, error
 		},
 	}
 
-	for i, jobId := range jobIds {
-		job, results, err := loadJobAndResults(client, jobId)
-		if err != nil {
-			return nil, err
+	results := make([][]byte, len(jobIds))
+	errs := core.ForEachParallel(ctx, len(jobIds), kMaxConcurrentLoads, func(_ context.Context, i int) error {
+		var err error
+		dataTable.jobs[i], results[i], err = loadJobAndResults(client, jobIds[i])
+		return err
+	})
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	// Results of jobs benchmarking multiple packages are grouped by package, so benchmarks with the same name in
+	// different packages are not mixed up
+	for _, jobResults := range results {
+		if len(resultsPackages(jobResults)) > 1 {
+			dataTable.collection.SplitBy = []string{kPackageLabel}
+			break
 		}
-		dataTable.jobs[i] = job
-		dataTable.collection.AddConfig(jobId, results)
+	}
+
+	// Results are added in order, which determines the order of columns
+	for i, jobId := range jobIds {
+		dataTable.collection.AddConfig(jobId, results[i])
 	}
 
 	dataTable.jobLabels = createJobLabels(dataTable.jobs)
@@ -56,6 +80,10 @@ func CreateDataTable(client JobRecordClient, jobIds ...string) (DataTable, error
 	}
 
 	for _, table := range dataTable.collection.Tables() {
+		if table.OldNewDelta {
+			addMissingComparisonRows(&dataTable.collection, table)
+		}
+		qualifyBenchmarkNames(table)
 		switch table.Metric {
 		case string(TimeOp):
 			dataTable.timeOpTable = table
@@ -76,3 +104,130 @@ func (dt *dataTableImpl) mapJobs(f func(*core.JobRecord) string) []string {
 	}
 	return mapped
 }
+
+// Package paths found in benchmark results ('pkg:' lines), in order of appearance
+func resultsPackages(results []byte) []string {
+	packages := []string{}
+	seen := map[string]bool{}
+	for _, line := range strings.Split(string(results), "\n") {
+		if pkg := strings.TrimPrefix(line, kPackageLabel+":"); pkg != line {
+			pkg = strings.TrimSpace(pkg)
+			if !seen[pkg] {
+				seen[pkg] = true
+				packages = append(packages, pkg)
+			}
+		}
+	}
+	return packages
+}
+
+// If benchmarks with the same name exist in different packages (groups), prefix their names with the package path,
+// relative to the packages common prefix (e.g. server.Foo and jetstream.Foo)
+func qualifyBenchmarkNames(table *benchstat.Table) {
+	groups := map[string]map[string]bool{}
+	for _, row := range table.Rows {
+		if groups[row.Benchmark] == nil {
+			groups[row.Benchmark] = map[string]bool{}
+		}
+		groups[row.Benchmark][row.Group] = true
+	}
+
+	packages := make([]string, 0, len(table.Groups))
+	for _, group := range table.Groups {
+		packages = append(packages, groupPackage(group))
+	}
+	relativePackages := trimCommonPathPrefix(packages)
+
+	for _, row := range table.Rows {
+		if len(groups[row.Benchmark]) < 2 {
+			continue
+		}
+		for i, group := range table.Groups {
+			if group == row.Group {
+				row.Benchmark = fmt.Sprintf("%s.%s", relativePackages[i], row.Benchmark)
+				break
+			}
+		}
+	}
+}
+
+// Package path of a benchstat group (e.g. "pkg:github.com/nats-io/nats-server/v2/server")
+func groupPackage(group string) string {
+	for _, label := range strings.Fields(group) {
+		if pkg := strings.TrimPrefix(label, kPackageLabel+":"); pkg != label {
+			return pkg
+		}
+	}
+	return group
+}
+
+// Remove the longest common prefix (whole path elements) from the given paths, keeping at least the last element of
+// each path
+func trimCommonPathPrefix(paths []string) []string {
+	elements := make([][]string, len(paths))
+	common := -1
+	for i, path := range paths {
+		elements[i] = strings.Split(path, "/")
+		if common < 0 || len(elements[i])-1 < common {
+			common = len(elements[i]) - 1
+		}
+	}
+	for i := 0; i < common; i++ {
+		for _, e := range elements[1:] {
+			if e[i] != elements[0][i] {
+				common = i
+				break
+			}
+		}
+	}
+
+	trimmed := make([]string, len(paths))
+	for i, e := range elements {
+		trimmed[i] = strings.Join(e[common:], "/")
+	}
+	return trimmed
+}
+
+// benchstat omits rows of a comparison table (2 result sets) if either job is missing the benchmark.
+// Add those rows back (with no delta), so missing data is handled the same regardless of the number of jobs.
+func addMissingComparisonRows(c *benchstat.Collection, table *benchstat.Table) {
+	unit := ""
+	for _, metrics := range table.Rows[0].Metrics {
+		if !isMissing(metrics) {
+			unit = metrics.Unit
+			break
+		}
+	}
+
+	rows := make([]*benchstat.Row, 0, len(table.Rows))
+	next := 0
+	for _, group := range c.Groups {
+		for _, benchmark := range c.Benchmarks[group] {
+			// Collection order is preserved, existing rows appear in the same order
+			if next < len(table.Rows) && table.Rows[next].Benchmark == benchmark {
+				rows = append(rows, table.Rows[next])
+				next += 1
+				continue
+			}
+
+			row := &benchstat.Row{
+				Benchmark: benchmark,
+				Metrics:   make([]*benchstat.Metrics, len(c.Configs)),
+			}
+			if len(c.Groups) > 1 {
+				row.Group = group
+			}
+			for i, config := range c.Configs {
+				m := c.Metrics[benchstat.Key{Config: config, Group: group, Benchmark: benchmark, Unit: unit}]
+				if m == nil {
+					m = new(benchstat.Metrics)
+				} else if row.Scaler == nil {
+					row.Scaler = benchstat.NewScaler(m.Mean, m.Unit)
+				}
+				row.Metrics[i] = m
+			}
+			rows = append(rows, row)
+		}
+	}
+	table.Rows = rows
+}
@@ -0,0 +1,57 @@
+package reports
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_trimCommonPathPrefix(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  []string
+	}{
+		{
+			name:  "siblings",
+			paths: []string{"github.com/nats-io/nats-server/v2/server", "github.com/nats-io/nats-server/v2/jetstream"},
+			want:  []string{"server", "jetstream"},
+		},
+		{
+			name:  "nested",
+			paths: []string{"a/b", "a/b/c", "a/d"},
+			want:  []string{"b", "b/c", "d"},
+		},
+		{
+			name:  "single path keeps last element",
+			paths: []string{"github.com/nats-io/nats-server/v2/server"},
+			want:  []string{"server"},
+		},
+		{
+			name:  "identical paths keep last element",
+			paths: []string{"a/b", "a/b"},
+			want:  []string{"b", "b"},
+		},
+		{
+			name:  "no common prefix",
+			paths: []string{"a/b", "c/d"},
+			want:  []string{"a/b", "c/d"},
+		},
+		{
+			name:  "prefix of another path",
+			paths: []string{"a", "a/b"},
+			want:  []string{"a", "a/b"},
+		},
+		{
+			name:  "empty",
+			paths: []string{},
+			want:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if trimmed := trimCommonPathPrefix(tt.paths); !reflect.DeepEqual(trimmed, tt.want) {
+				t.Errorf("trimCommonPathPrefix() = %v, want %v", trimmed, tt.want)
+			}
+		})
+	}
+}
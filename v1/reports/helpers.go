@@ -7,7 +7,6 @@ import (
 	"regexp"
 	"time"
 
-	"github.com/montanaflynn/stats"
 	"github.com/mprimi/go-bench-away/v1/core"
 	"golang.org/x/perf/benchstat"
 )
@@ -108,19 +107,15 @@ func resetChartId() {
 	chartCounter = 0
 }
 
-func valueDeviationAndScaledString(m *benchstat.Metrics) (float64, float64, string) {
-	if len(m.RValues) == 0 {
-		return 0, 0, "no data"
+// Append the benchmark filter expression (if any) to a section subtext
+func subTextWithFilter(subtext string, filter *regexp.Regexp) string {
+	if filter == nil {
+		return subtext
 	}
-	mean := m.Mean
-	scaler := benchstat.NewScaler(mean, m.Unit)
-	centile, err := stats.Percentile(m.RValues, kCentilePercent)
-	if err != nil {
-		panic(fmt.Sprintf("Failed to calculate percentile for %T %+v: %v", m, m, err))
+	if subtext == "" {
+		return fmt.Sprintf("Filter: '%s'", filter)
 	}
-	deviation := centile - mean
-	scaledString := fmt.Sprintf("%s ± %s", scaler(mean), scaler(deviation))
-	return mean, deviation, scaledString
+	return fmt.Sprintf("%s, benchmarks filter: '%s'", subtext, filter)
 }
 
 func filterByBenchmarkName(inputRows []*benchstat.Row, filter *regexp.Regexp) []*benchstat.Row {
@@ -137,11 +132,62 @@ func filterByBenchmarkName(inputRows []*benchstat.Row, filter *regexp.Regexp) []
 	return outputRows
 }
 
-func compileFilter(filterExpr string) *regexp.Regexp {
+// True if the job did not produce values for this metric
+func isMissing(m *benchstat.Metrics) bool {
+	return len(m.RValues) == 0
+}
+
+// Count the jobs that have values in this row
+func countPresent(row *benchstat.Row) int {
+	present := 0
+	for _, m := range row.Metrics {
+		if !isMissing(m) {
+			present += 1
+		}
+	}
+	return present
+}
+
+// Select the rows to present, based on the benchmark filter and the set of benchmarks selected for the report.
+// Rows without values for any job are always dropped.
+func (dt *dataTableImpl) selectRows(table *benchstat.Table, filter *regexp.Regexp) []*benchstat.Row {
+	inputRows := filterByBenchmarkName(table.Rows, filter)
+	outputRows := make([]*benchstat.Row, 0, len(inputRows))
+	for _, row := range inputRows {
+		present := countPresent(row)
+		if present == 0 || (dt.benchmarks == CommonBenchmarks && present < len(row.Metrics)) {
+			continue
+		}
+		outputRows = append(outputRows, row)
+	}
+	return outputRows
+}
+
+func compileFilter(filterExpr string) (*regexp.Regexp, error) {
 	if filterExpr == "" {
-		return nil
+		return nil, nil
+	}
+	filter, err := regexp.Compile(filterExpr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid benchmarks filter '%s': %v", filterExpr, err)
+	}
+	return filter, nil
+}
+
+// Table of samples for the given metric in a distribution chart, and the title of the values axis
+func (dt *dataTableImpl) samplesTable(metric Metric) (*benchstat.Table, string, error) {
+	switch metric {
+	case TimeOp:
+		return dt.timeOpTable, "Time/op (lower is better)", nil
+	case Speed, Throughput:
+		return dt.speedTable, "Throughput (higher is better)", nil
+	case OpsPerSec:
+		return invertTimeOpTable(dt.timeOpTable, metric), "Operations per second (higher is better)", nil
+	case MsgPerSec:
+		return invertTimeOpTable(dt.timeOpTable, metric), "Messages per second (higher is better)", nil
+	default:
+		return nil, "", fmt.Errorf("Unknown table metric: %s", metric)
 	}
-	return regexp.MustCompile(filterExpr)
 }
 
 // Given a TimeOp table, construct and return a table with inverse values. e.g. 0.1 s/op -> 10 op/s
@@ -218,7 +264,10 @@ func invertTimeOpTable(timeOpTable *benchstat.Table, metric Metric) *benchstat.T
 		}
 
 		// For side-by-side comparison tables (2 result sets), recalculate percentage differences
-		if timeOpTable.OldNewDelta {
+		if timeOpTable.OldNewDelta && countPresent(timeOpRow) < 2 {
+			// One of the two jobs is missing this benchmark, no delta
+			opsPerSecondRow.Delta = timeOpRow.Delta
+		} else if timeOpTable.OldNewDelta {
 			if len(opsPerSecondRow.Metrics) != 2 {
 				panic(fmt.Sprintf("unexpected number of metrics in comparison table: %d", len(opsPerSecondRow.Metrics)))
 			}
@@ -289,3 +289,29 @@ func Test_invertTimeOpTable(t *testing.T) {
 		})
 	}
 }
+
+func Test_samplesTable(t *testing.T) {
+	timeOpTable := &benchstat.Table{Metric: string(TimeOp), Rows: []*benchstat.Row{}}
+	speedTable := &benchstat.Table{Metric: string(Speed), Rows: []*benchstat.Row{}}
+	dt := &dataTableImpl{timeOpTable: timeOpTable, speedTable: speedTable}
+
+	expectedTables := map[Metric]string{
+		TimeOp:     string(TimeOp),
+		Speed:      string(Speed),
+		Throughput: string(Speed),
+		OpsPerSec:  string(OpsPerSec),
+		MsgPerSec:  string(MsgPerSec),
+	}
+	for metric, expectedMetric := range expectedTables {
+		table, title, err := dt.samplesTable(metric)
+		if err != nil {
+			t.Fatalf("%s: %v", metric, err)
+		} else if table.Metric != expectedMetric || title == "" {
+			t.Fatalf("%s: unexpected table %s, title: '%s'", metric, table.Metric, title)
+		}
+	}
+
+	if _, _, err := dt.samplesTable(Metric("foo")); err == nil {
+		t.Fatalf("Expected error for unknown metric")
+	}
+}
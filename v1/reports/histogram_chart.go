@@ -0,0 +1,74 @@
+package reports
+
+import (
+	"fmt"
+)
+
+type histogramTrace struct {
+	Name   string
+	Values []float64
+}
+
+type histogramChart struct {
+	ChartId       string
+	BenchmarkName string
+	Traces        []histogramTrace
+}
+
+type histogramChartSection struct {
+	baseSection
+	Metric        Metric
+	ChartId       string
+	NumBenchmarks int
+	Charts        []histogramChart
+}
+
+func (s *histogramChartSection) fillData(dt *dataTableImpl) error {
+	table, xTitle, err := dt.samplesTable(s.Metric)
+	if err != nil {
+		return err
+	}
+	s.XTitle = xTitle
+	s.YTitle = "Samples"
+
+	rows := dt.selectRows(table, s.BenchmarkFilter)
+
+	s.NumBenchmarks = len(rows)
+	s.Charts = make([]histogramChart, s.NumBenchmarks)
+
+	for i, row := range rows {
+		c := &s.Charts[i]
+		c.ChartId = fmt.Sprintf("%s_%d", s.ChartId, i)
+		c.BenchmarkName = row.Benchmark
+		c.Traces = make([]histogramTrace, 0, len(row.Metrics))
+		for j, m := range row.Metrics {
+			// Jobs that did not run this benchmark do not get a trace
+			if len(m.Values) == 0 {
+				continue
+			}
+			c.Traces = append(c.Traces, histogramTrace{
+				Name:   dt.jobLabels[j],
+				Values: m.Values,
+			})
+		}
+	}
+	return nil
+}
+
+func Histogram(title string, metric Metric, filterExpr string) SectionConfig {
+	if title == "" {
+		title = fmt.Sprintf("%s samples distribution", metric)
+	}
+	filter, filterErr := compileFilter(filterExpr)
+	return &histogramChartSection{
+		baseSection: baseSection{
+			Type:            "histogram",
+			Title:           title,
+			SubText:         subTextWithFilter("Distribution of individual samples (including outliers), one chart per benchmark", filter),
+			BenchmarkFilter: filter,
+			filterErr:       filterErr,
+		},
+		Metric:  metric,
+		ChartId: uniqueChartName(),
+	}
+}
@@ -9,8 +9,9 @@ import (
 type horizontalBarChartGroup struct {
 	Name            string
 	ExperimentNames []string
-	Averages        []float64
-	Deviation       []float64
+	Averages        []*float64
+	ErrorMinus      []*float64
+	ErrorPlus       []*float64
 	BarLabels       []string
 	HoverLabels     []string
 }
@@ -44,7 +45,12 @@ func (s *horizontalBarChartSection) fillData(dt *dataTableImpl) error {
 		return fmt.Errorf("Unknow table metric: %s", s.Metric)
 	}
 
-	rows := filterByBenchmarkName(table.Rows, s.BenchmarkFilter)
+	s.SubText = subTextWithFilter(
+		fmt.Sprintf("Bars represent %s, error bars represent %s", dt.uncertainty.CenterDescription(), dt.uncertainty.Description()),
+		s.BenchmarkFilter,
+	)
+
+	rows := dt.selectRows(table, s.BenchmarkFilter)
 
 	s.NumBenchmarks = len(rows)
 	experimentNames := make([]string, s.NumBenchmarks)
@@ -59,15 +65,16 @@ func (s *horizontalBarChartSection) fillData(dt *dataTableImpl) error {
 		g := &s.Groups[i]
 		g.Name = dt.jobLabels[i]
 		g.ExperimentNames = experimentNames
-		g.Averages = make([]float64, s.NumBenchmarks)
-		g.Deviation = make([]float64, s.NumBenchmarks)
+		g.Averages = make([]*float64, s.NumBenchmarks)
+		g.ErrorMinus = make([]*float64, s.NumBenchmarks)
+		g.ErrorPlus = make([]*float64, s.NumBenchmarks)
 
 		g.BarLabels = make([]string, s.NumBenchmarks)
 		g.HoverLabels = make([]string, s.NumBenchmarks)
 
 		for j, row := range rows {
 			m := row.Metrics[i]
-			g.Averages[j], g.Deviation[j], g.BarLabels[j] = valueDeviationAndScaledString(m)
+			g.Averages[j], g.ErrorMinus[j], g.ErrorPlus[j], g.BarLabels[j] = dt.uncertainty.optionalValueErrorsAndScaledString(m)
 			g.HoverLabels[j] = g.BarLabels[j]
 		}
 	}
@@ -78,16 +85,13 @@ func HorizontalBarChart(title string, metric Metric, filterExpr string) SectionC
 	if title == "" {
 		title = fmt.Sprintf("%s comparison", metric)
 	}
-	subtext := fmt.Sprintf("Error bars represent %.0f%% confidence interval", kCentilePercent)
-	if filterExpr != "" {
-		subtext = fmt.Sprintf("%s, benchmarks filter: '%s'", subtext, filterExpr)
-	}
+	filter, filterErr := compileFilter(filterExpr)
 	return &horizontalBarChartSection{
 		baseSection: baseSection{
 			Type:            "horizontal_bar_chart",
 			Title:           title,
-			SubText:         subtext,
-			BenchmarkFilter: compileFilter(filterExpr),
+			BenchmarkFilter: filter,
+			filterErr:       filterErr,
 		},
 		Metric:  metric,
 		ChartId: uniqueChartName(),
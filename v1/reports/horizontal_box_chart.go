@@ -12,12 +12,23 @@ type horizontalBoxChartBox struct {
 	Labels []string
 }
 
+// Central value and uncertainty of each benchmark, overlaid on top of the boxes
+type horizontalBoxChartSummary struct {
+	Name           string
+	BenchmarkNames []string
+	Values         []*float64
+	ErrorMinus     []*float64
+	ErrorPlus      []*float64
+	Labels         []string
+}
+
 type horizontalBoxChartSection struct {
 	baseSection
 	Metric        Metric
 	ChartId       string
 	NumBenchmarks int
 	Experiments   []horizontalBoxChartBox
+	Summary       horizontalBoxChartSummary
 }
 
 func (s *horizontalBoxChartSection) fillData(dt *dataTableImpl) error {
@@ -41,11 +52,24 @@ func (s *horizontalBoxChartSection) fillData(dt *dataTableImpl) error {
 		return fmt.Errorf("Unknow table metric: %s", s.Metric)
 	}
 
-	rows := filterByBenchmarkName(table.Rows, s.BenchmarkFilter)
+	s.SubText = subTextWithFilter(
+		fmt.Sprintf("Markers represent %s, error bars represent %s", dt.uncertainty.CenterDescription(), dt.uncertainty.Description()),
+		s.BenchmarkFilter,
+	)
+
+	rows := dt.selectRows(table, s.BenchmarkFilter)
 
 	s.NumBenchmarks = len(rows)
 
 	s.Experiments = make([]horizontalBoxChartBox, s.NumBenchmarks)
+	s.Summary = horizontalBoxChartSummary{
+		Name:           dt.uncertainty.CenterDescription(),
+		BenchmarkNames: make([]string, s.NumBenchmarks),
+		Values:         make([]*float64, s.NumBenchmarks),
+		ErrorMinus:     make([]*float64, s.NumBenchmarks),
+		ErrorPlus:      make([]*float64, s.NumBenchmarks),
+		Labels:         make([]string, s.NumBenchmarks),
+	}
 
 	for i, row := range rows {
 		metrics := row.Metrics[0]
@@ -58,6 +82,10 @@ func (s *horizontalBoxChartSection) fillData(dt *dataTableImpl) error {
 		for j, value := range metrics.Values {
 			s.Experiments[i].Labels[j] = scaler(value)
 		}
+
+		sm := &s.Summary
+		sm.BenchmarkNames[i] = row.Benchmark
+		sm.Values[i], sm.ErrorMinus[i], sm.ErrorPlus[i], sm.Labels[i] = dt.uncertainty.optionalValueErrorsAndScaledString(metrics)
 	}
 	return nil
 }
@@ -66,17 +94,13 @@ func HorizontalBoxChart(title string, metric Metric, filterExpr string) SectionC
 	if title == "" {
 		title = fmt.Sprintf("%s results distribution", metric)
 	}
-	subtext := ""
-	if filterExpr != "" {
-		subtext = fmt.Sprintf("Filter: '%s'", filterExpr)
-	}
-
+	filter, filterErr := compileFilter(filterExpr)
 	return &horizontalBoxChartSection{
 		baseSection: baseSection{
 			Type:            "horizontal_box_chart",
 			Title:           title,
-			SubText:         subtext,
-			BenchmarkFilter: compileFilter(filterExpr),
+			BenchmarkFilter: filter,
+			filterErr:       filterErr,
 		},
 		Metric:  metric,
 		ChartId: uniqueChartName(),
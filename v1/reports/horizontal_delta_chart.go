@@ -45,7 +45,13 @@ func (s *horizontalDeltaChartSection) fillData(dt *dataTableImpl) error {
 		return fmt.Errorf("Input table is not a comparison")
 	}
 
-	rows := filterByBenchmarkName(table.Rows, s.BenchmarkFilter)
+	// Deltas can only be computed for benchmarks present in both jobs
+	rows := make([]*benchstat.Row, 0, len(table.Rows))
+	for _, row := range dt.selectRows(table, s.BenchmarkFilter) {
+		if countPresent(row) == len(row.Metrics) {
+			rows = append(rows, row)
+		}
+	}
 
 	s.NumBenchmarks = len(rows)
 	s.ExperimentNames = make([]string, s.NumBenchmarks)
@@ -76,11 +82,13 @@ func HorizontalDeltaChart(title string, metric Metric, filterExpr string) Sectio
 	if title == "" {
 		title = fmt.Sprintf("Relative %s comparison", metric)
 	}
+	filter, filterErr := compileFilter(filterExpr)
 	return &horizontalDeltaChartSection{
 		baseSection: baseSection{
 			Type:            "horizontal_delta_chart",
 			Title:           title,
-			BenchmarkFilter: compileFilter(filterExpr),
+			BenchmarkFilter: filter,
+			filterErr:       filterErr,
 		},
 		Metric:  metric,
 		ChartId: uniqueChartName(),
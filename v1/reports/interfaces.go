@@ -10,3 +10,15 @@ type JobRecordClient interface {
 	LoadJob(jobId string) (*core.JobRecord, uint64, error)
 	LoadResultsArtifact(*core.JobRecord, io.Writer) error
 }
+
+type JobsQueryClient interface {
+	SearchJobs(q core.JobsQuery) ([]*core.JobRecord, int, error)
+}
+
+type ProfileClient interface {
+	LoadProfileArtifact(job *core.JobRecord, profile string, w io.Writer) error
+}
+
+type ResourcesClient interface {
+	LoadResourcesArtifact(job *core.JobRecord, w io.Writer) error
+}
@@ -0,0 +1,174 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+// ReportJobsSpec selects the jobs included in a report.
+// In JSON, it is either a list of job IDs, or an object with a list of IDs and/or a query.
+type ReportJobsSpec struct {
+	Ids   []string        `json:"ids" description:"Job IDs"`
+	Query *ReportJobQuery `json:"query" description:"Select the most recent jobs matching the given criteria"`
+}
+
+// ReportJobQuery selects the most recent jobs matching all the given criteria (empty fields match any job)
+type ReportJobQuery struct {
+	GitRemote       string `json:"remote" description:"Git remote URL"`
+	GitRef          string `json:"ref" description:"Git reference (branch, tag or SHA)"`
+	TestsSubDir     string `json:"subdir" description:"Tests subdirectory"`
+	TestsFilterExpr string `json:"filter" description:"Tests filter expression (exact match)"`
+	GoPath          string `json:"go_path" description:"Go installation path"`
+	// Job status, defaults to 'succeeded'
+	Status string `json:"status" description:"Job status (default: succeeded)"`
+	// Maximum number of jobs selected, 0 for unlimited
+	Limit int `json:"limit" description:"Maximum number of jobs selected (default: unlimited)"`
+	// Order of the selected jobs in the report: 'created' (default), 'started' or 'completed', oldest first
+	SortBy string `json:"sort_by" description:"Timestamp used to select the most recent jobs and order them oldest to newest"`
+}
+
+func (js *ReportJobsSpec) UnmarshalJSON(data []byte) error {
+	// Short form: list of job IDs
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		return json.Unmarshal(data, &js.Ids)
+	}
+
+	// Avoid recursion into this method
+	type jobsSpec ReportJobsSpec
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode((*jobsSpec)(js))
+}
+
+// Return the timestamp of a job used for sorting
+func (q *ReportJobQuery) sortKey() (func(*core.JobRecord) int64, error) {
+	switch q.SortBy {
+	case "", "created":
+		return func(job *core.JobRecord) int64 { return job.Created.UnixNano() }, nil
+	case "started":
+		return func(job *core.JobRecord) int64 { return job.Started.UnixNano() }, nil
+	case "completed":
+		return func(job *core.JobRecord) int64 { return job.Completed.UnixNano() }, nil
+	default:
+		return nil, fmt.Errorf("invalid jobs query sort_by: %s", q.SortBy)
+	}
+}
+
+func (q *ReportJobQuery) status() (core.JobStatus, error) {
+	if q.Status == "" {
+		return core.Succeeded, nil
+	}
+	status, err := core.ParseJobStatus(q.Status)
+	if err != nil {
+		return 0, fmt.Errorf("invalid jobs query status: %s", q.Status)
+	}
+	return status, nil
+}
+
+func (q *ReportJobQuery) validate() error {
+	if _, err := q.sortKey(); err != nil {
+		return err
+	}
+	if _, err := q.status(); err != nil {
+		return err
+	}
+	if q.Limit < 0 {
+		return fmt.Errorf("invalid jobs query limit: %d", q.Limit)
+	}
+	return nil
+}
+
+func (q *ReportJobQuery) matches(job *core.JobRecord, status core.JobStatus) bool {
+	p := job.Parameters
+	return job.Status == status &&
+		(q.GitRemote == "" || q.GitRemote == p.GitRemote) &&
+		(q.GitRef == "" || q.GitRef == p.GitRef) &&
+		(q.TestsSubDir == "" || q.TestsSubDir == p.TestsSubDir) &&
+		(q.TestsFilterExpr == "" || q.TestsFilterExpr == p.TestsFilterExpr) &&
+		(q.GoPath == "" || q.GoPath == p.GoPath)
+}
+
+// Search query selecting (at least) the jobs matching this query, criteria not supported by the search are applied
+// by selectJobs
+func (q *ReportJobQuery) searchQuery() core.JobsQuery {
+	status, _ := q.status()
+	searchQuery := core.JobsQuery{
+		Status:    &status,
+		GitRemote: q.GitRemote,
+		// Also matches SHA prefixes, exact match is checked by selectJobs
+		Ref: q.GitRef,
+	}
+	// Search results are the most recent by creation, so the limit applies if all other criteria are part of the search.
+	// The search also matches refs as SHA prefixes, so the limit cannot apply before the exact ref match.
+	if (q.SortBy == "" || q.SortBy == "created") && q.GitRef == "" && q.TestsSubDir == "" && q.TestsFilterExpr == "" && q.GoPath == "" {
+		searchQuery.Limit = q.Limit
+	}
+	return searchQuery
+}
+
+// Select matching jobs among the given ones, returns their IDs sorted oldest to newest
+func (q *ReportJobQuery) selectJobs(jobs []*core.JobRecord) ([]string, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+	sortKey, _ := q.sortKey()
+	status, _ := q.status()
+
+	selected := make([]*core.JobRecord, 0, len(jobs))
+	for _, job := range jobs {
+		if q.matches(job, status) {
+			selected = append(selected, job)
+		}
+	}
+
+	// Newest first, to apply the limit
+	sort.SliceStable(selected, func(i, j int) bool { return sortKey(selected[i]) > sortKey(selected[j]) })
+	if q.Limit > 0 && len(selected) > q.Limit {
+		selected = selected[:q.Limit]
+	}
+
+	jobIds := make([]string, len(selected))
+	for i, job := range selected {
+		jobIds[len(selected)-1-i] = job.Id
+	}
+	return jobIds, nil
+}
+
+// JobIds returns the IDs of the jobs selected by the spec: explicit IDs first, followed by query results (if any).
+// Jobs are searched via the client only if the spec contains a query.
+func (js *ReportJobsSpec) JobIds(client JobsQueryClient) ([]string, error) {
+	jobIds := append([]string{}, js.Ids...)
+	if js.Query == nil {
+		return jobIds, nil
+	}
+
+	if err := js.Query.validate(); err != nil {
+		return nil, err
+	}
+
+	jobs, _, err := client.SearchJobs(js.Query.searchQuery())
+	if err != nil {
+		return nil, err
+	}
+
+	queryJobIds, err := js.Query.selectJobs(jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, jobId := range queryJobIds {
+		// Skip jobs already listed explicitly
+		duplicate := false
+		for _, id := range js.Ids {
+			duplicate = duplicate || id == jobId
+		}
+		if !duplicate {
+			jobIds = append(jobIds, jobId)
+		}
+	}
+	return jobIds, nil
+}
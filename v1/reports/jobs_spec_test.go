@@ -0,0 +1,167 @@
+package reports
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+type mockJobsQueryClient struct {
+	jobs []*core.JobRecord
+	// Last search query
+	query *core.JobsQuery
+}
+
+func (m *mockJobsQueryClient) SearchJobs(q core.JobsQuery) ([]*core.JobRecord, int, error) {
+	m.query = &q
+	matching := []*core.JobRecord{}
+	for _, job := range m.jobs {
+		if q.Matches(job) {
+			matching = append(matching, job)
+		}
+	}
+	return q.Page(matching), len(matching), nil
+}
+
+func TestReportJobsSpec_Unmarshal(t *testing.T) {
+	var spec ReportSpec
+	err := json.Unmarshal([]byte(`{"jobs": ["a", "b"]}`), &spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(spec.Jobs.Ids, []string{"a", "b"}) || spec.Jobs.Query != nil {
+		t.Fatalf("Unexpected jobs spec: %+v", spec.Jobs)
+	}
+
+	spec = ReportSpec{}
+	err = json.Unmarshal([]byte(`{"jobs": {"ids": ["a"], "query": {"ref": "main", "limit": 3}}}`), &spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(spec.Jobs.Ids, []string{"a"}) || spec.Jobs.Query == nil || spec.Jobs.Query.GitRef != "main" || spec.Jobs.Query.Limit != 3 {
+		t.Fatalf("Unexpected jobs spec: %+v", spec.Jobs)
+	}
+
+	spec = ReportSpec{}
+	err = json.Unmarshal([]byte(`{"jobs": {"query": {"branch": "main"}}}`), &spec)
+	if err == nil {
+		t.Fatalf("Expected error for unknown query field")
+	}
+}
+
+func TestReportJobsSpec_JobIds(t *testing.T) {
+	t0 := time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC)
+	newJob := func(id, ref string, status core.JobStatus, hoursCreated, hoursCompleted int) *core.JobRecord {
+		return &core.JobRecord{
+			Id:     id,
+			Status: status,
+			Parameters: core.JobParameters{
+				GitRemote: "https://github.com/nats-io/nats-server.git",
+				GitRef:    ref,
+			},
+			Created:   t0.Add(time.Duration(hoursCreated) * time.Hour),
+			Completed: t0.Add(time.Duration(hoursCompleted) * time.Hour),
+		}
+	}
+
+	// Commit of j6 (on a release branch) looks like the ref of j0
+	j6 := newJob("j6", "release", core.Succeeded, 7, 8)
+	j6.SHA = "deadbeef0123456789abcdef0123456789abcdef"
+
+	// Newest first, as returned by the client
+	client := &mockJobsQueryClient{
+		jobs: []*core.JobRecord{
+			j6,
+			newJob("j5", "main", core.Succeeded, 5, 6),
+			newJob("j4", "main", core.Failed, 4, 5),
+			newJob("j3", "dev", core.Succeeded, 3, 4),
+			newJob("j2", "main", core.Succeeded, 2, 9),
+			newJob("j1", "main", core.Succeeded, 1, 2),
+			newJob("j0", "deadbeef", core.Succeeded, 0, 1),
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		spec     ReportJobsSpec
+		expected []string
+		// Limit of the search query
+		searchLimit int
+	}{
+		{
+			"explicit ids",
+			ReportJobsSpec{Ids: []string{"x", "y"}},
+			[]string{"x", "y"},
+			0,
+		},
+		{
+			"succeeded on main",
+			ReportJobsSpec{Query: &ReportJobQuery{GitRef: "main"}},
+			[]string{"j1", "j2", "j5"},
+			0,
+		},
+		{
+			"last 2 by creation",
+			ReportJobsSpec{Query: &ReportJobQuery{GitRef: "main", Limit: 2}},
+			[]string{"j2", "j5"},
+			0,
+		},
+		{
+			"last 2 by creation, any ref",
+			ReportJobsSpec{Query: &ReportJobQuery{Limit: 2}},
+			[]string{"j5", "j6"},
+			2,
+		},
+		{
+			"last by creation, ref matching a newer SHA",
+			ReportJobsSpec{Query: &ReportJobQuery{GitRef: "deadbeef", Limit: 1}},
+			[]string{"j0"},
+			0,
+		},
+		{
+			"last 2 by completion",
+			ReportJobsSpec{Query: &ReportJobQuery{GitRef: "main", Limit: 2, SortBy: "completed"}},
+			[]string{"j5", "j2"},
+			0,
+		},
+		{
+			"last 2 with unsearchable criteria",
+			ReportJobsSpec{Query: &ReportJobQuery{GitRef: "main", TestsSubDir: ".", Limit: 2}},
+			[]string{},
+			0,
+		},
+		{
+			"failed",
+			ReportJobsSpec{Query: &ReportJobQuery{Status: "failed"}},
+			[]string{"j4"},
+			0,
+		},
+		{
+			"explicit ids and query",
+			ReportJobsSpec{Ids: []string{"j3", "j5"}, Query: &ReportJobQuery{GitRef: "main"}},
+			[]string{"j3", "j5", "j1", "j2"},
+			0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				jobIds, err := tc.spec.JobIds(client)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(jobIds, tc.expected) {
+					t.Fatalf("Expected: %v, actual: %v", tc.expected, jobIds)
+				}
+				if tc.spec.Query != nil && client.query.Limit != tc.searchLimit {
+					t.Fatalf("Unexpected search limit: %d", client.query.Limit)
+				}
+			},
+		)
+	}
+}
@@ -1,16 +1,41 @@
 package reports
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/mprimi/go-bench-away/v1/core"
 )
 
 type jobsTableSection struct {
 	baseSection
 	Jobs []*core.JobRecord
+	// Host properties that differ between jobs, highlighted in the table
+	HostDifferences map[string]bool
+	// Warnings shown above the table, e.g. if jobs ran on differing or noisy environments
+	Warnings []string
 }
 
 func (s *jobsTableSection) fillData(dt *dataTableImpl) error {
 	s.Jobs = dt.jobs
+	s.HostDifferences = map[string]bool{}
+	differences := core.HostDifferences(dt.jobs)
+	for _, name := range differences {
+		s.HostDifferences[name] = true
+	}
+	s.Warnings = []string{}
+	if len(differences) > 0 {
+		s.Warnings = append(s.Warnings, fmt.Sprintf("Jobs ran on differing environments (%s), results may not be comparable", strings.Join(differences, ", ")))
+	}
+	noisyJobs := []string{}
+	for _, job := range dt.jobs {
+		if job.Preflight != nil && !job.Preflight.Passed() {
+			noisyJobs = append(noisyJobs, job.Id)
+		}
+	}
+	if len(noisyJobs) > 0 {
+		s.Warnings = append(s.Warnings, fmt.Sprintf("Jobs ran in a noisy environment (failed pre-flight checks): %s", strings.Join(noisyJobs, ", ")))
+	}
 	return nil
 }
 
@@ -0,0 +1,120 @@
+package reports
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+	"golang.org/x/perf/benchstat"
+)
+
+// JSON equivalent of a report: jobs and results of each benchmark, for each metric available
+type jsonReport struct {
+	Title       string             `json:"title"`
+	Uncertainty string             `json:"uncertainty"`
+	Jobs        []jsonReportJob    `json:"jobs"`
+	Results     []jsonReportMetric `json:"results"`
+}
+
+type jsonReportJob struct {
+	Label string          `json:"label"`
+	Job   *core.JobRecord `json:"job"`
+}
+
+type jsonReportMetric struct {
+	Metric     Metric                `json:"metric"`
+	Benchmarks []jsonReportBenchmark `json:"benchmarks"`
+}
+
+type jsonReportBenchmark struct {
+	Name string `json:"name"`
+	// One value per job, null if the benchmark is missing from a job
+	Values []*jsonReportValue `json:"values"`
+	// Comparison of two jobs only, empty if not significant or not comparable
+	Delta    string   `json:"delta,omitempty"`
+	PctDelta *float64 `json:"pct_delta,omitempty"`
+	Note     string   `json:"note,omitempty"`
+}
+
+type jsonReportValue struct {
+	Value      float64 `json:"value"`
+	ErrorMinus float64 `json:"error_minus"`
+	ErrorPlus  float64 `json:"error_plus"`
+	Unit       string  `json:"unit"`
+	Samples    int     `json:"samples"`
+}
+
+// WriteJSONReport writes the data of a report as JSON. Sections are ignored, the report includes all benchmarks
+// for the time/op metric (and speed, if present), using the labels, uncertainty and benchmarks set configured.
+func WriteJSONReport(cfg *ReportConfig, dataTable DataTable, writer io.Writer) error {
+	dt := dataTable.(*dataTableImpl)
+	title, err := cfg.configureDataTable(dt)
+	if err != nil {
+		return err
+	}
+
+	report := jsonReport{
+		Title:       title,
+		Uncertainty: dt.uncertainty.Description(),
+		Jobs:        make([]jsonReportJob, len(dt.jobs)),
+		Results:     []jsonReportMetric{},
+	}
+
+	for i, job := range dt.jobs {
+		report.Jobs[i] = jsonReportJob{
+			Label: dt.jobLabels[i],
+			Job:   job,
+		}
+	}
+
+	tables := map[Metric]*benchstat.Table{
+		TimeOp: dt.timeOpTable,
+		Speed:  dt.speedTable,
+	}
+
+	for _, metric := range []Metric{TimeOp, Speed} {
+		table := tables[metric]
+		if table == nil {
+			continue
+		}
+
+		rows := dt.selectRows(table, nil)
+		metricResults := jsonReportMetric{
+			Metric:     metric,
+			Benchmarks: make([]jsonReportBenchmark, len(rows)),
+		}
+
+		for i, row := range rows {
+			benchmark := &metricResults.Benchmarks[i]
+			benchmark.Name = row.Benchmark
+			benchmark.Values = make([]*jsonReportValue, len(row.Metrics))
+			for j, m := range row.Metrics {
+				if isMissing(m) {
+					continue
+				}
+				value, errMinus, errPlus, _ := dt.uncertainty.valueErrorsAndScaledString(m)
+				benchmark.Values[j] = &jsonReportValue{
+					Value:      value,
+					ErrorMinus: errMinus,
+					ErrorPlus:  errPlus,
+					Unit:       m.Unit,
+					Samples:    len(m.RValues),
+				}
+			}
+			if table.OldNewDelta && countPresent(row) == len(row.Metrics) {
+				benchmark.Delta = row.Delta
+				benchmark.Note = row.Note
+				if row.Delta != "~" {
+					pctDelta := row.PctDelta
+					benchmark.PctDelta = &pctDelta
+				}
+			}
+		}
+
+		report.Results = append(report.Results, metricResults)
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
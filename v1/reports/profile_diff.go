@@ -0,0 +1,222 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+// DefaultProfileDiffRows is the default number of functions in a profile diff table
+const DefaultProfileDiffRows = 20
+
+// ProfileDiff is the change in flat and cumulative value of each function between two profiles of the same type
+type ProfileDiff struct {
+	Profile   string
+	BaseJobId string
+	NewJobId  string
+	// Sample type compared (e.g. cpu), and its unit (e.g. nanoseconds)
+	SampleType string
+	Unit       string
+	BaseTotal  int64
+	NewTotal   int64
+	Functions  []FunctionDiff
+}
+
+type FunctionDiff struct {
+	Name     string
+	BaseFlat int64
+	NewFlat  int64
+	BaseCum  int64
+	NewCum   int64
+}
+
+func (fd FunctionDiff) FlatDelta() int64 {
+	return fd.NewFlat - fd.BaseFlat
+}
+
+func (fd FunctionDiff) CumDelta() int64 {
+	return fd.NewCum - fd.BaseCum
+}
+
+// LoadProfileDiff loads a profile of the given type for two jobs, and computes their difference
+func LoadProfileDiff(c ProfileClient, baseJob, newJob *core.JobRecord, profileType string) (*ProfileDiff, error) {
+	load := func(job *core.JobRecord) (*profile.Profile, error) {
+		buf := bytes.Buffer{}
+		if err := c.LoadProfileArtifact(job, profileType, &buf); err != nil {
+			return nil, err
+		}
+		p, err := profile.Parse(&buf)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s profile of job %s: %v", profileType, job.Id, err)
+		}
+		return p, nil
+	}
+
+	baseProfile, err := load(baseJob)
+	if err != nil {
+		return nil, err
+	}
+	newProfile, err := load(newJob)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := DiffProfiles(baseProfile, newProfile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to compare %s profiles: %v", profileType, err)
+	}
+	diff.Profile = profileType
+	diff.BaseJobId = baseJob.Id
+	diff.NewJobId = newJob.Id
+	return diff, nil
+}
+
+// DiffProfiles computes the flat and cumulative value of each function in two profiles, using the default sample
+// type of the base profile (e.g. cpu time, rather than number of samples). Functions are sorted by largest change
+// in flat value first.
+func DiffProfiles(baseProfile, newProfile *profile.Profile) (*ProfileDiff, error) {
+	baseIndex := defaultSampleIndex(baseProfile)
+	if baseIndex < 0 {
+		return nil, fmt.Errorf("profile has no sample types")
+	}
+	sampleType := baseProfile.SampleType[baseIndex]
+
+	newIndex := -1
+	for i, st := range newProfile.SampleType {
+		if st.Type == sampleType.Type && st.Unit == sampleType.Unit {
+			newIndex = i
+		}
+	}
+	if newIndex < 0 {
+		return nil, fmt.Errorf("sample type %s (%s) not found in both profiles", sampleType.Type, sampleType.Unit)
+	}
+
+	functions := map[string]*FunctionDiff{}
+	function := func(name string) *FunctionDiff {
+		fd, found := functions[name]
+		if !found {
+			fd = &FunctionDiff{Name: name}
+			functions[name] = fd
+		}
+		return fd
+	}
+
+	baseTotal := aggregateProfile(baseProfile, baseIndex, func(name string, flat, cum int64) {
+		fd := function(name)
+		fd.BaseFlat += flat
+		fd.BaseCum += cum
+	})
+	newTotal := aggregateProfile(newProfile, newIndex, func(name string, flat, cum int64) {
+		fd := function(name)
+		fd.NewFlat += flat
+		fd.NewCum += cum
+	})
+
+	diff := &ProfileDiff{
+		SampleType: sampleType.Type,
+		Unit:       sampleType.Unit,
+		BaseTotal:  baseTotal,
+		NewTotal:   newTotal,
+		Functions:  make([]FunctionDiff, 0, len(functions)),
+	}
+	for _, fd := range functions {
+		diff.Functions = append(diff.Functions, *fd)
+	}
+	sort.Slice(diff.Functions, func(i, j int) bool {
+		fi, fj := diff.Functions[i], diff.Functions[j]
+		if d1, d2 := abs(fi.FlatDelta()), abs(fj.FlatDelta()); d1 != d2 {
+			return d1 > d2
+		} else if d1, d2 := abs(fi.CumDelta()), abs(fj.CumDelta()); d1 != d2 {
+			return d1 > d2
+		}
+		return fi.Name < fj.Name
+	})
+	return diff, nil
+}
+
+// Index of the sample type shown by default (as chosen by pprof), -1 if the profile has no sample types
+func defaultSampleIndex(p *profile.Profile) int {
+	for i, st := range p.SampleType {
+		if st.Type == p.DefaultSampleType {
+			return i
+		}
+	}
+	return len(p.SampleType) - 1
+}
+
+// Visit the flat and cumulative value of each function in a profile, returns the total value of all samples.
+// The flat value is attributed to the innermost function of each sample, the cumulative value to every function
+// in the stack (once, even if recursive).
+func aggregateProfile(p *profile.Profile, sampleIndex int, visit func(name string, flat, cum int64)) int64 {
+	var total int64
+	for _, sample := range p.Sample {
+		value := sample.Value[sampleIndex]
+		if value == 0 {
+			continue
+		}
+		total += value
+
+		// Stack of functions, innermost first (inlined functions included)
+		stack := []string{}
+		for _, location := range sample.Location {
+			for _, line := range location.Line {
+				if line.Function != nil {
+					stack = append(stack, line.Function.Name)
+				}
+			}
+			if len(location.Line) == 0 {
+				stack = append(stack, fmt.Sprintf("0x%x", location.Address))
+			}
+		}
+		if len(stack) == 0 {
+			continue
+		}
+
+		visit(stack[0], value, 0)
+		seen := map[string]bool{}
+		for _, name := range stack {
+			if !seen[name] {
+				seen[name] = true
+				visit(name, 0, value)
+			}
+		}
+	}
+	return total
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// Format a profile value for humans, depending on its unit
+func formatProfileValue(value int64, unit string) string {
+	switch unit {
+	case "nanoseconds":
+		d := time.Duration(value)
+		if d > time.Millisecond {
+			d = d.Round(time.Microsecond)
+		}
+		return d.String()
+	case "bytes":
+		return fmt.Sprintf("%.2fMB", float64(value)/(1<<20))
+	default:
+		return fmt.Sprintf("%d", value)
+	}
+}
+
+// Same as formatProfileValue, with an explicit sign
+func formatProfileDelta(delta int64, unit string) string {
+	if delta > 0 {
+		return "+" + formatProfileValue(delta, unit)
+	} else if delta < 0 {
+		return "-" + formatProfileValue(-delta, unit)
+	}
+	return formatProfileValue(0, unit)
+}
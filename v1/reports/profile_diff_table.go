@@ -0,0 +1,99 @@
+package reports
+
+import (
+	"fmt"
+	"strings"
+)
+
+type profileDiffRow struct {
+	FunctionName string
+	// Base flat, new flat, flat delta, base cumulative, new cumulative, cumulative delta
+	Values []resultsCell
+}
+
+type profileDiffTableSection struct {
+	baseSection
+	diff        *ProfileDiff
+	limit       int
+	JobLabels   []string
+	ProfileRows []profileDiffRow
+	// Web UI page showing the differential flame graph (optional)
+	FlameGraphURL string
+}
+
+func (s *profileDiffTableSection) fillData(dt *dataTableImpl) error {
+	if len(dt.jobLabels) != 2 {
+		return fmt.Errorf("Profile diff requires exactly 2 jobs, got %d", len(dt.jobLabels))
+	}
+	s.JobLabels = dt.jobLabels
+
+	diff := s.diff
+	if s.Title == "" {
+		s.Title = fmt.Sprintf("%s profile changes", diff.Profile)
+	}
+	totalDelta := "n/a"
+	if diff.BaseTotal != 0 {
+		totalDelta = fmt.Sprintf("%+.1f%%", 100*float64(diff.NewTotal-diff.BaseTotal)/float64(diff.BaseTotal))
+	}
+	s.SubText = fmt.Sprintf(
+		"Top %d functions by change in flat %s, total: %s → %s (%s)",
+		s.limit,
+		diff.SampleType,
+		formatProfileValue(diff.BaseTotal, diff.Unit),
+		formatProfileValue(diff.NewTotal, diff.Unit),
+		totalDelta,
+	)
+
+	functions := diff.Functions
+	if len(functions) > s.limit {
+		functions = functions[:s.limit]
+	}
+
+	cell := func(value int64, label string) resultsCell {
+		sortKey := float64(value)
+		return resultsCell{SortKey: &sortKey, Label: label}
+	}
+
+	s.ProfileRows = make([]profileDiffRow, len(functions))
+	for i, fd := range functions {
+		s.ProfileRows[i] = profileDiffRow{
+			FunctionName: fd.Name,
+			Values: []resultsCell{
+				cell(fd.BaseFlat, formatProfileValue(fd.BaseFlat, diff.Unit)),
+				cell(fd.NewFlat, formatProfileValue(fd.NewFlat, diff.Unit)),
+				cell(fd.FlatDelta(), formatProfileDelta(fd.FlatDelta(), diff.Unit)),
+				cell(fd.BaseCum, formatProfileValue(fd.BaseCum, diff.Unit)),
+				cell(fd.NewCum, formatProfileValue(fd.NewCum, diff.Unit)),
+				cell(fd.CumDelta(), formatProfileDelta(fd.CumDelta(), diff.Unit)),
+			},
+		}
+	}
+	return nil
+}
+
+// ProfileDiffTable shows the functions whose flat and cumulative values changed most between the profiles of two
+// jobs (at most limit rows, 0 for the default). If webURL is set (base URL of the web UI), it links to the
+// differential flame graph.
+func ProfileDiffTable(diff *ProfileDiff, limit int, webURL string) SectionConfig {
+	if limit <= 0 {
+		limit = DefaultProfileDiffRows
+	}
+	var flameGraphURL string
+	if webURL != "" {
+		flameGraphURL = fmt.Sprintf(
+			"%s/diff/%s/%s/pprof/%s/flamegraph",
+			strings.TrimSuffix(webURL, "/"),
+			diff.BaseJobId,
+			diff.NewJobId,
+			diff.Profile,
+		)
+	}
+	return &profileDiffTableSection{
+		baseSection: baseSection{
+			Type: "profile_diff_table",
+		},
+		diff:          diff,
+		limit:         limit,
+		FlameGraphURL: flameGraphURL,
+	}
+}
@@ -0,0 +1,134 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/pprof/profile"
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+// Create a CPU profile with the given stacks (function names, innermost first) and values (nanoseconds)
+func testCPUProfile(samples map[string]int64) *profile.Profile {
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "samples", Unit: "count"},
+			{Type: "cpu", Unit: "nanoseconds"},
+		},
+		PeriodType: &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+		Period:     10000000,
+	}
+	functions := map[string]*profile.Function{}
+	locations := map[string]*profile.Location{}
+	for stack, value := range samples {
+		sample := &profile.Sample{Value: []int64{value / p.Period, value}}
+		for _, name := range strings.Split(stack, ",") {
+			if _, found := functions[name]; !found {
+				functions[name] = &profile.Function{ID: uint64(len(functions) + 1), Name: name}
+				locations[name] = &profile.Location{
+					ID:   uint64(len(locations) + 1),
+					Line: []profile.Line{{Function: functions[name]}},
+				}
+				p.Function = append(p.Function, functions[name])
+				p.Location = append(p.Location, locations[name])
+			}
+			sample.Location = append(sample.Location, locations[name])
+		}
+		p.Sample = append(p.Sample, sample)
+	}
+	return p
+}
+
+type mockProfileClient map[string]*profile.Profile
+
+func (m mockProfileClient) LoadProfileArtifact(job *core.JobRecord, profileType string, w io.Writer) error {
+	p, found := m[job.Id]
+	if !found {
+		return fmt.Errorf("no %s profile", profileType)
+	}
+	return p.Write(w)
+}
+
+func TestDiffProfiles(t *testing.T) {
+	baseProfile := testCPUProfile(map[string]int64{
+		"encode,marshal,main":  300,
+		"compress,encode,main": 500,
+		"main":                 200,
+	})
+	newProfile := testCPUProfile(map[string]int64{
+		"encode,marshal,main":  300,
+		"compress,encode,main": 900,
+		"alloc,main":           100,
+	})
+
+	diff, err := DiffProfiles(baseProfile, newProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.SampleType != "cpu" || diff.BaseTotal != 1000 || diff.NewTotal != 1300 {
+		t.Fatalf("Unexpected diff: %+v", diff)
+	}
+
+	expected := []FunctionDiff{
+		{Name: "compress", BaseFlat: 500, NewFlat: 900, BaseCum: 500, NewCum: 900},
+		{Name: "main", BaseFlat: 200, NewFlat: 0, BaseCum: 1000, NewCum: 1300},
+		{Name: "alloc", BaseFlat: 0, NewFlat: 100, BaseCum: 0, NewCum: 100},
+		{Name: "encode", BaseFlat: 300, NewFlat: 300, BaseCum: 800, NewCum: 1200},
+		{Name: "marshal", BaseFlat: 0, NewFlat: 0, BaseCum: 300, NewCum: 300},
+	}
+	if len(diff.Functions) != len(expected) {
+		t.Fatalf("Unexpected functions: %+v", diff.Functions)
+	}
+	for i, fd := range expected {
+		if diff.Functions[i] != fd {
+			t.Fatalf("Function %d: expected %+v, got %+v", i, fd, diff.Functions[i])
+		}
+	}
+
+	heapProfile := &profile.Profile{SampleType: []*profile.ValueType{{Type: "inuse_space", Unit: "bytes"}}}
+	if _, err := DiffProfiles(baseProfile, heapProfile); err == nil {
+		t.Fatalf("Expected error for different profile types")
+	}
+}
+
+func TestWriteProfileDiffReport(t *testing.T) {
+	c := mockProfileClient{
+		job1: testCPUProfile(map[string]int64{"compress,main": 500}),
+		job2: testCPUProfile(map[string]int64{"compress,main": 900}),
+	}
+	baseJob, _, _ := mockClient{}.LoadJob(job1)
+	newJob, _, _ := mockClient{}.LoadJob(job2)
+
+	diff, err := LoadProfileDiff(c, baseJob, newJob, "cpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadProfileDiff(c, baseJob, &core.JobRecord{Id: job3}, "cpu"); err == nil {
+		t.Fatalf("Expected error for missing profile")
+	}
+
+	dataTable, err := CreateDataTable(mockClient{}, job1, job2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ReportConfig{}
+	cfg.AddSections(ProfileDiffTable(diff, 1, "http://localhost:8888/"))
+
+	buf := bytes.Buffer{}
+	if err := WriteReport(cfg, dataTable, &buf); err != nil {
+		t.Fatal(err)
+	}
+	report := buf.String()
+	flameGraphURL := fmt.Sprintf("http://localhost:8888/diff/%s/%s/pprof/cpu/flamegraph", job1, job2)
+	for _, s := range []string{"cpu profile changes", flameGraphURL, "<th>compress</th>", "&#43;400ns"} {
+		if !strings.Contains(report, s) {
+			t.Fatalf("Report does not contain '%s'", s)
+		}
+	}
+	if strings.Contains(report, "<th>main</th>") {
+		t.Fatalf("Report contains more rows than the limit")
+	}
+}
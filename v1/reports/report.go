@@ -1,7 +1,8 @@
 package reports
 
 import (
-	_ "embed"
+	"bytes"
+	"embed"
 	"fmt"
 	"html/template"
 	"io"
@@ -11,8 +12,18 @@ import (
 //go:embed html/report.html.tmpl
 var reportHtmlTmpl string
 
+//go:generate curl -sSfL -o html/assets/plotly-2.14.0.min.js https://cdn.plot.ly/plotly-2.14.0.min.js
+
+// Assets inlined into self-contained reports
+//
+//go:embed html/assets
+var reportAssets embed.FS
+
+const kPlotlyJsAsset = "html/assets/plotly-2.14.0.min.js"
+
 type SectionConfig interface {
 	fillData(dt *dataTableImpl) error
+	configError() error
 }
 
 type SectionType string
@@ -24,6 +35,12 @@ type baseSection struct {
 	XTitle          string
 	YTitle          string
 	BenchmarkFilter *regexp.Regexp
+	// Error in the section configuration (e.g. invalid filter), reported when the report is written
+	filterErr error
+}
+
+func (s baseSection) configError() error {
+	return s.filterErr
 }
 
 type Metric string
@@ -36,11 +53,37 @@ const (
 	MsgPerSec  = Metric("msg/s")
 )
 
+// BenchmarksSet selects which benchmarks are presented when jobs did not all run the same set
+type BenchmarksSet string
+
+const (
+	// Benchmarks present in at least one job, missing values are shown as gaps
+	AllBenchmarks = BenchmarksSet("union")
+	// Only benchmarks present in all jobs
+	CommonBenchmarks = BenchmarksSet("intersection")
+)
+
+// ParseBenchmarksSet validates a benchmarks set name, an empty name selects the default (union)
+func ParseBenchmarksSet(name string) (BenchmarksSet, error) {
+	switch set := BenchmarksSet(name); set {
+	case "":
+		return AllBenchmarks, nil
+	case AllBenchmarks, CommonBenchmarks:
+		return set, nil
+	default:
+		return "", fmt.Errorf("unknown benchmarks set: %s (use '%s' or '%s')", name, AllBenchmarks, CommonBenchmarks)
+	}
+}
+
 type ReportConfig struct {
 	Title        string
 	sections     []SectionConfig
 	verbose      bool
 	customLabels []string
+	uncertainty  *Uncertainty
+	benchmarks   BenchmarksSet
+	// Inline JavaScript rather than loading it from the network
+	selfContained bool
 }
 
 func (r *ReportConfig) AddSections(sections ...SectionConfig) *ReportConfig {
@@ -53,6 +96,14 @@ func (r *ReportConfig) Verbose() *ReportConfig {
 	return r
 }
 
+// SelfContained produces a report that embeds the charting library, so it can be viewed offline (web fonts are not
+// embedded, text uses the default sans-serif font of the browser). Fails if the library is not bundled in this build.
+// By default, reports load the charting library and fonts from the network.
+func (r *ReportConfig) SelfContained() *ReportConfig {
+	r.selfContained = true
+	return r
+}
+
 func (r *ReportConfig) Log(format string, args ...any) {
 	if r.verbose {
 		fmt.Printf("[debug] "+format+"\n", args...)
@@ -63,27 +114,66 @@ func (r *ReportConfig) SetCustomLabels(customLabels []string) {
 	r.customLabels = customLabels
 }
 
-func WriteReport(cfg *ReportConfig, dataTable DataTable, writer io.Writer) error {
-	dt := dataTable.(*dataTableImpl)
+// SetUncertainty selects the model used to compute error bars and ± values in all sections
+func (r *ReportConfig) SetUncertainty(uncertainty Uncertainty) error {
+	if err := uncertainty.validate(); err != nil {
+		return err
+	}
+	r.uncertainty = &uncertainty
+	return nil
+}
+
+// SetBenchmarksSet selects whether to present all benchmarks (union) or only the ones common to all jobs (intersection)
+func (r *ReportConfig) SetBenchmarksSet(benchmarks BenchmarksSet) error {
+	if _, err := ParseBenchmarksSet(string(benchmarks)); err != nil {
+		return err
+	}
+	r.benchmarks = benchmarks
+	return nil
+}
+
+// Apply report-level configuration (labels, uncertainty, benchmarks set) to the data table, returns the report title
+func (cfg *ReportConfig) configureDataTable(dt *dataTableImpl) (string, error) {
 	title := cfg.Title
 	if cfg.customLabels != nil || len(cfg.customLabels) > 0 {
 		if len(cfg.customLabels) != len(dt.jobs) {
-			panic(
-				fmt.Sprintf(
-					"Wrong number of custom labels, %d given for %d jobs",
-					len(cfg.customLabels),
-					len(dt.jobs),
-				),
+			return "", fmt.Errorf(
+				"wrong number of custom labels, %d given for %d jobs",
+				len(cfg.customLabels),
+				len(dt.jobs),
 			)
 		}
 		dt.jobLabels = cfg.customLabels
 	}
+	dt.uncertainty = DefaultUncertainty
+	if cfg.uncertainty != nil {
+		dt.uncertainty = *cfg.uncertainty
+	}
+	dt.benchmarks = AllBenchmarks
+	if cfg.benchmarks != "" {
+		dt.benchmarks = cfg.benchmarks
+	}
 	if title == "" {
 		title = fmt.Sprintf("Performance report (%d result sets)", len(dt.jobs))
 	}
+	return title, nil
+}
+
+func WriteReport(cfg *ReportConfig, dataTable DataTable, writer io.Writer) error {
+	dt := dataTable.(*dataTableImpl)
+	title, err := cfg.configureDataTable(dt)
+	if err != nil {
+		return err
+	}
 
 	cfg.Log("Generating report '%s'", title)
 
+	for i, section := range cfg.sections {
+		if err := section.configError(); err != nil {
+			return fmt.Errorf("section %d/%d: %v", i+1, len(cfg.sections), err)
+		}
+	}
+
 	for i, section := range cfg.sections {
 		cfg.Log("Generating section %d/%d: %T: %+v", i+1, len(cfg.sections), section, section)
 		err := section.fillData(dt)
@@ -92,18 +182,33 @@ func WriteReport(cfg *ReportConfig, dataTable DataTable, writer io.Writer) error
 		}
 	}
 
+	var plotlyJs []byte
+	if cfg.selfContained {
+		var err error
+		plotlyJs, err = reportAssets.ReadFile(kPlotlyJsAsset)
+		if err != nil {
+			return fmt.Errorf("charting library is not bundled in this build (run go generate ./v1/reports), cannot create a self-contained report: %v", err)
+		}
+		// Avoid terminating the inline script element early
+		plotlyJs = bytes.ReplaceAll(plotlyJs, []byte("</script"), []byte("<\\/script"))
+	}
+
 	t := template.New("report")
 	t = template.Must(t.Parse(reportHtmlTmpl))
 
 	tv := struct {
-		Title    string
-		Sections []SectionConfig
+		Title         string
+		Sections      []SectionConfig
+		SelfContained bool
+		PlotlyJs      template.JS
 	}{
-		Title:    title,
-		Sections: cfg.sections,
+		Title:         title,
+		Sections:      cfg.sections,
+		SelfContained: plotlyJs != nil,
+		PlotlyJs:      template.JS(plotlyJs),
 	}
 
-	err := t.Execute(writer, tv)
+	err = t.Execute(writer, tv)
 	if err != nil {
 		return err
 	}
@@ -1,32 +1,69 @@
 package reports
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/yaml"
 )
 
+// Field descriptions (`description` tags) are included in the JSON Schema, see ReportSpecJSONSchema
+
 type ReportSpec struct {
-	Title    string              `json:"title"`
-	Sections []ReportSectionSpec `json:"sections"`
-	Labels   []string            `json:"labels"`
+	Title       string                 `json:"title" description:"Report title"`
+	Sections    []ReportSectionSpec    `json:"sections" description:"Report sections, in order"`
+	Labels      []string               `json:"labels" description:"Custom labels for the jobs, one per job"`
+	Uncertainty *ReportUncertaintySpec `json:"uncertainty" description:"Model for error bars and ± values"`
+	Benchmarks  string                 `json:"benchmarks" description:"Benchmarks to include when jobs ran different sets"`
+	Jobs        *ReportJobsSpec        `json:"jobs" description:"Jobs included in the report: list of IDs, or object with IDs and/or query"`
+}
+
+type ReportUncertaintySpec struct {
+	Method     string  `json:"method" description:"Uncertainty method"`
+	Confidence float64 `json:"confidence" description:"Confidence level (percent)"`
 }
 
 type ReportSectionSpec struct {
-	Title               string `json:"title"`
-	Metric              string `json:"metric"`
-	Type                string `json:"type"`
-	BenchmarkFilterExpr string `json:"filter"`
+	Title               string `json:"title" description:"Section title (auto-generated if empty)"`
+	Metric              string `json:"metric" description:"Metric presented"`
+	Type                string `json:"type" description:"Section (chart) type"`
+	BenchmarkFilterExpr string `json:"filter" description:"Regular expression selecting benchmarks by name"`
+	// Results table following the chart is collapsed by default, unless set to false
+	HiddenResultsTable *bool `json:"hidden_results_table" description:"Collapse the results table following the chart (default: true)"`
 }
 
+// Known values of ReportSectionSpec.Type
+var specSectionTypes = []string{
+	"trend_chart",
+	"horizontal_bar_chart",
+	"horizontal_bar_chart_with_delta",
+	"horizontal_box_chart",
+	"horizontal_delta_chart",
+	"histogram",
+	"violin_chart",
+}
+
+// Known values of ReportSectionSpec.Metric
+var specMetrics = []Metric{TimeOp, Speed, Throughput, OpsPerSec, MsgPerSec}
+
+// LoadFile loads a spec in YAML format if the file extension is .yaml or .yml, JSON otherwise
 func (spec *ReportSpec) LoadFile(specPath string) error {
 	f, err := os.Open(specPath)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
-	return spec.Load(f)
+	switch strings.ToLower(filepath.Ext(specPath)) {
+	case ".yaml", ".yml":
+		return spec.LoadYAML(f)
+	default:
+		return spec.Load(f)
+	}
 }
 
 func (spec *ReportSpec) Load(r io.Reader) error {
@@ -40,12 +77,62 @@ func (spec *ReportSpec) Load(r io.Reader) error {
 	return nil
 }
 
+// LoadYAML loads a spec in YAML format, with the same structure and field names as JSON
+func (spec *ReportSpec) LoadYAML(r io.Reader) error {
+	yamlData, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	jsonData, err := yaml.YAMLToJSON(yamlData)
+	if err != nil {
+		return err
+	}
+	return spec.Load(bytes.NewReader(jsonData))
+}
+
+func parseMetric(name string) (Metric, error) {
+	for _, metric := range specMetrics {
+		if name == string(metric) {
+			return metric, nil
+		}
+	}
+	// TODO: handle custom metrics
+	return "", fmt.Errorf("unknown metric: %s", name)
+}
+
 func (spec *ReportSpec) ConfigureReport(reportCfg *ReportConfig) error {
+	// Fail on the first problem, use Validate to list all of them
+	if problems := spec.Validate(0); len(problems) > 0 {
+		return problems[0]
+	}
+
 	// Set title if present in spec
 	if spec.Title != "" {
 		reportCfg.Title = spec.Title
 	}
 
+	// Set uncertainty model, if present
+	if spec.Uncertainty != nil {
+		uncertainty, err := ParseUncertainty(spec.Uncertainty.Method, spec.Uncertainty.Confidence)
+		if err != nil {
+			return err
+		}
+		if err := reportCfg.SetUncertainty(uncertainty); err != nil {
+			return err
+		}
+	}
+
+	// Set benchmarks set (union or intersection), if present
+	if spec.Benchmarks != "" {
+		benchmarks, err := ParseBenchmarksSet(spec.Benchmarks)
+		if err != nil {
+			return err
+		}
+		if err := reportCfg.SetBenchmarksSet(benchmarks); err != nil {
+			return err
+		}
+	}
+
 	// Always add jobs table
 	reportCfg.AddSections(
 		JobsTable(),
@@ -59,21 +146,9 @@ func (spec *ReportSpec) ConfigureReport(reportCfg *ReportConfig) error {
 	for _, sectionSpec := range spec.Sections {
 
 		// Parse metric
-		var metric Metric
-		switch sectionSpec.Metric {
-		case string(TimeOp):
-			metric = TimeOp
-		case string(Speed):
-			metric = Speed
-		case string(Throughput):
-			metric = Throughput
-		case string(OpsPerSec):
-			metric = OpsPerSec
-		case string(MsgPerSec):
-			metric = MsgPerSec
-		default:
-			// TODO: handle custom metrics
-			return fmt.Errorf("unknown metric: %s", sectionSpec.Metric)
+		metric, err := parseMetric(sectionSpec.Metric)
+		if err != nil {
+			return err
 		}
 
 		// Parse section (plot type)
@@ -98,6 +173,12 @@ func (spec *ReportSpec) ConfigureReport(reportCfg *ReportConfig) error {
 			sections = append(sections, HorizontalDeltaChart(sectionSpec.Title, metric, sectionSpec.BenchmarkFilterExpr))
 			isDelta = true
 
+		case "histogram":
+			sections = append(sections, Histogram(sectionSpec.Title, metric, sectionSpec.BenchmarkFilterExpr))
+
+		case "violin_chart":
+			sections = append(sections, ViolinChart(sectionSpec.Title, metric, sectionSpec.BenchmarkFilterExpr))
+
 		default:
 			return fmt.Errorf("unknown section type: %s", sectionSpec.Type)
 		}
@@ -105,9 +186,11 @@ func (spec *ReportSpec) ConfigureReport(reportCfg *ReportConfig) error {
 		// Add plot to report
 		reportCfg.AddSections(sections...)
 
-		// Add table to report (always hidden)
-		// TODO allow configuring hidden or not
-		const hideResultsTable = true
+		// Add table to report (hidden unless configured otherwise)
+		hideResultsTable := true
+		if sectionSpec.HiddenResultsTable != nil {
+			hideResultsTable = *sectionSpec.HiddenResultsTable
+		}
 		if isDelta {
 			reportCfg.AddSections(ResultsDeltaTable(metric, sectionSpec.BenchmarkFilterExpr, hideResultsTable))
 		} else {
@@ -0,0 +1,86 @@
+package reports
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const kReportSpecSchemaId = "https://raw.githubusercontent.com/mprimi/go-bench-away/main/assets/report-spec.schema.json"
+
+// Enumerated values of spec fields, keyed by "<struct>.<field>"
+func specFieldEnums() map[string][]string {
+	metrics := make([]string, len(specMetrics))
+	for i, metric := range specMetrics {
+		metrics[i] = string(metric)
+	}
+	return map[string][]string{
+		"ReportSectionSpec.Metric":     metrics,
+		"ReportSectionSpec.Type":       specSectionTypes,
+		"ReportSpec.Benchmarks":        {string(AllBenchmarks), string(CommonBenchmarks)},
+		"ReportUncertaintySpec.Method": {string(CentileDeviation), string(PercentileRange), string(StandardError), string(BootstrapMean), string(BootstrapMedian)},
+		"ReportJobQuery.Status":        {"succeeded", "failed", "submitted", "running", "cancelled"},
+		"ReportJobQuery.SortBy":        {"created", "started", "completed"},
+	}
+}
+
+// ReportSpecJSONSchema generates the JSON Schema of ReportSpec (which also applies to YAML specs)
+func ReportSpecJSONSchema() ([]byte, error) {
+	schema := typeSchema(reflect.TypeOf(ReportSpec{}), specFieldEnums())
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = kReportSpecSchemaId
+	schema["title"] = "go-bench-away report specification"
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func typeSchema(t reflect.Type, enums map[string][]string) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem(), enums)
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), enums)}
+	case reflect.Struct:
+		properties := map[string]any{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			fieldSchema := typeSchema(field.Type, enums)
+			if description := field.Tag.Get("description"); description != "" {
+				fieldSchema["description"] = description
+			}
+			if values, found := enums[t.Name()+"."+field.Name]; found {
+				fieldSchema["enum"] = values
+			}
+			properties[name] = fieldSchema
+		}
+		objectSchema := map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		// Jobs can also be given as a plain list of IDs
+		if t == reflect.TypeOf(ReportJobsSpec{}) {
+			return map[string]any{
+				"oneOf": []any{
+					map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+					objectSchema,
+				},
+			}
+		}
+		return objectSchema
+	default:
+		panic(fmt.Sprintf("unsupported type in report spec: %v", t))
+	}
+}
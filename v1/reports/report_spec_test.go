@@ -1,7 +1,9 @@
 package reports
 
 import (
+	"bytes"
 	"fmt"
+	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -65,6 +67,10 @@ func TestReportSpec_LoadFile(t *testing.T) {
 			"report_spec_valid_2.json",
 			&validReportCfg2,
 		},
+		{
+			"report_spec_valid_2.yaml",
+			&validReportCfg2,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -164,6 +170,18 @@ func TestReportSpec_ConfigureError(t *testing.T) {
 			"report_spec_invalid_3.json",
 			"unknown section",
 		},
+		{
+			"report_spec_invalid_4.json",
+			"unknown uncertainty method",
+		},
+		{
+			"report_spec_invalid_5.json",
+			"unknown benchmarks set",
+		},
+		{
+			"report_spec_invalid_6.json",
+			"invalid jobs query sort_by",
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -190,3 +208,58 @@ func TestReportSpec_ConfigureError(t *testing.T) {
 		)
 	}
 }
+
+func TestReportSpec_Validate(t *testing.T) {
+	var spec ReportSpec
+	err := spec.LoadFile(filepath.Join("testconfig", "report_spec_invalid_7.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const numJobs = 3
+	problems := spec.Validate(numJobs)
+
+	expectedProblems := []string{
+		"sections[1]: unknown metric: bananas/op",
+		"sections[2]: section type horizontal_delta_chart requires 2 jobs, got 3",
+		"sections[2]: invalid benchmarks filter 'foo(['",
+		"sections[3]: unknown section type: pie_chart",
+	}
+	if len(problems) != len(expectedProblems) {
+		t.Fatalf("Expected %d problems, got %d: %v", len(expectedProblems), len(problems), problems)
+	}
+	for i, problem := range problems {
+		if !strings.HasPrefix(problem.Error(), expectedProblems[i]) {
+			t.Fatalf("Expected problem: \"%s\", actual: \"%s\"", expectedProblems[i], problem)
+		}
+	}
+
+	// Labels count mismatch
+	problems = spec.Validate(2)
+	if !strings.Contains(problems[0].Error(), "wrong number of custom labels, 3 given for 2 jobs") {
+		t.Fatalf("Unexpected problem: %s", problems[0])
+	}
+
+	// Configure fails on the first problem, rather than panic
+	err = spec.ConfigureReport(&ReportConfig{})
+	if err == nil || !strings.Contains(err.Error(), "unknown metric") {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestReportSpecJSONSchema(t *testing.T) {
+	schema, err := ReportSpecJSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The published schema is up to date
+	const publishedSchemaPath = "../../assets/report-spec.schema.json"
+	publishedSchema, err := os.ReadFile(publishedSchemaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(publishedSchema), bytes.TrimSpace(schema)) {
+		t.Fatalf("%s is out of date, regenerate it with: go-bench-away validate-spec -print_schema", publishedSchemaPath)
+	}
+}
@@ -0,0 +1,82 @@
+package reports
+
+import (
+	"fmt"
+)
+
+// SpecError is a problem found in a report spec
+type SpecError struct {
+	// Index of the section the problem refers to, -1 for top-level fields
+	Section int
+	Err     error
+}
+
+func (e *SpecError) Error() string {
+	if e.Section < 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("sections[%d]: %v", e.Section, e.Err)
+}
+
+func (e *SpecError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks the spec and returns all the problems found (if any), as SpecError.
+// If numJobs is greater than zero, the spec is also checked against that number of jobs (e.g. count of labels).
+func (spec *ReportSpec) Validate(numJobs int) []error {
+	var problems []error
+	addProblem := func(section int, format string, args ...any) {
+		problems = append(problems, &SpecError{Section: section, Err: fmt.Errorf(format, args...)})
+	}
+
+	if spec.Uncertainty != nil {
+		if _, err := ParseUncertainty(spec.Uncertainty.Method, spec.Uncertainty.Confidence); err != nil {
+			addProblem(-1, "%v", err)
+		}
+	}
+
+	if _, err := ParseBenchmarksSet(spec.Benchmarks); err != nil {
+		addProblem(-1, "%v", err)
+	}
+
+	if spec.Jobs != nil {
+		if countUnique(spec.Jobs.Ids) != len(spec.Jobs.Ids) {
+			addProblem(-1, "the list of job IDs contains duplicates")
+		}
+		if spec.Jobs.Query != nil {
+			if err := spec.Jobs.Query.validate(); err != nil {
+				addProblem(-1, "%v", err)
+			}
+		}
+	}
+
+	if numJobs > 0 && len(spec.Labels) > 0 && len(spec.Labels) != numJobs {
+		addProblem(-1, "wrong number of custom labels, %d given for %d jobs", len(spec.Labels), numJobs)
+	}
+
+	for i, sectionSpec := range spec.Sections {
+		if _, err := parseMetric(sectionSpec.Metric); err != nil {
+			addProblem(i, "%v", err)
+		}
+
+		knownType := false
+		for _, sectionType := range specSectionTypes {
+			knownType = knownType || sectionSpec.Type == sectionType
+		}
+		if !knownType {
+			addProblem(i, "unknown section type: %s", sectionSpec.Type)
+		}
+
+		isDelta := sectionSpec.Type == "horizontal_delta_chart" || sectionSpec.Type == "horizontal_bar_chart_with_delta"
+		if isDelta && numJobs > 0 && numJobs != 2 {
+			addProblem(i, "section type %s requires 2 jobs, got %d", sectionSpec.Type, numJobs)
+		}
+
+		if _, err := compileFilter(sectionSpec.BenchmarkFilterExpr); err != nil {
+			addProblem(i, "%v", err)
+		}
+	}
+
+	return problems
+}
@@ -8,7 +8,9 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/mprimi/go-bench-away/v1/core"
 )
@@ -213,6 +215,10 @@ func TestWriteCustomReports(t *testing.T) {
 		{name: "custom_labels", jobs: []string{job1, job2}},
 		{name: "compare1", jobs: []string{job1, job2}},
 		{name: "compare2", jobs: []string{job1, job2}},
+		{name: "distribution1", jobs: []string{job1, job2, job3}},
+		{name: "uncertainty1", jobs: []string{job1, job2, job3}},
+		{name: "intersection1", jobs: []string{job1, job2, job3}},
+		{name: "intersection2", jobs: []string{job1, job2}},
 	}
 
 	for _, test := range tests {
@@ -264,6 +270,8 @@ func writeReportAndCompareToExpected(t *testing.T, jobIds []string, reportConfig
 	}
 	defer file.Close()
 
+	// Keep expected reports small, the charting library is not inlined
+
 	err = WriteReport(reportConfig, dataTable, file)
 	if err != nil {
 		t.Fatal(err)
@@ -313,3 +321,248 @@ func assertReportEqual(t *testing.T, reportPath string, expectedReportPath strin
 		t.Fatalf("Report %s does not match expected %s", reportPath, expectedReportPath)
 	}
 }
+
+func TestWriteReport_SelfContained(t *testing.T) {
+	dataTable, err := CreateDataTable(mockClient{}, job1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	externalUrls := []string{"https://cdn.plot.ly", "https://fonts.googleapis.com"}
+
+	// By default, the charting library and fonts are loaded from the network
+	cfg := &ReportConfig{}
+	cfg.AddSections(TrendChart("", TimeOp, ""))
+	var buf bytes.Buffer
+	if err := WriteReport(cfg, dataTable, &buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, externalUrl := range externalUrls {
+		if !bytes.Contains(buf.Bytes(), []byte(externalUrl)) {
+			t.Fatalf("Report does not reference external resource: %s", externalUrl)
+		}
+	}
+
+	// Self-contained reports embed the library, or fail if it is not bundled in this build
+	cfg.SelfContained()
+	buf.Reset()
+	err = WriteReport(cfg, dataTable, &buf)
+	if _, assetErr := reportAssets.ReadFile(kPlotlyJsAsset); assetErr != nil {
+		if err == nil || !strings.Contains(err.Error(), "not bundled") {
+			t.Fatalf("Expected error for charting library not bundled, got: %v", err)
+		}
+		return
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	for _, externalUrl := range externalUrls {
+		if bytes.Contains(buf.Bytes(), []byte(externalUrl)) {
+			t.Fatalf("Report references external resource: %s", externalUrl)
+		}
+	}
+}
+
+func TestWriteJSONReport(t *testing.T) {
+	dataTable, err := CreateDataTable(mockClient{}, job1, job2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &ReportConfig{Title: "JSON report"}
+	cfg.SetCustomLabels([]string{"old", "new"})
+
+	var buf bytes.Buffer
+	err = WriteJSONReport(cfg, dataTable, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := jsonReport{}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Title != "JSON report" {
+		t.Fatalf("Unexpected title: %s", report.Title)
+	} else if len(report.Jobs) != 2 || report.Jobs[0].Label != "old" || report.Jobs[1].Job.Id != job2 {
+		t.Fatalf("Unexpected jobs: %+v", report.Jobs)
+	} else if len(report.Results) != 2 || report.Results[0].Metric != TimeOp || report.Results[1].Metric != Speed {
+		t.Fatalf("Unexpected results metrics: %+v", report.Results)
+	}
+
+	deltas := 0
+	for _, benchmark := range report.Results[0].Benchmarks {
+		if len(benchmark.Values) != 2 {
+			t.Fatalf("Unexpected values for %s: %+v", benchmark.Name, benchmark.Values)
+		}
+		for _, value := range benchmark.Values {
+			if value != nil && (value.Value <= 0 || value.Unit != "ns/op" || value.Samples == 0) {
+				t.Fatalf("Unexpected value for %s: %+v", benchmark.Name, value)
+			}
+		}
+		if benchmark.PctDelta != nil {
+			deltas += 1
+		}
+	}
+	if len(report.Results[0].Benchmarks) == 0 || deltas == 0 {
+		t.Fatalf("Expected benchmarks with significant deltas")
+	}
+
+	// Custom labels must match the number of jobs
+	cfg.SetCustomLabels([]string{"a", "b", "c"})
+	if err := WriteJSONReport(cfg, dataTable, &buf); err == nil {
+		t.Fatalf("Expected error with wrong number of labels")
+	}
+}
+
+func TestWriteReport_HostDifferences(t *testing.T) {
+	dataTable, err := CreateDataTable(mockClient{}, job1, job2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := dataTable.(*dataTableImpl).jobs
+	jobs[0].Host = &core.HostInfo{CPUModel: "Apple M1", NumCPU: 8}
+	jobs[1].Host = &core.HostInfo{CPUModel: "Apple M2", NumCPU: 8}
+	jobs[1].Preflight = &core.PreflightResult{
+		Checks:   []core.PreflightCheck{{Name: "load", Passed: false, Message: "load average 4.00 (max: 1.00)"}},
+		Attempts: 3,
+	}
+
+	cfg := &ReportConfig{Title: "Host differences"}
+	cfg.AddSections(JobsTable())
+
+	var buf bytes.Buffer
+	if err := WriteReport(cfg, dataTable, &buf); err != nil {
+		t.Fatal(err)
+	}
+	report := buf.String()
+	for _, s := range []string{"Jobs ran on differing environments (CPU)", "font-weight: 600\">CPU: Apple M2", "<small>Cores: 8</small>", "Jobs ran in a noisy environment (failed pre-flight checks): " + job2, "⚠️ Noisy environment: load average 4.00"} {
+		if !strings.Contains(report, s) {
+			t.Fatalf("Report does not contain '%s'", s)
+		}
+	}
+}
+
+type mockResourcesClient map[string]*core.ResourceUsage
+
+func (m mockResourcesClient) LoadResourcesArtifact(job *core.JobRecord, w io.Writer) error {
+	usage, found := m[job.Id]
+	if !found {
+		return fmt.Errorf("no resource usage")
+	}
+	_, err := w.Write(usage.Bytes())
+	return err
+}
+
+func TestWriteReport_ResourceUsage(t *testing.T) {
+	dataTable, err := CreateDataTable(mockClient{}, job1, job2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := dataTable.(*dataTableImpl).jobs
+	jobs[0].Resources = "jobs/" + job1 + "/" + core.ResourcesArtifact
+	c := mockResourcesClient{
+		job1: {
+			Interval: time.Second,
+			Samples: []core.ResourceSample{
+				{Elapsed: time.Second, CPUTime: 2 * time.Second, RSS: 64 << 20, VoluntaryCtxSwitches: 100, HostCPU: 25},
+				{Elapsed: 2 * time.Second, CPUTime: 5 * time.Second, RSS: 128 << 20, VoluntaryCtxSwitches: 300, HostCPU: 50},
+			},
+		},
+	}
+
+	usage, err := LoadResourceUsage(c, jobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usage) != 1 || len(usage[job1].Samples) != 2 {
+		t.Fatalf("Unexpected resource usage: %v", usage)
+	}
+	jobs[1].Resources = "jobs/" + job2 + "/" + core.ResourcesArtifact
+	if _, err := LoadResourceUsage(c, jobs); err == nil {
+		t.Fatalf("Expected error for missing artifact")
+	}
+
+	cfg := &ReportConfig{Title: "Resource usage"}
+	cfg.AddSections(ResourceUsageChart("", usage))
+
+	var buf bytes.Buffer
+	if err := WriteReport(cfg, dataTable, &buf); err != nil {
+		t.Fatal(err)
+	}
+	report := buf.String()
+	for _, s := range []string{"<h2>Resource usage</h2>", "no samples recorded for: " + dataTable.(*dataTableImpl).jobLabels[1], `"y": [2,3]`, `"y": [64,128]`, `"y": [100,200]`, "Host CPU (%)"} {
+		if !strings.Contains(report, s) {
+			t.Fatalf("Report does not contain '%s'", s)
+		}
+	}
+}
+
+// Loads job records from testdata, and the given results
+type mockResultsClient struct {
+	mockClient
+	results map[string]string
+}
+
+func (m mockResultsClient) LoadResultsArtifact(record *core.JobRecord, writer io.Writer) error {
+	_, err := io.WriteString(writer, m.results[record.Id])
+	return err
+}
+
+func TestWriteReport_MultiplePackages(t *testing.T) {
+	packageResults := func(pkg string, nsOp ...string) string {
+		results := fmt.Sprintf("goos: linux\ngoarch: amd64\npkg: github.com/nats-io/nats-server/v2/%s\n", pkg)
+		for _, ns := range nsOp {
+			results += fmt.Sprintf("BenchmarkPublish-8 \t 1000 \t %s ns/op\nBenchmark_%s_Only-8 \t 1000 \t %s ns/op\n", ns, pkg, ns)
+		}
+		return results + "PASS\n"
+	}
+	c := mockResultsClient{
+		results: map[string]string{
+			job1: packageResults("server", "100", "110") + packageResults("jetstream", "200", "210"),
+			job2: packageResults("server", "120", "130") + packageResults("jetstream", "300", "310"),
+		},
+	}
+
+	dataTable, err := CreateDataTable(c, job1, job2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, row := range dataTable.(*dataTableImpl).timeOpTable.Rows {
+		names = append(names, row.Benchmark)
+	}
+	expected := "server.Publish-8 _server_Only-8 jetstream.Publish-8 _jetstream_Only-8"
+	if strings.Join(names, " ") != expected {
+		t.Fatalf("Unexpected benchmarks: %v", names)
+	}
+
+	cfg := &ReportConfig{}
+	cfg.AddSections(ResultsTable(TimeOp, "", false))
+	var buf bytes.Buffer
+	if err := WriteReport(cfg, dataTable, &buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"server.Publish-8", "jetstream.Publish-8"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Fatalf("Report does not contain '%s'", s)
+		}
+	}
+
+	// Only one job benchmarks multiple packages, results of all jobs are grouped by package
+	c.results[job2] = packageResults("server", "120", "130")
+	dataTable, err = CreateDataTable(c, job1, job2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names = []string{}
+	for _, row := range dataTable.(*dataTableImpl).timeOpTable.Rows {
+		names = append(names, row.Benchmark)
+		if missing := isMissing(row.Metrics[1]); missing != (groupPackage(row.Group) != "github.com/nats-io/nats-server/v2/server") {
+			t.Fatalf("Unexpected results of second job for %s (missing: %v)", row.Benchmark, missing)
+		}
+	}
+	if strings.Join(names, " ") != expected {
+		t.Fatalf("Unexpected benchmarks: %v", names)
+	}
+}
@@ -0,0 +1,126 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/mprimi/go-bench-away/v1/core"
+)
+
+type resourceUsageSeries struct {
+	JobLabel string
+	// Elapsed time (seconds)
+	X []float64
+	Y []float64
+}
+
+type resourceUsagePlot struct {
+	ChartId string
+	YTitle  string
+	Series  []resourceUsageSeries
+}
+
+type resourceUsageChartSection struct {
+	baseSection
+	usage map[string]*core.ResourceUsage
+	Plots []resourceUsagePlot
+}
+
+func (s *resourceUsageChartSection) fillData(dt *dataTableImpl) error {
+	missing := []string{}
+	for i, job := range dt.jobs {
+		usage := s.usage[job.Id]
+		if usage == nil || len(usage.Samples) == 0 {
+			missing = append(missing, dt.jobLabels[i])
+			continue
+		}
+
+		cpu := resourceUsageSeries{JobLabel: dt.jobLabels[i]}
+		rss := resourceUsageSeries{JobLabel: dt.jobLabels[i]}
+		ctxSwitches := resourceUsageSeries{JobLabel: dt.jobLabels[i]}
+		hostCPU := resourceUsageSeries{JobLabel: dt.jobLabels[i]}
+
+		// Rates are computed over the interval since the previous sample (or since the job started)
+		previous := core.ResourceSample{}
+		for _, sample := range usage.Samples {
+			elapsed := sample.Elapsed.Seconds()
+			interval := (sample.Elapsed - previous.Elapsed).Seconds()
+			if interval <= 0 {
+				continue
+			}
+			switches := sample.VoluntaryCtxSwitches + sample.InvoluntaryCtxSwitches
+			previousSwitches := previous.VoluntaryCtxSwitches + previous.InvoluntaryCtxSwitches
+			// Counters of the process tree decrease when a process exits without being waited for
+			switchesRate := 0.0
+			if switches > previousSwitches {
+				switchesRate = float64(switches-previousSwitches) / interval
+			}
+			cpuRate := 0.0
+			if sample.CPUTime > previous.CPUTime {
+				cpuRate = (sample.CPUTime - previous.CPUTime).Seconds() / interval
+			}
+
+			for _, series := range []*resourceUsageSeries{&cpu, &rss, &ctxSwitches, &hostCPU} {
+				series.X = append(series.X, elapsed)
+			}
+			cpu.Y = append(cpu.Y, cpuRate)
+			rss.Y = append(rss.Y, float64(sample.RSS)/(1<<20))
+			ctxSwitches.Y = append(ctxSwitches.Y, switchesRate)
+			hostCPU.Y = append(hostCPU.Y, sample.HostCPU)
+			previous = sample
+		}
+
+		s.Plots[0].Series = append(s.Plots[0].Series, cpu)
+		s.Plots[1].Series = append(s.Plots[1].Series, rss)
+		s.Plots[2].Series = append(s.Plots[2].Series, ctxSwitches)
+		s.Plots[3].Series = append(s.Plots[3].Series, hostCPU)
+	}
+
+	s.SubText = "Resource usage of the benchmark process tree over time (seconds since the job started)"
+	if len(missing) > 0 {
+		s.SubText += fmt.Sprintf(", no samples recorded for: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// ResourceUsageChart plots the resource usage (CPU, memory, context switches and host CPU utilization) sampled while
+// each job ran, usage is indexed by job ID (see LoadResourceUsage)
+func ResourceUsageChart(title string, usage map[string]*core.ResourceUsage) SectionConfig {
+	if title == "" {
+		title = "Resource usage"
+	}
+	plots := []resourceUsagePlot{}
+	for _, yTitle := range []string{"CPU (cores)", "RSS (MiB)", "Context switches/s", "Host CPU (%)"} {
+		plots = append(plots, resourceUsagePlot{ChartId: uniqueChartName(), YTitle: yTitle})
+	}
+	return &resourceUsageChartSection{
+		baseSection: baseSection{
+			Type:  "resource_usage_chart",
+			Title: title,
+		},
+		usage: usage,
+		Plots: plots,
+	}
+}
+
+// LoadResourceUsage loads the resource usage samples of the given jobs, indexed by job ID. Jobs without samples
+// (e.g. sampling disabled in the worker) are omitted.
+func LoadResourceUsage(c ResourcesClient, jobs []*core.JobRecord) (map[string]*core.ResourceUsage, error) {
+	usage := map[string]*core.ResourceUsage{}
+	for _, job := range jobs {
+		if job.Resources == "" {
+			continue
+		}
+		buf := bytes.Buffer{}
+		if err := c.LoadResourcesArtifact(job, &buf); err != nil {
+			return nil, fmt.Errorf("Failed to load resource usage of job %s: %v", job.Id, err)
+		}
+		jobUsage, err := core.LoadResourceUsage(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("Invalid resource usage of job %s: %v", job.Id, err)
+		}
+		usage[job.Id] = jobUsage
+	}
+	return usage, nil
+}
@@ -8,7 +8,9 @@ import (
 
 type resultsDeltaRow struct {
 	BenchmarkName string
-	Values        []string
+	Values        []resultsCell
+	// Delta is statistically significant
+	Significant bool
 }
 
 type resultsDeltaTableSection struct {
@@ -41,7 +43,9 @@ func (s *resultsDeltaTableSection) fillData(dt *dataTableImpl) error {
 		return fmt.Errorf("Input table is not a comparison")
 	}
 
-	rows := filterByBenchmarkName(table.Rows, s.BenchmarkFilter)
+	s.SubText = fmt.Sprintf("Values are %s ± %s", dt.uncertainty.CenterDescription(), dt.uncertainty.Description())
+
+	rows := dt.selectRows(table, s.BenchmarkFilter)
 
 	s.JobLabels = dt.jobLabels
 	s.ResultsRows = make([]resultsDeltaRow, len(rows))
@@ -49,15 +53,22 @@ func (s *resultsDeltaTableSection) fillData(dt *dataTableImpl) error {
 	for i, row := range rows {
 		tr := &s.ResultsRows[i]
 		tr.BenchmarkName = row.Benchmark
-		tr.Values = make([]string, len(s.JobLabels)+1)
+		tr.Values = make([]resultsCell, len(s.JobLabels)+1)
 		for j, m := range row.Metrics {
-			_, _, tr.Values[j] = valueDeviationAndScaledString(m)
+			c := &tr.Values[j]
+			c.SortKey, _, _, c.Label = dt.uncertainty.optionalValueErrorsAndScaledString(m)
 		}
 
-		if row.Delta == "~" {
-			tr.Values[len(s.JobLabels)] = "Inconclusive"
+		delta := &tr.Values[len(s.JobLabels)]
+		if countPresent(row) < len(row.Metrics) {
+			delta.Label = kMissingValueLabel
+		} else if row.Delta == "~" {
+			delta.Label = "Inconclusive"
+			delta.SortKey = new(float64)
 		} else {
-			tr.Values[len(s.JobLabels)] = fmt.Sprintf("%+.1f%%", row.PctDelta)
+			delta.Label = fmt.Sprintf("%+.1f%%", row.PctDelta)
+			delta.SortKey = &row.PctDelta
+			tr.Significant = true
 		}
 
 	}
@@ -66,11 +77,13 @@ func (s *resultsDeltaTableSection) fillData(dt *dataTableImpl) error {
 }
 
 func ResultsDeltaTable(metric Metric, filterExpr string, hidden bool) SectionConfig {
+	filter, filterErr := compileFilter(filterExpr)
 	return &resultsDeltaTableSection{
 		baseSection: baseSection{
 			Type:            "results_delta_table",
 			Title:           "",
-			BenchmarkFilter: compileFilter(filterExpr),
+			BenchmarkFilter: filter,
+			filterErr:       filterErr,
 		},
 		Metric: metric,
 		Hidden: hidden,
@@ -6,9 +6,15 @@ import (
 	"golang.org/x/perf/benchstat"
 )
 
+// Table cell, with the numeric value used to sort rows client-side (nil if the value is missing)
+type resultsCell struct {
+	Label   string
+	SortKey *float64
+}
+
 type resultsRow struct {
 	BenchmarkName string
-	Values        []string
+	Values        []resultsCell
 }
 
 type resultsTableSection struct {
@@ -37,7 +43,9 @@ func (s *resultsTableSection) fillData(dt *dataTableImpl) error {
 		return fmt.Errorf("Unknow table metric: %s", s.Metric)
 	}
 
-	rows := filterByBenchmarkName(table.Rows, s.BenchmarkFilter)
+	s.SubText = fmt.Sprintf("Values are %s ± %s", dt.uncertainty.CenterDescription(), dt.uncertainty.Description())
+
+	rows := dt.selectRows(table, s.BenchmarkFilter)
 
 	s.JobLabels = dt.jobLabels
 	s.ResultsRows = make([]resultsRow, len(rows))
@@ -45,10 +53,10 @@ func (s *resultsTableSection) fillData(dt *dataTableImpl) error {
 	for i, row := range rows {
 		tr := &s.ResultsRows[i]
 		tr.BenchmarkName = row.Benchmark
-		tr.Values = make([]string, len(s.JobLabels))
+		tr.Values = make([]resultsCell, len(s.JobLabels))
 		for j, m := range row.Metrics {
-			_, _, tr.Values[j] = valueDeviationAndScaledString(m)
-
+			c := &tr.Values[j]
+			c.SortKey, _, _, c.Label = dt.uncertainty.optionalValueErrorsAndScaledString(m)
 		}
 	}
 
@@ -56,11 +64,13 @@ func (s *resultsTableSection) fillData(dt *dataTableImpl) error {
 }
 
 func ResultsTable(metric Metric, filterExpr string, hidden bool) SectionConfig {
+	filter, filterErr := compileFilter(filterExpr)
 	return &resultsTableSection{
 		baseSection: baseSection{
 			Type:            "results_table",
 			Title:           "",
-			BenchmarkFilter: compileFilter(filterExpr),
+			BenchmarkFilter: filter,
+			filterErr:       filterErr,
 		},
 		Metric: metric,
 		Hidden: hidden,
@@ -10,8 +10,9 @@ import (
 type trendChartSeries struct {
 	BenchmarkName string
 	JobIds        []string
-	Values        []float64
-	Deviation     []float64
+	Values        []*float64
+	ErrorMinus    []*float64
+	ErrorPlus     []*float64
 	HoverLabels   []string
 }
 
@@ -50,7 +51,12 @@ func (s *trendChartSection) fillData(dt *dataTableImpl) error {
 		return fmt.Errorf("Unknow table metric: %s", s.Metric)
 	}
 
-	rows := filterByBenchmarkName(table.Rows, s.BenchmarkFilter)
+	s.SubText = subTextWithFilter(
+		fmt.Sprintf("Points represent %s, error bars represent %s", dt.uncertainty.CenterDescription(), dt.uncertainty.Description()),
+		s.BenchmarkFilter,
+	)
+
+	rows := dt.selectRows(table, s.BenchmarkFilter)
 
 	s.NumBenchmarks = len(rows)
 	s.JobLabels = dt.jobLabels
@@ -63,12 +69,13 @@ func (s *trendChartSection) fillData(dt *dataTableImpl) error {
 		sr.BenchmarkName = row.Benchmark
 		sr.JobIds = s.JobIds
 
-		sr.Values = make([]float64, len(s.JobIds))
-		sr.Deviation = make([]float64, len(s.JobIds))
+		sr.Values = make([]*float64, len(s.JobIds))
+		sr.ErrorMinus = make([]*float64, len(s.JobIds))
+		sr.ErrorPlus = make([]*float64, len(s.JobIds))
 		sr.HoverLabels = make([]string, len(s.JobIds))
 
 		for j, m := range row.Metrics {
-			sr.Values[j], sr.Deviation[j], sr.HoverLabels[j] = valueDeviationAndScaledString(m)
+			sr.Values[j], sr.ErrorMinus[j], sr.ErrorPlus[j], sr.HoverLabels[j] = dt.uncertainty.optionalValueErrorsAndScaledString(m)
 		}
 	}
 
@@ -79,16 +86,13 @@ func TrendChart(title string, metric Metric, filterExpr string) SectionConfig {
 	if title == "" {
 		title = fmt.Sprintf("%s trend", metric)
 	}
-	subtext := fmt.Sprintf("Error bars represent %.0f%% confidence interval", kCentilePercent)
-	if filterExpr != "" {
-		subtext = fmt.Sprintf("%s, benchmarks filter: '%s'", subtext, filterExpr)
-	}
+	filter, filterErr := compileFilter(filterExpr)
 	return &trendChartSection{
 		baseSection: baseSection{
 			Type:            "trend_chart",
 			Title:           title,
-			SubText:         subtext,
-			BenchmarkFilter: compileFilter(filterExpr),
+			BenchmarkFilter: filter,
+			filterErr:       filterErr,
 		},
 		Metric:  metric,
 		ChartId: uniqueChartName(),
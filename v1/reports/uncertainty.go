@@ -0,0 +1,239 @@
+package reports
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/montanaflynn/stats"
+	"golang.org/x/perf/benchstat"
+)
+
+// UncertaintyMethod selects how error bars and ± values are computed from a set of samples
+type UncertaintyMethod string
+
+const (
+	// Distance between the mean and the given percentile (symmetric), the historical default
+	CentileDeviation = UncertaintyMethod("centile_deviation")
+	// Central range of the samples, e.g. 90% confidence -> 5th to 95th percentile around the mean
+	PercentileRange = UncertaintyMethod("percentile_range")
+	// Confidence interval of the mean based on standard error (normal approximation)
+	StandardError = UncertaintyMethod("standard_error")
+	// Bootstrap confidence interval of the mean
+	BootstrapMean = UncertaintyMethod("bootstrap_mean")
+	// Bootstrap confidence interval of the median (values are reported as median rather than mean)
+	BootstrapMedian = UncertaintyMethod("bootstrap_median")
+)
+
+// Uncertainty model applied to all values presented in a report
+type Uncertainty struct {
+	Method UncertaintyMethod
+	// Confidence level in percent, in the range (0, 100)
+	Confidence float64
+}
+
+// DefaultUncertainty is used if a report does not configure a different model
+var DefaultUncertainty = Uncertainty{
+	Method:     CentileDeviation,
+	Confidence: kCentilePercent,
+}
+
+// ParseUncertainty creates and validates an uncertainty model.
+// An empty method selects the default method, a zero confidence selects the default confidence.
+func ParseUncertainty(method string, confidence float64) (Uncertainty, error) {
+	u := Uncertainty{
+		Method:     UncertaintyMethod(method),
+		Confidence: confidence,
+	}
+	if u.Method == "" {
+		u.Method = DefaultUncertainty.Method
+	}
+	if u.Confidence == 0 {
+		u.Confidence = DefaultUncertainty.Confidence
+	}
+	return u, u.validate()
+}
+
+func (u Uncertainty) validate() error {
+	switch u.Method {
+	case CentileDeviation, PercentileRange, StandardError, BootstrapMean, BootstrapMedian:
+	default:
+		return fmt.Errorf("unknown uncertainty method: %s", u.Method)
+	}
+	if u.Confidence <= 0 || u.Confidence >= 100 {
+		return fmt.Errorf("invalid confidence level: %v (must be between 0 and 100)", u.Confidence)
+	}
+	return nil
+}
+
+// Description of the method, suitable to be displayed in a section subtext
+func (u Uncertainty) Description() string {
+	switch u.Method {
+	case CentileDeviation:
+		return fmt.Sprintf("distance between mean and %s percentile", ordinal(u.Confidence))
+	case PercentileRange:
+		lowPercent := (100 - u.Confidence) / 2
+		return fmt.Sprintf(
+			"range between %s and %s percentile of samples",
+			ordinal(lowPercent),
+			ordinal(100-lowPercent),
+		)
+	case StandardError:
+		return fmt.Sprintf("%v%% confidence interval of the mean (standard error)", u.Confidence)
+	case BootstrapMean:
+		return fmt.Sprintf("%v%% confidence interval of the mean (bootstrap, %d resamples)", u.Confidence, kBootstrapResamples)
+	case BootstrapMedian:
+		return fmt.Sprintf("%v%% confidence interval of the median (bootstrap, %d resamples)", u.Confidence, kBootstrapResamples)
+	default:
+		return string(u.Method)
+	}
+}
+
+// CenterDescription is the name of the central value presented (e.g. 'mean')
+func (u Uncertainty) CenterDescription() string {
+	if u.Method == BootstrapMedian {
+		return "median"
+	}
+	return "mean"
+}
+
+// Compute the central value and the (non-negative) distance of the lower and upper bound from it.
+// The mean of values is passed in, since benchstat already computed it.
+func (u Uncertainty) estimate(values []float64, mean float64) (float64, float64, float64) {
+	center := mean
+	if u.Method == BootstrapMedian {
+		center = quantile(sortedCopy(values), 0.5)
+	}
+
+	var low, high float64
+	switch u.Method {
+	case CentileDeviation:
+		centile, err := stats.Percentile(values, u.Confidence)
+		if err != nil {
+			panic(fmt.Sprintf("Failed to calculate percentile for %+v: %v", values, err))
+		}
+		deviation := centile - center
+		return center, deviation, deviation
+	case PercentileRange:
+		sorted := sortedCopy(values)
+		alpha := (1 - u.Confidence/100) / 2
+		low, high = quantile(sorted, alpha), quantile(sorted, 1-alpha)
+	case StandardError:
+		if len(values) < 2 {
+			return center, 0, 0
+		}
+		sd, err := stats.StandardDeviationSample(values)
+		if err != nil {
+			panic(fmt.Sprintf("Failed to calculate standard deviation for %+v: %v", values, err))
+		}
+		z := stats.NormPpf(1-(1-u.Confidence/100)/2, 0, 1)
+		margin := z * sd / math.Sqrt(float64(len(values)))
+		return center, margin, margin
+	case BootstrapMean:
+		low, high = bootstrapInterval(values, u.Confidence, sampleMean)
+	case BootstrapMedian:
+		low, high = bootstrapInterval(values, u.Confidence, func(xs []float64) float64 {
+			return quantile(sortedCopy(xs), 0.5)
+		})
+	default:
+		panic(fmt.Sprintf("unknown uncertainty method: %s", u.Method))
+	}
+
+	return center, math.Max(0, center-low), math.Max(0, high-center)
+}
+
+// Compute value and uncertainty of a metric, and format them into a scaled string.
+// Returns central value, error below, error above, and label.
+func (u Uncertainty) valueErrorsAndScaledString(m *benchstat.Metrics) (float64, float64, float64, string) {
+	if isMissing(m) {
+		return 0, 0, 0, kMissingValueLabel
+	}
+	center, errMinus, errPlus := u.estimate(m.RValues, m.Mean)
+	scaler := benchstat.NewScaler(center, m.Unit)
+	if errMinus == errPlus {
+		return center, errMinus, errPlus, fmt.Sprintf("%s ± %s", scaler(center), scaler(errPlus))
+	}
+	return center, errMinus, errPlus, fmt.Sprintf("%s -%s +%s", scaler(center), scaler(errMinus), scaler(errPlus))
+}
+
+// Like valueErrorsAndScaledString, but values are nil if the metric has no data, so they are rendered as gaps
+func (u Uncertainty) optionalValueErrorsAndScaledString(m *benchstat.Metrics) (*float64, *float64, *float64, string) {
+	if isMissing(m) {
+		return nil, nil, nil, kMissingValueLabel
+	}
+	value, errMinus, errPlus, label := u.valueErrorsAndScaledString(m)
+	return &value, &errMinus, &errPlus, label
+}
+
+const (
+	kBootstrapResamples = 1000
+	// Fixed seed, so that reports generated from the same data are identical
+	kBootstrapSeed = 42
+)
+
+func bootstrapInterval(values []float64, confidence float64, statistic func([]float64) float64) (float64, float64) {
+	if len(values) < 2 {
+		v := statistic(values)
+		return v, v
+	}
+	rng := rand.New(rand.NewSource(kBootstrapSeed))
+	resample := make([]float64, len(values))
+	estimates := make([]float64, kBootstrapResamples)
+	for i := range estimates {
+		for j := range resample {
+			resample[j] = values[rng.Intn(len(values))]
+		}
+		estimates[i] = statistic(resample)
+	}
+	sort.Float64s(estimates)
+	alpha := (1 - confidence/100) / 2
+	return quantile(estimates, alpha), quantile(estimates, 1-alpha)
+}
+
+func sampleMean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func sortedCopy(values []float64) []float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	return sorted
+}
+
+// Quantile q (0 <= q <= 1) of the sorted values, with linear interpolation between closest ranks
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	position := q * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+	fraction := position - float64(lower)
+	return sorted[lower] + fraction*(sorted[upper]-sorted[lower])
+}
+
+// Format a percentile as ordinal, e.g. 90 -> 90th, 2.5 -> 2.5th
+func ordinal(percent float64) string {
+	suffix := "th"
+	if percent == math.Trunc(percent) {
+		switch int(percent) % 100 {
+		case 11, 12, 13:
+		default:
+			switch int(percent) % 10 {
+			case 1:
+				suffix = "st"
+			case 2:
+				suffix = "nd"
+			case 3:
+				suffix = "rd"
+			}
+		}
+	}
+	return fmt.Sprintf("%v%s", percent, suffix)
+}
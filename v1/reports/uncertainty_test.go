@@ -0,0 +1,107 @@
+package reports
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUncertainty_Estimate(t *testing.T) {
+	values := []float64{10, 11, 12, 13, 14, 15, 16, 17, 18, 19}
+	const mean = 14.5
+
+	testCases := []struct {
+		method         UncertaintyMethod
+		confidence     float64
+		expectedCenter float64
+		expectedMinus  float64
+		expectedPlus   float64
+	}{
+		{CentileDeviation, 90, 14.5, 3.5, 3.5},
+		{PercentileRange, 90, 14.5, 4.05, 4.05},
+		{PercentileRange, 50, 14.5, 2.25, 2.25},
+		{StandardError, 95, 14.5, 1.8765, 1.8765},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			string(tc.method),
+			func(t *testing.T) {
+				u, err := ParseUncertainty(string(tc.method), tc.confidence)
+				if err != nil {
+					t.Fatal(err)
+				}
+				center, errMinus, errPlus := u.estimate(values, mean)
+				const tolerance = 0.001
+				if math.Abs(center-tc.expectedCenter) > tolerance ||
+					math.Abs(errMinus-tc.expectedMinus) > tolerance ||
+					math.Abs(errPlus-tc.expectedPlus) > tolerance {
+					t.Fatalf(
+						"Expected: %v -%v +%v, actual: %v -%v +%v",
+						tc.expectedCenter, tc.expectedMinus, tc.expectedPlus,
+						center, errMinus, errPlus,
+					)
+				}
+			},
+		)
+	}
+}
+
+func TestUncertainty_Bootstrap(t *testing.T) {
+	values := []float64{10, 11, 12, 13, 14, 15, 16, 17, 18, 100}
+	const mean = 22.6
+
+	for _, method := range []UncertaintyMethod{BootstrapMean, BootstrapMedian} {
+		t.Run(
+			string(method),
+			func(t *testing.T) {
+				u, err := ParseUncertainty(string(method), 95)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				center, errMinus, errPlus := u.estimate(values, mean)
+				if errMinus <= 0 || errPlus <= 0 {
+					t.Fatalf("Expected non-zero interval, got: -%v +%v", errMinus, errPlus)
+				}
+				if center-errMinus < 10 || center+errPlus > 100 {
+					t.Fatalf("Interval outside of samples range: %v -%v +%v", center, errMinus, errPlus)
+				}
+
+				// Results are deterministic
+				center2, errMinus2, errPlus2 := u.estimate(values, mean)
+				if center != center2 || errMinus != errMinus2 || errPlus != errPlus2 {
+					t.Fatalf("Bootstrap estimate is not deterministic")
+				}
+			},
+		)
+	}
+
+	// The median is robust to the outlier, the mean is not
+	u, _ := ParseUncertainty(string(BootstrapMedian), 95)
+	if median, _, _ := u.estimate(values, mean); median != 14.5 {
+		t.Fatalf("Unexpected median: %v", median)
+	}
+}
+
+func TestParseUncertainty(t *testing.T) {
+	u, err := ParseUncertainty("", 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if u != DefaultUncertainty {
+		t.Fatalf("Expected default uncertainty, got: %+v", u)
+	}
+
+	invalid := []struct {
+		method     string
+		confidence float64
+	}{
+		{"foo", 90},
+		{string(StandardError), 100},
+		{string(StandardError), -5},
+	}
+	for _, tc := range invalid {
+		if _, err := ParseUncertainty(tc.method, tc.confidence); err == nil {
+			t.Fatalf("Expected error for %+v", tc)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package reports
+
+import (
+	"fmt"
+
+	"golang.org/x/perf/benchstat"
+)
+
+// Each trace is the set of samples for one job, across all benchmarks.
+// Samples are flattened and paired with the name of the benchmark they belong to.
+type violinChartTrace struct {
+	Name           string
+	BenchmarkNames []string
+	Values         []float64
+	Labels         []string
+}
+
+type violinChartSection struct {
+	baseSection
+	Metric        Metric
+	ChartId       string
+	NumBenchmarks int
+	Traces        []violinChartTrace
+}
+
+func (s *violinChartSection) fillData(dt *dataTableImpl) error {
+	table, xTitle, err := dt.samplesTable(s.Metric)
+	if err != nil {
+		return err
+	}
+	s.XTitle = xTitle
+
+	rows := dt.selectRows(table, s.BenchmarkFilter)
+
+	s.NumBenchmarks = len(rows)
+	s.Traces = make([]violinChartTrace, len(dt.jobs))
+
+	for i := range dt.jobs {
+		tr := &s.Traces[i]
+		tr.Name = dt.jobLabels[i]
+		tr.BenchmarkNames = []string{}
+		tr.Values = []float64{}
+		tr.Labels = []string{}
+
+		for _, row := range rows {
+			m := row.Metrics[i]
+			if len(m.Values) == 0 {
+				// Job did not run this benchmark
+				continue
+			}
+			scaler := benchstat.NewScaler(m.Mean, m.Unit)
+			for _, value := range m.Values {
+				tr.BenchmarkNames = append(tr.BenchmarkNames, row.Benchmark)
+				tr.Values = append(tr.Values, value)
+				tr.Labels = append(tr.Labels, scaler(value))
+			}
+		}
+	}
+	return nil
+}
+
+func ViolinChart(title string, metric Metric, filterExpr string) SectionConfig {
+	if title == "" {
+		title = fmt.Sprintf("%s samples distribution", metric)
+	}
+	filter, filterErr := compileFilter(filterExpr)
+	return &violinChartSection{
+		baseSection: baseSection{
+			Type:            "violin_chart",
+			Title:           title,
+			SubText:         subTextWithFilter("Distribution of individual samples (including outliers)", filter),
+			BenchmarkFilter: filter,
+			filterErr:       filterErr,
+		},
+		Metric:  metric,
+		ChartId: uniqueChartName(),
+	}
+}